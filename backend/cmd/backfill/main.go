@@ -0,0 +1,105 @@
+// Command backfill assigns TMDB IDs to movies that were stored before
+// spoilers were keyed by tmdb_id. Each legacy row is matched against a TMDB
+// search on its title; rows are only updated when exactly one result has the
+// same title and release year, everything else is logged for manual review.
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+
+	"spoiler_api/internal/config"
+	"spoiler_api/internal/logging"
+	"spoiler_api/internal/models"
+	"spoiler_api/internal/services"
+	"spoiler_api/internal/upstream"
+)
+
+const pageSize = 100
+
+var (
+	errNoMatch   = errors.New("no TMDB movie with the same title and year")
+	errAmbiguous = errors.New("several TMDB movies share this title and year")
+)
+
+func main() {
+	logging.Init(os.Stdout)
+	cfg := config.LoadConfig()
+	logging.RegisterSecrets(cfg.TMDBAPIKey, cfg.SupabaseKey)
+
+	if cfg.TMDBAPIKey == "" {
+		fatal("TMDB_API_KEY environment variable is required")
+	}
+	if cfg.SupabaseURL == "" || cfg.SupabaseKey == "" {
+		fatal("SUPABASE_URL and SUPABASE_KEY environment variables are required")
+	}
+
+	tmdbService := services.NewTMDBService(cfg.TMDBAPIKey, upstream.New("tmdb", upstream.Options{Timeout: cfg.TMDBTimeout}))
+	supabaseService := services.NewSupabaseService(cfg.SupabaseURL, cfg.SupabaseKey, upstream.New("supabase", upstream.Options{Timeout: cfg.SupabaseTimeout}))
+
+	ctx := context.Background()
+	updated, skipped := 0, 0
+	for {
+		// Updated rows drop out of the tmdb_id=is.null filter, so only skipped rows shift the offset
+		movies, err := supabaseService.ListMoviesWithoutTMDBID(ctx, skipped, pageSize)
+		if err != nil {
+			fatal("Failed to list legacy movies", "error", err)
+		}
+		if len(movies) == 0 {
+			break
+		}
+
+		for _, movie := range movies {
+			tmdbID, err := matchTMDBID(ctx, tmdbService, movie)
+			if err != nil {
+				slog.Warn("Skipping movie", "title", movie.Title, "year", movie.Year, "error", err)
+				skipped++
+				continue
+			}
+
+			if err := supabaseService.SetTMDBID(ctx, movie.RowID, tmdbID); err != nil {
+				slog.Warn("Skipping movie", "title", movie.Title, "year", movie.Year, "error", err)
+				skipped++
+				continue
+			}
+
+			slog.Info("Backfilled movie", "title", movie.Title, "year", movie.Year, "tmdb_id", tmdbID)
+			updated++
+		}
+	}
+
+	slog.Info("Backfill complete", "updated", updated, "skipped", skipped)
+}
+
+// fatal logs an error and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// matchTMDBID finds the single TMDB movie with the same title and year as a legacy row
+func matchTMDBID(ctx context.Context, tmdbService *services.TMDBService, movie services.LegacyMovie) (int, error) {
+	results, err := tmdbService.SearchMovies(ctx, movie.Title, services.DefaultLanguage)
+	if err != nil {
+		return 0, err
+	}
+
+	var matches []models.TMDBMovie
+	for _, result := range results {
+		if strings.EqualFold(result.Title, movie.Title) && tmdbService.ExtractYear(result.ReleaseDate) == movie.Year {
+			matches = append(matches, result)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return 0, errNoMatch
+	case 1:
+		return matches[0].ID, nil
+	default:
+		return 0, errAmbiguous
+	}
+}
@@ -1,27 +1,56 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"spoiler_api/internal/config"
 	"spoiler_api/internal/handlers"
+	"spoiler_api/internal/logging"
+	"spoiler_api/internal/middleware"
+	"spoiler_api/internal/ratelimit"
 	"spoiler_api/internal/routes"
 	"spoiler_api/internal/services"
+	"spoiler_api/internal/tracing"
+	"spoiler_api/internal/upstream"
 )
 
 func main() {
+	// Structured JSON logs on stdout; installed first so config warnings are JSON too
+	logging.Init(os.Stdout)
+
 	// Load configuration from environment variables
 	cfg := config.LoadConfig()
 
+	if err := logging.SetLevel(cfg.LogLevel); err != nil {
+		slog.Warn("Falling back to info logging", "error", err)
+	}
+
+	// Keep every credential out of log lines and error responses
+	logging.RegisterSecrets(cfg.TMDBAPIKey, cfg.GeminiAPIKey, cfg.OpenAIAPIKey, cfg.SupabaseKey, cfg.AdminToken)
+
 	// Validate required environment variables
 	if cfg.TMDBAPIKey == "" {
-		log.Fatal("TMDB_API_KEY environment variable is required")
+		fatal("TMDB_API_KEY environment variable is required")
 	}
-	if cfg.GeminiAPIKey == "" {
-		log.Fatal("GEMINI_API_KEY environment variable is required")
+
+	// Export traces when configured
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TracingExporter, cfg.TracingFile)
+	if err != nil {
+		fatal("Failed to set up tracing", "error", err)
+	}
+	if cfg.TracingExporter != "none" {
+		slog.Info("Tracing enabled", "exporter", cfg.TracingExporter)
 	}
 
 	// Set Gin mode based on environment
@@ -31,53 +60,216 @@ func main() {
 		gin.SetMode(gin.DebugMode)
 	}
 
-	// Create Gin router
-	router := gin.Default()
+	// Create Gin router with structured request logging in place of Gin's text logger
+	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		fatal("Invalid TRUSTED_PROXIES", "error", err)
+	}
+	router.Use(middleware.RequestID(), middleware.Tracing(), middleware.Logger(), gin.Recovery())
 
-	// Add CORS middleware
-	router.Use(corsMiddleware())
+	// Add CORS and metrics middleware
+	cors, err := middleware.CORS(middleware.CORSOptions{
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
+		AllowedMethods:   cfg.CORSAllowedMethods,
+		AllowedHeaders:   cfg.CORSAllowedHeaders,
+		MaxAge:           cfg.CORSMaxAge,
+		AllowCredentials: cfg.CORSAllowCredentials,
+	})
+	if err != nil {
+		fatal("Invalid CORS configuration", "error", err)
+	}
+	router.Use(cors)
+	router.Use(middleware.Metrics())
 
 	// Initialize services
-	tmdbService := services.NewTMDBService(cfg.TMDBAPIKey)
-	geminiService := services.NewGeminiService(cfg.GeminiAPIKey)
-
-	// Initialize Supabase service (optional — app works without it)
-	var supabaseService *services.SupabaseService
-	if cfg.SupabaseURL != "" && cfg.SupabaseKey != "" {
-		supabaseService = services.NewSupabaseService(cfg.SupabaseURL, cfg.SupabaseKey)
-		log.Println("Supabase database caching enabled")
+	tmdbService := services.NewTMDBService(cfg.TMDBAPIKey, upstream.New("tmdb", upstream.Options{Timeout: cfg.TMDBTimeout}))
+	spoilerCache := services.NewSpoilerCache(cfg.CacheMaxEntries, cfg.CacheMaxBytes, cfg.CacheTTL)
+	budget := ratelimit.NewDailyBudget(int64(cfg.GeminiDailyTokenBudget))
+	spoilerGenerator, err := newSpoilerGenerator(cfg, spoilerCache, budget)
+	if err != nil {
+		fatal("Failed to create spoiler provider", "error", err)
+	}
+	slog.Info("Using spoiler provider", "provider", cfg.LLMProvider)
+
+	// Initialize the movie store (optional — app works without it)
+	movieStore, err := newMovieStore(cfg)
+	if err != nil {
+		fatal("Failed to open movie store", "error", err)
+	}
+	// API keys live in the same database as the movies
+	var apiKeys *services.APIKeyService
+	if keyStore, ok := movieStore.(services.APIKeyStore); ok {
+		apiKeys = services.NewAPIKeyService(keyStore)
+	}
+
+	if movieStore != nil {
+		slog.Info("Database caching enabled", "backend", cfg.StoreBackend)
+		movieStore = services.NewTracedStore(movieStore, cfg.StoreBackend)
 	} else {
-		log.Println("Warning: SUPABASE_URL/SUPABASE_KEY not set — running without database caching")
+		slog.Warn("STORE_BACKEND=none — running without database caching")
 	}
+	// Scene outlines for progress spoilers are stored alongside them too
+	outlineStore, _ := movieStore.(services.OutlineStore)
+
+	// Database writes go through a durable write-behind queue
+	var writeQueue *services.WriteQueue
+	if movieStore != nil {
+		writeQueue, err = services.NewWriteQueue(movieStore, cfg.WriteQueuePath)
+		if err != nil {
+			fatal("Failed to open write queue", "error", err)
+		}
+	}
+
+	// Genre lists are loaded per language in the background, with a bounded wait at startup, and refreshed periodically
+	genres := services.NewGenreCache(tmdbService, cfg.Languages, cfg.GenreRefreshInterval)
+	defer genres.Close()
 
 	// Initialize handlers
-	movieHandler := handlers.NewMovieHandler(tmdbService, geminiService, supabaseService)
+	policy := handlers.GenerationPolicy{
+		Limiter: ratelimit.New("generations", cfg.GenerationsPerHour, time.Hour, cfg.GenerationBurst),
+	}
+	if cfg.LLMProvider == "gemini" {
+		policy.Budget = budget
+	}
+	switch cfg.GenerationOnDisconnect {
+	case "detach":
+		policy.Detach = true
+	case "cancel":
+	default:
+		fatal("Unknown GENERATION_ON_DISCONNECT (expected detach or cancel)", "value", cfg.GenerationOnDisconnect)
+	}
+	movieHandler := handlers.NewMovieHandler(tmdbService, spoilerGenerator, spoilerCache, movieStore, outlineStore, genres, writeQueue, cfg.Languages, policy)
+
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeys)
 
 	// Setup routes
-	routes.SetupRoutes(router, movieHandler)
+	auth, err := middleware.APIKeyAuth(apiKeys, cfg.AnonymousScopes)
+	if err != nil {
+		fatal("Invalid ANONYMOUS_SCOPES", "error", err)
+	}
+	requestLimiter := ratelimit.New("requests", cfg.RequestsPerMinute, time.Minute, cfg.RequestBurst)
+	routes.SetupRoutes(router, movieHandler, apiKeyHandler, routes.Guards{
+		Auth:      auth,
+		RateLimit: middleware.RateLimit(requestLimiter),
+		Admin:     middleware.AdminAuth(cfg.AdminToken),
+	})
 
 	// Start server
 	address := fmt.Sprintf(":%s", cfg.Port)
-	log.Printf("Starting SpoilerHub API server on %s\n", address)
+	server := &http.Server{Addr: address, Handler: router}
 
-	if err := router.Run(address); err != nil {
-		log.Fatalf("Failed to start server: %v\n", err)
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("Starting SpoilerHub API server", "address", address)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	// Wait for SIGINT/SIGTERM (or a failure to start listening)
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err := <-serverErr:
+		fatal("Failed to start server", "error", err)
+	case sig := <-stop:
+		slog.Info("Shutting down", "signal", sig.String(), "deadline", cfg.ShutdownTimeout.String())
 	}
+
+	shutdown(server, movieHandler, writeQueue, apiKeys, movieStore, shutdownTracing, cfg.ShutdownTimeout)
 }
 
-// corsMiddleware adds CORS headers to responses
-func corsMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
+// shutdown stops accepting connections, waits for in-flight requests and
+// spoiler generations (including detached ones) and then for queued database writes, all
+// within a single deadline. Requests still running at the deadline are
+// dropped; unapplied writes stay in the journal for the next start.
+func shutdown(server *http.Server, movieHandler *handlers.MovieHandler, writeQueue *services.WriteQueue, apiKeys *services.APIKeyService, movieStore services.MovieStore, shutdownTracing func(context.Context) error, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Warn("HTTP server did not shut down cleanly", "error", err, "dropped_generations", movieHandler.InFlightGenerations())
+	}
+
+	// Generations detached from disconnected clients are not tracked by the server
+	if !movieHandler.WaitForGenerations(ctx) {
+		slog.Warn("Shutdown deadline reached with detached generations running", "dropped_generations", movieHandler.InFlightGenerations())
+	}
+
+	// Requests finishing above may have queued more writes, so drain afterwards
+	if writeQueue != nil {
+		if remaining := writeQueue.Drain(ctx); remaining > 0 {
+			slog.Warn("Shutdown deadline reached with database writes pending; they will be replayed at next start", "pending", remaining)
+		} else {
+			slog.Info("All queued database writes applied")
+		}
+	}
+
+	// Write API key usage counted since the last flush
+	if apiKeys != nil {
+		apiKeys.Close(ctx)
+	}
+
+	if closer, ok := movieStore.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			slog.Error("Failed to close movie store", "error", err)
+		}
+	}
+
+	// Flush the spans recorded while draining
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("Failed to flush traces", "error", err)
+	}
+
+	slog.Info("Server stopped")
+}
+
+// fatal logs an error and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// newSpoilerGenerator creates the LLM provider selected by LLM_PROVIDER
+func newSpoilerGenerator(cfg *config.Config, cache *services.SpoilerCache, budget *ratelimit.DailyBudget) (services.SpoilerGenerator, error) {
+	switch cfg.LLMProvider {
+	case "gemini":
+		if cfg.GeminiAPIKey == "" {
+			return nil, fmt.Errorf("GEMINI_API_KEY environment variable is required for the gemini provider (set LLM_PROVIDER=offline for placeholder spoilers in development)")
+		}
+		return services.NewGeminiService(cfg.GeminiAPIKey, cfg.GeminiModel, upstream.New("gemini", upstream.Options{Timeout: cfg.LLMTimeout}), cache, budget), nil
+	case "openai":
+		return services.NewOpenAIService(cfg.OpenAIBaseURL, cfg.OpenAIAPIKey, cfg.OpenAIModel, upstream.New("openai", upstream.Options{Timeout: cfg.LLMTimeout}), cache), nil
+	case "offline":
+		// Placeholder spoilers are never stored, but users would still be served them
+		if cfg.Environment == "production" {
+			return nil, fmt.Errorf("LLM_PROVIDER=offline serves placeholder spoilers and is not allowed in production")
 		}
+		slog.Warn("LLM_PROVIDER=offline: serving placeholder spoilers, which are not stored in the database")
+		return services.NewOfflineService(), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM_PROVIDER %q (expected gemini, openai or offline)", cfg.LLMProvider)
+	}
+}
 
-		c.Next()
+// newMovieStore creates the persistence backend selected by STORE_BACKEND.
+// It returns a nil store for "none".
+func newMovieStore(cfg *config.Config) (services.MovieStore, error) {
+	switch cfg.StoreBackend {
+	case "supabase":
+		if cfg.SupabaseURL == "" || cfg.SupabaseKey == "" {
+			return nil, fmt.Errorf("SUPABASE_URL and SUPABASE_KEY environment variables are required for the supabase store")
+		}
+		return services.NewSupabaseService(cfg.SupabaseURL, cfg.SupabaseKey, upstream.New("supabase", upstream.Options{Timeout: cfg.SupabaseTimeout})), nil
+	case "sqlite":
+		store, err := services.NewSQLiteStore(cfg.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	case "none":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown STORE_BACKEND %q (expected supabase, sqlite or none)", cfg.StoreBackend)
 	}
 }
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"spoiler_api/internal/config"
+	"spoiler_api/internal/handlers"
+	"spoiler_api/internal/models"
+	"spoiler_api/internal/services"
+)
+
+func TestNewSpoilerGenerator(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		wantErr bool
+	}{
+		{name: "gemini without a key", cfg: config.Config{LLMProvider: "gemini", Environment: "development"}, wantErr: true},
+		{name: "gemini", cfg: config.Config{LLMProvider: "gemini", GeminiAPIKey: "gemini-key"}},
+		{name: "offline in development", cfg: config.Config{LLMProvider: "offline", Environment: "development"}},
+		{name: "offline in production", cfg: config.Config{LLMProvider: "offline", Environment: "production"}, wantErr: true},
+		{name: "unknown provider", cfg: config.Config{LLMProvider: "gpt"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := services.NewSpoilerCache(10, 1<<20, 0)
+			generator, err := newSpoilerGenerator(&tt.cfg, cache, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && generator == nil {
+				t.Error("no generator returned")
+			}
+		})
+	}
+}
+
+func TestShutdownDrainsRequestsAndWrites(t *testing.T) {
+	tests := []struct {
+		name        string
+		timeout     time.Duration
+		requestTime time.Duration // how long the in-flight request keeps running after shutdown starts
+		wantServed  bool
+	}{
+		{name: "in-flight request finishes and its write is applied", timeout: 5 * time.Second, requestTime: 50 * time.Millisecond, wantServed: true},
+		{name: "deadline cuts a request that runs too long", timeout: 50 * time.Millisecond, requestTime: time.Hour, wantServed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := services.NewSQLiteStore(filepath.Join(t.TempDir(), "spoilers.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+			writeQueue, err := services.NewWriteQueue(store, filepath.Join(t.TempDir(), "writes.jsonl"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The request queues its save just before it responds, after shutdown has begun
+			started := make(chan struct{})
+			stop := make(chan struct{})
+			defer close(stop)
+			mux := http.NewServeMux()
+			mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				select {
+				case <-time.After(tt.requestTime):
+				case <-stop:
+					return
+				}
+				if err := writeQueue.EnqueueSave(models.MovieResponse{TMDBID: 27205, Title: "Inception", Language: "en"}); err != nil {
+					t.Errorf("EnqueueSave: %v", err)
+				}
+				w.WriteHeader(http.StatusOK)
+			})
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			server := &http.Server{Handler: mux}
+			go server.Serve(listener)
+
+			served := make(chan bool, 1)
+			go func() {
+				resp, err := http.Get("http://" + listener.Addr().String() + "/slow")
+				if err == nil {
+					resp.Body.Close()
+				}
+				served <- err == nil && resp.StatusCode == http.StatusOK
+			}()
+			<-started
+
+			movieHandler := handlers.NewMovieHandler(nil, nil, services.NewSpoilerCache(10, 1<<20, 0), nil, nil, nil, nil, nil, handlers.GenerationPolicy{})
+			begin := time.Now()
+			shutdown(server, movieHandler, writeQueue, nil, nil, func(context.Context) error { return nil }, tt.timeout)
+			if elapsed := time.Since(begin); elapsed > tt.timeout+time.Second {
+				t.Errorf("shutdown took %v with a %v deadline", elapsed, tt.timeout)
+			}
+
+			if !tt.wantServed {
+				return
+			}
+			if ok := <-served; !ok {
+				t.Error("in-flight request was not answered")
+			}
+			if movie, err := store.FindMovie(context.Background(), 27205, "en"); err != nil || movie == nil {
+				t.Errorf("FindMovie = %v, %v; want the save queued during shutdown applied", movie, err)
+			}
+		})
+	}
+}
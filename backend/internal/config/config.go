@@ -1,29 +1,171 @@
 package config
 
 import (
+	"log/slog"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all application configuration
 type Config struct {
-	Port           string
-	TMDBAPIKey     string
-	GeminiAPIKey   string
-	Environment    string
-	SupabaseURL    string
-	SupabaseKey    string
+	Port         string
+	TMDBAPIKey   string
+	GeminiAPIKey string
+	Environment  string
+	LogLevel     string
+	SupabaseURL  string
+	SupabaseKey  string
+
+	// LLM provider selection: "gemini", "openai" or "offline"
+	LLMProvider   string
+	GeminiModel   string
+	OpenAIBaseURL string
+	OpenAIAPIKey  string
+	OpenAIModel   string
+
+	// Persistence backend: "supabase", "sqlite" or "none"
+	StoreBackend string
+	SQLitePath   string
+
+	// WriteQueuePath is the journal of database writes not yet applied
+	WriteQueuePath string
+
+	// In-memory spoiler cache bounds
+	CacheMaxEntries int
+	CacheMaxBytes   int
+	CacheTTL        time.Duration
+
+	// Per-upstream request timeouts
+	TMDBTimeout     time.Duration
+	LLMTimeout      time.Duration
+	SupabaseTimeout time.Duration
+
+	// Tracing exporter: "none", "otlp", "stdout" or "file"
+	TracingExporter string
+	TracingFile     string
+
+	// Per-client token buckets: API requests per minute and cache-miss
+	// generations per hour, each with a burst allowance. 0 disables a limit.
+	RequestsPerMinute  int
+	RequestBurst       int
+	GenerationsPerHour int
+	GenerationBurst    int
+
+	// GeminiDailyTokenBudget caps Gemini tokens per UTC day across all clients; 0 is unlimited
+	GeminiDailyTokenBudget int
+
+	// CORS policy. Origins may be exact, wildcard subdomain patterns such as
+	// https://*.vercel.app, or "*" for any origin (not with credentials).
+	CORSAllowedOrigins   []string
+	CORSAllowedMethods   []string
+	CORSAllowedHeaders   []string
+	CORSMaxAge           time.Duration
+	CORSAllowCredentials bool
+
+	// TrustedProxies lists the proxy IPs/CIDRs whose X-Forwarded-For is believed
+	// when identifying clients; empty trusts none
+	TrustedProxies []string
+
+	// GenerationOnDisconnect is what happens to a spoiler generation when its
+	// client disconnects: "detach" finishes and caches it, "cancel" stops it
+	GenerationOnDisconnect string
+
+	// ShutdownTimeout bounds how long shutdown waits for in-flight requests and queued database writes
+	ShutdownTimeout time.Duration
+
+	// AdminToken enables the /api/admin endpoints when set
+	AdminToken string
+
+	// AnonymousScopes are granted to requests without an API key
+	AnonymousScopes []string
+
+	// Languages are the language codes clients may ask for; the first one is
+	// served when a request names none of them
+	Languages []string
+
+	// GenreRefreshInterval is how often the cached TMDB genre lists are refreshed; 0 disables refreshing
+	GenreRefreshInterval time.Duration
 }
 
 // LoadConfig loads configuration from environment variables
 func LoadConfig() *Config {
-	return &Config{
+	cfg := &Config{
 		Port:         getEnv("PORT", "8080"),
 		TMDBAPIKey:   getEnv("TMDB_API_KEY", ""),
 		GeminiAPIKey: getEnv("GEMINI_API_KEY", ""),
 		Environment:  getEnv("ENVIRONMENT", "development"),
+		LogLevel:     getEnv("LOG_LEVEL", "info"),
 		SupabaseURL:  getEnv("SUPABASE_URL", ""),
 		SupabaseKey:  getEnv("SUPABASE_KEY", ""),
+
+		LLMProvider:   getEnv("LLM_PROVIDER", "gemini"),
+		GeminiModel:   getEnv("GEMINI_MODEL", "gemini-2.5-flash"),
+		OpenAIBaseURL: getEnv("OPENAI_BASE_URL", "http://localhost:11434"),
+		OpenAIAPIKey:  getEnv("OPENAI_API_KEY", ""),
+		OpenAIModel:   getEnv("OPENAI_MODEL", "llama3.1"),
+
+		StoreBackend: getEnv("STORE_BACKEND", ""),
+		SQLitePath:   getEnv("SQLITE_PATH", "spoilerhub.db"),
+
+		WriteQueuePath: getEnv("WRITE_QUEUE_PATH", "spoilerhub-writes.jsonl"),
+
+		CacheMaxEntries: getEnvInt("CACHE_MAX_ENTRIES", 1000),
+		CacheMaxBytes:   getEnvInt("CACHE_MAX_BYTES", 64<<20),
+		CacheTTL:        getEnvDuration("CACHE_TTL", 24*time.Hour),
+
+		TMDBTimeout:     getEnvDuration("TMDB_TIMEOUT", 10*time.Second),
+		LLMTimeout:      getEnvDuration("LLM_TIMEOUT", 90*time.Second),
+		SupabaseTimeout: getEnvDuration("SUPABASE_TIMEOUT", 10*time.Second),
+
+		TracingExporter: getEnv("TRACING_EXPORTER", "none"),
+		TracingFile:     getEnv("TRACING_FILE", "traces.jsonl"),
+
+		RequestsPerMinute:  getEnvInt("RATE_LIMIT_REQUESTS_PER_MINUTE", 60),
+		RequestBurst:       getEnvInt("RATE_LIMIT_REQUEST_BURST", 30),
+		GenerationsPerHour: getEnvInt("RATE_LIMIT_GENERATIONS_PER_HOUR", 10),
+		GenerationBurst:    getEnvInt("RATE_LIMIT_GENERATION_BURST", 3),
+
+		GeminiDailyTokenBudget: getEnvInt("GEMINI_DAILY_TOKEN_BUDGET", 0),
+
+		CORSAllowedOrigins:   getEnvList("CORS_ALLOWED_ORIGINS", "http://localhost:3000"),
+		CORSAllowedMethods:   getEnvList("CORS_ALLOWED_METHODS", "GET, POST, DELETE, OPTIONS"),
+		CORSAllowedHeaders:   getEnvList("CORS_ALLOWED_HEADERS", "Authorization, X-API-Key, X-Admin-Token, X-Request-ID, Content-Type, Accept, Accept-Language, Cache-Control"),
+		CORSMaxAge:           getEnvDuration("CORS_MAX_AGE", 12*time.Hour),
+		CORSAllowCredentials: getEnvBool("CORS_ALLOW_CREDENTIALS", false),
+
+		TrustedProxies: getEnvList("TRUSTED_PROXIES", ""),
+
+		GenerationOnDisconnect: getEnv("GENERATION_ON_DISCONNECT", "detach"),
+
+		ShutdownTimeout: getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+
+		AdminToken: getEnv("ADMIN_TOKEN", ""),
+
+		AnonymousScopes: getEnvList("ANONYMOUS_SCOPES", "read-cached,generate"),
+
+		Languages: getEnvList("SUPPORTED_LANGUAGES", "en,es,am"),
+
+		GenreRefreshInterval: getEnvDuration("GENRE_REFRESH_INTERVAL", 24*time.Hour),
 	}
+
+	// Default to Supabase when configured, otherwise keep spoilers in a local SQLite file
+	if cfg.StoreBackend == "" {
+		if cfg.SupabaseURL != "" && cfg.SupabaseKey != "" {
+			cfg.StoreBackend = "supabase"
+		} else {
+			cfg.StoreBackend = "sqlite"
+		}
+	}
+
+	// The development default only admits the local Nuxt server, so a deployed
+	// frontend would be blocked by every browser
+	if _, set := os.LookupEnv("CORS_ALLOWED_ORIGINS"); !set && cfg.Environment == "production" {
+		slog.Warn("CORS_ALLOWED_ORIGINS is not set in production; browsers may only call the API from the default origin", "origins", cfg.CORSAllowedOrigins)
+	}
+
+	return cfg
 }
 
 // getEnv retrieves environment variable or returns default
@@ -33,3 +175,56 @@ func getEnv(key, defaultVal string) string {
 	}
 	return defaultVal
 }
+
+// getEnvList retrieves a comma-separated environment variable or default, skipping empty items
+func getEnvList(key, defaultVal string) []string {
+	var items []string
+	for _, item := range strings.Split(getEnv(key, defaultVal), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvBool retrieves a boolean environment variable or returns default
+func getEnvBool(key string, defaultVal bool) bool {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultVal
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		slog.Warn("Invalid environment variable, using default", "key", key, "value", value, "default", defaultVal)
+		return defaultVal
+	}
+	return parsed
+}
+
+// getEnvInt retrieves an integer environment variable or returns default
+func getEnvInt(key string, defaultVal int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultVal
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		slog.Warn("Invalid environment variable, using default", "key", key, "value", value, "default", defaultVal)
+		return defaultVal
+	}
+	return parsed
+}
+
+// getEnvDuration retrieves a duration environment variable (e.g. "24h") or returns default
+func getEnvDuration(key string, defaultVal time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultVal
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		slog.Warn("Invalid environment variable, using default", "key", key, "value", value, "default", defaultVal.String())
+		return defaultVal
+	}
+	return parsed
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigDefaults(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		wantProvider string
+		wantStore    string
+	}{
+		{
+			name:         "nothing configured",
+			wantProvider: "gemini",
+			wantStore:    "sqlite",
+		},
+		{
+			name:         "offline is never chosen for a missing key",
+			env:          map[string]string{"GEMINI_API_KEY": ""},
+			wantProvider: "gemini",
+			wantStore:    "sqlite",
+		},
+		{
+			name:         "explicit provider",
+			env:          map[string]string{"LLM_PROVIDER": "offline"},
+			wantProvider: "offline",
+			wantStore:    "sqlite",
+		},
+		{
+			name:         "supabase configured",
+			env:          map[string]string{"SUPABASE_URL": "https://db.example.com", "SUPABASE_KEY": "service-key"},
+			wantProvider: "gemini",
+			wantStore:    "supabase",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"LLM_PROVIDER", "GEMINI_API_KEY", "STORE_BACKEND", "SUPABASE_URL", "SUPABASE_KEY"} {
+				t.Setenv(key, tt.env[key])
+				if _, set := tt.env[key]; !set {
+					unsetenv(t, key)
+				}
+			}
+
+			cfg := LoadConfig()
+			if cfg.LLMProvider != tt.wantProvider {
+				t.Errorf("LLMProvider = %q, want %q", cfg.LLMProvider, tt.wantProvider)
+			}
+			if cfg.StoreBackend != tt.wantStore {
+				t.Errorf("StoreBackend = %q, want %q", cfg.StoreBackend, tt.wantStore)
+			}
+		})
+	}
+}
+
+func TestGetEnvHelpers(t *testing.T) {
+	t.Setenv("TEST_LIST", " en, ,es ,")
+	if got := getEnvList("TEST_LIST", ""); len(got) != 2 || got[0] != "en" || got[1] != "es" {
+		t.Errorf("getEnvList = %q, want [en es]", got)
+	}
+
+	t.Setenv("TEST_INT", "many")
+	if got := getEnvInt("TEST_INT", 7); got != 7 {
+		t.Errorf("getEnvInt with an invalid value = %d, want the default 7", got)
+	}
+
+	t.Setenv("TEST_DURATION", "90m")
+	if got := getEnvDuration("TEST_DURATION", time.Hour); got != 90*time.Minute {
+		t.Errorf("getEnvDuration = %v, want 1h30m", got)
+	}
+
+	t.Setenv("TEST_BOOL", "yes please")
+	if got := getEnvBool("TEST_BOOL", true); !got {
+		t.Errorf("getEnvBool with an invalid value = %v, want the default true", got)
+	}
+}
+
+// unsetenv removes key for the rest of the test; t.Setenv has already
+// arranged for its original value to be restored
+func unsetenv(t *testing.T, key string) {
+	t.Helper()
+	if err := os.Unsetenv(key); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"spoiler_api/internal/models"
+	"spoiler_api/internal/services"
+)
+
+// APIKeyHandler serves the admin API for minting and revoking API keys
+type APIKeyHandler struct {
+	keys *services.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new API key handler. keys is nil when there is
+// no store to hold API keys.
+func NewAPIKeyHandler(keys *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{keys: keys}
+}
+
+// CreateKey handles POST /api/admin/keys — mints a key and returns it once
+func (h *APIKeyHandler) CreateKey(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+	ctx := c.Request.Context()
+
+	var request models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondInvalid(c, "body", fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+
+	request.Name = strings.TrimSpace(request.Name)
+	if request.Name == "" || len(request.Scopes) == 0 {
+		respondInvalid(c, "body", "name and at least one scope are required")
+		return
+	}
+	scopes, err := services.ParseScopes(request.Scopes)
+	if err != nil {
+		respondInvalid(c, "scopes", err.Error())
+		return
+	}
+
+	key, secret, err := h.keys.Create(ctx, request.Name, scopes)
+	if err != nil {
+		respondError(c, err, "failed to create API key")
+		return
+	}
+
+	slog.InfoContext(ctx, "Created API key", "key_id", key.ID, "name", key.Name, "scopes", key.Scopes)
+	c.JSON(http.StatusCreated, models.CreateAPIKeyResponse{APIKey: *key, Key: secret})
+}
+
+// ListKeys handles GET /api/admin/keys — lists keys with their usage
+func (h *APIKeyHandler) ListKeys(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+
+	keys, err := h.keys.List(c.Request.Context())
+	if err != nil {
+		respondError(c, err, "failed to list API keys")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"keys": keys,
+	})
+}
+
+// RevokeKey handles DELETE /api/admin/keys/:id — revokes a key
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+	if !h.enabled(c) {
+		return
+	}
+	ctx := c.Request.Context()
+	id := c.Param("id")
+
+	err := h.keys.Revoke(ctx, id)
+	if errors.Is(err, services.ErrAPIKeyNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Code:  models.ErrorNotFound,
+			Error: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		respondError(c, err, "failed to revoke API key")
+		return
+	}
+
+	slog.InfoContext(ctx, "Revoked API key", "key_id", id)
+	c.JSON(http.StatusOK, gin.H{
+		"id":      id,
+		"revoked": true,
+	})
+}
+
+// enabled writes a 503 and returns false when API keys are unavailable
+func (h *APIKeyHandler) enabled(c *gin.Context) bool {
+	if h.keys != nil {
+		return true
+	}
+	respondDatabaseRequired(c, "API keys need a database (STORE_BACKEND=none)")
+	return false
+}
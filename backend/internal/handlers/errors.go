@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"spoiler_api/internal/logging"
+	"spoiler_api/internal/models"
+	"spoiler_api/internal/ratelimit"
+	"spoiler_api/internal/services"
+	"spoiler_api/internal/upstream"
+)
+
+// statusClientClosedRequest is the nginx convention for a request abandoned by
+// its client; nobody receives it, but it keeps access logs and metrics honest
+const statusClientClosedRequest = 499
+
+// newErrorResponse classifies err into an error code. The message is
+// followed by a reason safe to show clients; for unclassified errors the
+// reason is left out, since their text may carry raw upstream responses.
+func newErrorResponse(err error, message string) models.ErrorResponse {
+	response := models.ErrorResponse{Code: models.ErrorInternal}
+	reason := ""
+
+	var limitErr *ratelimit.Error
+	var upstreamErr *upstream.Error
+	switch {
+	case errors.As(err, &limitErr):
+		response.Code = models.ErrorRateLimited
+		response.Details = gin.H{"limit": limitErr.Limit}
+		response.RetryAfter = retryAfterSeconds(limitErr.RetryAfter)
+		reason = limitErr.Error()
+	case errors.Is(err, services.ErrGenerateScopeRequired):
+		response.Code = models.ErrorGenerationRefused
+		response.Details = gin.H{"reason": "scope"}
+		reason = services.ErrGenerateScopeRequired.Error()
+	case errors.Is(err, services.ErrGenerationRefused):
+		response.Code = models.ErrorGenerationRefused
+		response.Details = gin.H{"reason": "model"}
+		reason = services.ErrGenerationRefused.Error()
+	case errors.Is(err, services.ErrMovieNotFound), errors.Is(err, services.ErrShowNotFound),
+		errors.Is(err, services.ErrSpoilerUnavailable):
+		response.Code = models.ErrorNotFound
+		reason = err.Error()
+	case errors.Is(err, services.ErrInvalidSpoiler):
+		response.Code = models.ErrorUpstreamUnavailable
+		response.Details = gin.H{"service": "llm", "reason": "invalid output"}
+		reason = services.ErrInvalidSpoiler.Error()
+	case errors.As(err, &upstreamErr):
+		response.Code = models.ErrorUpstreamUnavailable
+		if errors.Is(upstreamErr, upstream.ErrTimeout) {
+			response.Code = models.ErrorUpstreamTimeout
+		}
+		response.Details = gin.H{"service": upstreamErr.Service, "reason": upstreamReason(upstreamErr)}
+		response.RetryAfter = retryAfterSeconds(upstreamErr.RetryAfter)
+		reason = fmt.Sprintf("%s %s", upstreamErr.Service, upstreamReason(upstreamErr))
+	}
+
+	response.Error = message
+	if reason != "" {
+		response.Error = message + ": " + reason
+	}
+	response.Error = logging.Redact(response.Error)
+	return response
+}
+
+// upstreamReason describes an upstream failure in a word or two
+func upstreamReason(err *upstream.Error) string {
+	switch {
+	case errors.Is(err, upstream.ErrTimeout):
+		return "timed out"
+	case errors.Is(err, upstream.ErrRateLimited):
+		return "rate limited"
+	case errors.Is(err, upstream.ErrRejected):
+		return "rejected the request"
+	default:
+		return "unavailable"
+	}
+}
+
+// respondError logs err and writes an error response with the code for err,
+// or just a 499 status if the client has gone away, adding a Retry-After
+// header when a rate limit or the upstream says when to come back. Secrets
+// are redacted from the message.
+func respondError(c *gin.Context, err error, message string) {
+	ctx := c.Request.Context()
+	if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+		slog.InfoContext(ctx, "Client disconnected before the response was ready", "error", err)
+		c.AbortWithStatus(statusClientClosedRequest)
+		return
+	}
+
+	response := newErrorResponse(err, message)
+	status := response.Code.Status()
+	if status >= http.StatusInternalServerError {
+		slog.ErrorContext(ctx, "Request failed", "status", status, "code", response.Code, "error", err)
+	} else {
+		slog.InfoContext(ctx, "Request rejected", "status", status, "code", response.Code, "error", err)
+	}
+
+	if response.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(response.RetryAfter))
+	}
+	c.JSON(status, response)
+}
+
+// respondInvalid writes an invalid_input error naming the offending parameter
+func respondInvalid(c *gin.Context, param, message string) {
+	c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		Code:    models.ErrorInvalidInput,
+		Error:   message,
+		Details: gin.H{"param": param},
+	})
+}
+
+// respondDatabaseRequired writes the error for endpoints that need a database
+func respondDatabaseRequired(c *gin.Context, message string) {
+	c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+		Code:    models.ErrorUpstreamUnavailable,
+		Error:   message,
+		Details: gin.H{"service": "database", "reason": "not configured"},
+	})
+}
+
+// retryAfterSeconds rounds a delay up to whole seconds
+func retryAfterSeconds(after time.Duration) int {
+	return int(math.Ceil(after.Seconds()))
+}
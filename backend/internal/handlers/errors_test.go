@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"spoiler_api/internal/models"
+	"spoiler_api/internal/ratelimit"
+	"spoiler_api/internal/services"
+	"spoiler_api/internal/upstream"
+)
+
+func TestNewErrorResponse(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantCode       models.ErrorCode
+		wantStatus     int
+		wantRetryAfter int
+	}{
+		{
+			name:           "rate limited",
+			err:            &ratelimit.Error{Limit: "generations", RetryAfter: 1500 * time.Millisecond},
+			wantCode:       models.ErrorRateLimited,
+			wantStatus:     http.StatusTooManyRequests,
+			wantRetryAfter: 2,
+		},
+		{
+			name:       "generate scope required",
+			err:        services.ErrGenerateScopeRequired,
+			wantCode:   models.ErrorGenerationRefused,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "model declined",
+			err:        fmt.Errorf("%w: no candidates", services.ErrGenerationRefused),
+			wantCode:   models.ErrorGenerationRefused,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "movie not found",
+			err:        fmt.Errorf("%w: no movie with TMDB ID 1", services.ErrMovieNotFound),
+			wantCode:   models.ErrorNotFound,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "show not found",
+			err:        services.ErrShowNotFound,
+			wantCode:   models.ErrorNotFound,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "model knows nothing about the movie",
+			err:        services.ErrSpoilerUnavailable,
+			wantCode:   models.ErrorNotFound,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "malformed spoiler",
+			err:        fmt.Errorf("%w: spoiler is missing the ending section", services.ErrInvalidSpoiler),
+			wantCode:   models.ErrorUpstreamUnavailable,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "upstream unavailable",
+			err:            fmt.Errorf("failed to search TMDB: %w", &upstream.Error{Service: "tmdb", Kind: upstream.ErrUnavailable, StatusCode: 502, RetryAfter: 30 * time.Second}),
+			wantCode:       models.ErrorUpstreamUnavailable,
+			wantStatus:     http.StatusServiceUnavailable,
+			wantRetryAfter: 30,
+		},
+		{
+			name:       "upstream timeout",
+			err:        fmt.Errorf("failed to generate spoiler explanation: %w", &upstream.Error{Service: "gemini", Kind: upstream.ErrTimeout, Err: context.DeadlineExceeded}),
+			wantCode:   models.ErrorUpstreamTimeout,
+			wantStatus: http.StatusGatewayTimeout,
+		},
+		{
+			name:           "upstream rate limited",
+			err:            &upstream.Error{Service: "tmdb", Kind: upstream.ErrRateLimited, StatusCode: 429, RetryAfter: 10 * time.Second},
+			wantCode:       models.ErrorUpstreamUnavailable,
+			wantStatus:     http.StatusServiceUnavailable,
+			wantRetryAfter: 10,
+		},
+		{
+			name:       "upstream rejected the request",
+			err:        fmt.Errorf("Gemini API error: %w", &upstream.Error{Service: "gemini", Kind: upstream.ErrRejected, StatusCode: 400, Err: errors.New("API key not valid")}),
+			wantCode:   models.ErrorUpstreamUnavailable,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "unclassified",
+			err:        errors.New("something broke"),
+			wantCode:   models.ErrorInternal,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := newErrorResponse(tt.err, "request failed")
+			if response.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", response.Code, tt.wantCode)
+			}
+			if status := response.Code.Status(); status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if response.RetryAfter != tt.wantRetryAfter {
+				t.Errorf("retry_after = %d, want %d", response.RetryAfter, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestGetMovieErrors(t *testing.T) {
+	tmdb := fakeTMDB{"/movie/27205": inceptionJSON}
+
+	tests := []struct {
+		name        string
+		target      string
+		spoiler     string
+		err         error
+		wantStatus  int
+		wantCode    models.ErrorCode
+		wantDetails map[string]interface{}
+	}{
+		{
+			name:       "valid spoiler",
+			target:     "/api/movie?id=27205",
+			spoiler:    validSpoiler,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "unknown movie",
+			target:     "/api/movie?id=1",
+			spoiler:    validSpoiler,
+			wantStatus: http.StatusNotFound,
+			wantCode:   models.ErrorNotFound,
+		},
+		{
+			name:       "model does not know the movie",
+			target:     "/api/movie?id=27205",
+			spoiler:    "## Movie Not Found\nI have no information about this film.",
+			wantStatus: http.StatusNotFound,
+			wantCode:   models.ErrorNotFound,
+		},
+		{
+			name:        "malformed spoiler",
+			target:      "/api/movie?id=27205",
+			spoiler:     "I'm sorry, I can't help with that.",
+			wantStatus:  http.StatusServiceUnavailable,
+			wantCode:    models.ErrorUpstreamUnavailable,
+			wantDetails: map[string]interface{}{"service": "llm", "reason": "invalid output"},
+		},
+		{
+			name:       "model declined",
+			target:     "/api/movie?id=27205",
+			err:        services.ErrGenerationRefused,
+			wantStatus: http.StatusForbidden,
+			wantCode:   models.ErrorGenerationRefused,
+		},
+		{
+			name:        "model timed out",
+			target:      "/api/movie?id=27205",
+			err:         &upstream.Error{Service: "gemini", Kind: upstream.ErrTimeout, Err: context.DeadlineExceeded},
+			wantStatus:  http.StatusGatewayTimeout,
+			wantCode:    models.ErrorUpstreamTimeout,
+			wantDetails: map[string]interface{}{"service": "gemini", "reason": "timed out"},
+		},
+		{
+			name:       "invalid level",
+			target:     "/api/movie?id=27205&level=everything",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   models.ErrorInvalidInput,
+		},
+		{
+			name:       "unsupported language",
+			target:     "/api/movie?id=27205&lang=xx",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   models.ErrorInvalidInput,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _ := newTestHandler(t, tmdb, tt.spoiler, tt.err)
+			recorder := serve(handler.GetMovie, "/api/movie", tt.target)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			if tt.wantStatus == http.StatusOK {
+				return
+			}
+
+			var response models.ErrorResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("decode error response: %v", err)
+			}
+			if response.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", response.Code, tt.wantCode)
+			}
+			for key, want := range tt.wantDetails {
+				if got := response.Details.(map[string]interface{})[key]; got != want {
+					t.Errorf("details[%q] = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetMovieEvictsRejectedSpoiler(t *testing.T) {
+	handler, generator := newTestHandler(t, fakeTMDB{"/movie/27205": inceptionJSON}, "## Movie Not Found\nUnknown.", nil)
+
+	for i := 0; i < 2; i++ {
+		if recorder := serve(handler.GetMovie, "/api/movie", "/api/movie?id=27205"); recorder.Code != http.StatusNotFound {
+			t.Fatalf("request %d: status = %d, want 404", i+1, recorder.Code)
+		}
+	}
+	if handler.spoilerCache.Contains("27205") {
+		t.Error("rejected spoiler is still cached")
+	}
+	if calls := generator.Calls(); calls != 2 {
+		t.Errorf("generator called %d times, want 2 (the rejected spoiler must not be served from cache)", calls)
+	}
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"spoiler_api/internal/ratelimit"
+	"spoiler_api/internal/services"
+	"spoiler_api/internal/upstream"
+)
+
+const inceptionJSON = `{"id":27205,"title":"Inception","release_date":"2010-07-15","genre_ids":[28],"genres":[{"id":28,"name":"Action"}],"overview":"A thief enters dreams.","runtime":148,"credits":{"cast":[],"crew":[]}}`
+
+const validSpoiler = `## Movie Overview
+A thief enters dreams.
+
+## The Beginning
+Cobb takes one last job.
+
+## Major Turning Point
+The team goes three dreams deep.
+
+## The Climax
+The kicks are synchronized.
+
+## Ending Explained
+The top keeps spinning.
+`
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// fakeTMDB answers TMDB requests by URL path; unknown paths get TMDB's 404
+type fakeTMDB map[string]string
+
+func (f fakeTMDB) RoundTrip(req *http.Request) (*http.Response, error) {
+	status, body := http.StatusNotFound, `{"status_code":34,"status_message":"The resource you requested could not be found."}`
+	if response, ok := f[strings.TrimPrefix(req.URL.Path, "/3")]; ok {
+		status, body = http.StatusOK, response
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+// fakeGenerator returns a fixed spoiler or error and, like the real
+// providers, serves and caches spoilers in the memory cache. With block set,
+// generations wait for it to close. It remembers the last request it generated.
+type fakeGenerator struct {
+	cache   *services.SpoilerCache
+	spoiler string
+	err     error
+	block   chan struct{}
+
+	mu    sync.Mutex
+	calls int
+	last  services.SpoilerRequest
+}
+
+func (g *fakeGenerator) GenerateSpoiler(ctx context.Context, request services.SpoilerRequest) (string, error) {
+	if spoiler, ok := g.cache.Get(request.CacheKey()); ok {
+		return spoiler, nil
+	}
+
+	g.mu.Lock()
+	g.calls++
+	g.last = request
+	g.mu.Unlock()
+
+	if g.block != nil {
+		select {
+		case <-g.block:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	if g.err != nil {
+		return "", g.err
+	}
+	g.cache.Set(request.CacheKey(), g.spoiler)
+	return g.spoiler, nil
+}
+
+// Calls returns how many spoilers were generated on a cache miss
+func (g *fakeGenerator) Calls() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.calls
+}
+
+// LastRequest returns the request of the latest generation
+func (g *fakeGenerator) LastRequest() services.SpoilerRequest {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.last
+}
+
+// newTestHandler returns a handler without a database whose TMDB client talks
+// to tmdb and whose generator returns spoiler or err
+func newTestHandler(t *testing.T, tmdb fakeTMDB, spoiler string, err error) (*MovieHandler, *fakeGenerator) {
+	t.Helper()
+
+	cache := services.NewSpoilerCache(100, 1<<20, time.Hour)
+	generator := &fakeGenerator{cache: cache, spoiler: spoiler, err: err}
+	tmdbService := services.NewTMDBService("test-key", upstream.New("tmdb", upstream.Options{
+		Timeout:    time.Second,
+		MaxRetries: -1,
+		Transport:  tmdb,
+	}))
+	genres := services.NewGenreCache(tmdbService, []string{"en"}, 0)
+	t.Cleanup(genres.Close)
+
+	handler := NewMovieHandler(tmdbService, generator, cache, nil, nil, genres, nil, []string{"en", "es"}, GenerationPolicy{})
+	return handler, generator
+}
+
+// serve sends a GET request for target to handle as a caller allowed to generate spoilers
+func serve(handle gin.HandlerFunc, route, target string) *httptest.ResponseRecorder {
+	return serveAs(handle, route, target, "", services.ScopeGenerate)
+}
+
+// serveAs sends a GET request for target to handle as the client with the
+// given rate limit key and scopes
+func serveAs(handle gin.HandlerFunc, route, target, client string, scopes ...services.Scope) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		ctx := services.WithCaller(c.Request.Context(), services.NewCaller(nil, scopes, nil))
+		c.Request = c.Request.WithContext(ratelimit.WithClient(ctx, client))
+	})
+	router.GET(route, handle)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+	return recorder
+}
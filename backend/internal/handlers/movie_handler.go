@@ -1,124 +1,269 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 
+	"spoiler_api/internal/metrics"
 	"spoiler_api/internal/models"
+	"spoiler_api/internal/ratelimit"
 	"spoiler_api/internal/services"
+	"spoiler_api/internal/tracing"
+)
+
+const (
+	// detailsCastSize is the number of top-billed actors returned by GET /api/movie/:id
+	detailsCastSize = 10
+	// promptCastSize is the number of top-billed actors passed to the spoiler prompt
+	promptCastSize = 8
+	// trendingLimit is the number of movies returned by GET /api/trending
+	trendingLimit = 50
 )
 
 // MovieHandler handles movie-related API requests
 type MovieHandler struct {
-	tmdbService     *services.TMDBService
-	geminiService   *services.GeminiService
-	supabaseService *services.SupabaseService
+	tmdbService      *services.TMDBService
+	spoilerGenerator services.SpoilerGenerator
+	spoilerCache     *services.SpoilerCache
+	movieStore       services.MovieStore
+	outlineStore     services.OutlineStore
+	genres           *services.GenreCache
+	generations      *services.Coalescer[*models.MovieResponse]
+	textGenerations  *services.Coalescer[string]
+	writeQueue       *services.WriteQueue
+	languages        []string
+	policy           GenerationPolicy
+
+	streamsMu sync.Mutex
+	streams   map[string]*spoilerStream
+}
+
+// GenerationPolicy controls when spoiler generations may start and what
+// happens to them when their clients disconnect
+type GenerationPolicy struct {
+	// Detach keeps generations running after their clients disconnect so the result is still cached
+	Detach bool
+	// Limiter throttles cache-miss generations per client; nil for no limit
+	Limiter *ratelimit.Limiter
+	// Budget caps the LLM tokens spent per day; nil for no budget
+	Budget *ratelimit.DailyBudget
 }
 
 // NewMovieHandler creates a new movie handler
-func NewMovieHandler(tmdbService *services.TMDBService, geminiService *services.GeminiService, supabaseService *services.SupabaseService) *MovieHandler {
+func NewMovieHandler(tmdbService *services.TMDBService, spoilerGenerator services.SpoilerGenerator, spoilerCache *services.SpoilerCache, movieStore services.MovieStore, outlineStore services.OutlineStore, genres *services.GenreCache, writeQueue *services.WriteQueue, languages []string, policy GenerationPolicy) *MovieHandler {
 	return &MovieHandler{
-		tmdbService:     tmdbService,
-		geminiService:   geminiService,
-		supabaseService: supabaseService,
+		tmdbService:      tmdbService,
+		spoilerGenerator: spoilerGenerator,
+		spoilerCache:     spoilerCache,
+		movieStore:       movieStore,
+		outlineStore:     outlineStore,
+		genres:           genres,
+		generations:      services.NewCoalescer[*models.MovieResponse](policy.Detach),
+		textGenerations:  services.NewCoalescer[string](policy.Detach),
+		writeQueue:       writeQueue,
+		languages:        languages,
+		policy:           policy,
+		streams:          make(map[string]*spoilerStream),
 	}
 }
 
-
+// GetMovie handles GET /api/movie?title=X[&year=YYYY] or GET /api/movie?id=N — returns a movie with its spoiler.
+// The optional level parameter (teaser, partial or full) limits how much of the plot is revealed.
+// Responds with 300 Multiple Choices and a candidate list when the title is ambiguous.
 func (h *MovieHandler) GetMovie(c *gin.Context) {
-	// Get title from query parameters
-	title := c.Query("title")
+	ctx := c.Request.Context()
 
-	// Validate query parameter
-	if title == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: "title query parameter is required",
-		})
+	level := models.SpoilerLevel(c.DefaultQuery("level", string(models.SpoilerFull)))
+	if !level.Valid() {
+		respondInvalid(c, "level", "level must be teaser, partial or full")
 		return
 	}
 
-	// Search for movie on TMDB first to get the canonical title and year
-	tmdbMovie, err := h.tmdbService.SearchMovie(title)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: err.Error(),
-		})
+	language, ok := h.resolveLanguage(c)
+	if !ok {
+		return
+	}
+
+	// Resolve the canonical TMDB movie from the query parameters
+	tmdbMovie, ok := h.resolveMovie(c, language)
+	if !ok {
 		return
 	}
 
 	// Extract year from release date
 	year := h.tmdbService.ExtractYear(tmdbMovie.ReleaseDate)
 
-	// Step 1: Check Supabase database for cached result
-	if h.supabaseService != nil {
-		cachedMovie, err := h.supabaseService.FindMovieByTitleAndYear(tmdbMovie.Title, year)
-		if err != nil {
-			log.Printf("Supabase lookup warning: %v", err)
-		} else if cachedMovie != nil {
-			log.Printf("Cache HIT: serving '%s (%s)' from Supabase", cachedMovie.Title, cachedMovie.Year)
-			c.JSON(http.StatusOK, cachedMovie)
-			return
-		}
+	// Step 1: Check the database for a cached result
+	if cachedMovie := h.findStoredMovie(ctx, tmdbMovie.ID, level, language); cachedMovie != nil {
+		c.JSON(http.StatusOK, cachedMovie)
+		return
 	}
 
-	log.Printf("Cache MISS: generating spoiler for '%s (%s)' via Gemini", tmdbMovie.Title, year)
+	// Every caller is admitted on its own, even one that joins a generation already in flight
+	key := services.SpoilerRequest{TMDBID: tmdbMovie.ID, Level: level, Language: language}.CacheKey()
+	if err := h.admitGeneration(ctx, key); err != nil {
+		respondError(c, err, "failed to load spoiler")
+		return
+	}
 
-	// Get genres mapping
-	genreMap, err := h.tmdbService.GetGenres()
+	// Step 2: Concurrent requests for the same movie, level and language share a single generation.
+	// The shared work outlives this request while other callers wait on it, and is then cancelled
+	// or detached per GENERATION_ON_DISCONNECT
+	response, waiters, shared, err := h.generations.Do(ctx, key, func(workCtx context.Context) (*models.MovieResponse, error) {
+		return h.generateMovie(h.generationContext(workCtx), tmdbMovie, year, level, language, nil)
+	})
+	if shared {
+		slog.InfoContext(ctx, "Coalesced request onto in-flight generation", "title", tmdbMovie.Title, "year", year, "level", level, "language", language)
+	} else if waiters > 0 {
+		slog.InfoContext(ctx, "Served coalesced waiters", "title", tmdbMovie.Title, "year", year, "level", level, "language", language, "waiters", waiters)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: "failed to fetch genres",
-		})
+		respondError(c, err, "failed to load spoiler")
 		return
 	}
 
-	// Extract genre names
-	genres := h.tmdbService.ExtractGenreNames(tmdbMovie.GenreIDs, genreMap)
+	// Return successful response
+	c.JSON(http.StatusOK, response)
+}
+
+// findStoredMovie returns the stored spoiler for a movie at level in
+// language, or nil when it has to be generated. Only full spoilers are
+// stored; partial ones are cut down from a stored full spoiler when there is
+// one, and teasers have their own prompt.
+func (h *MovieHandler) findStoredMovie(ctx context.Context, tmdbID int, level models.SpoilerLevel, language string) *models.MovieResponse {
+	if level == models.SpoilerTeaser {
+		return nil
+	}
+	cachedMovie := h.findCachedMovie(ctx, tmdbID, language)
+	switch {
+	case cachedMovie == nil:
+		return nil
+	case level == models.SpoilerFull:
+		return cachedMovie
+	case cachedMovie.Structured != nil:
+		return cutMovieSpoiler(*cachedMovie, level)
+	default:
+		return nil
+	}
+}
+
+// generateMovie generates the spoiler for a movie at level in language and
+// queues full spoilers for the database. Callers check the database and admit
+// the generation first. A non-nil onChunk receives the text as it is
+// generated when the provider can stream it.
+func (h *MovieHandler) generateMovie(ctx context.Context, tmdbMovie *models.TMDBMovie, year string, level models.SpoilerLevel, language string, onChunk func(chunk string) error) (*models.MovieResponse, error) {
+	slog.InfoContext(ctx, "Cache MISS: generating spoiler", "title", tmdbMovie.Title, "year", year, "level", level, "language", language)
+
+	// Generate spoiler explanation using the LLM provider
+	var (
+		spoiler string
+		err     error
+	)
+	spoilerRequest := h.newSpoilerRequest(ctx, tmdbMovie, year, level, language)
+	if streamer, ok := h.spoilerGenerator.(services.SpoilerStreamer); ok && onChunk != nil {
+		spoiler, err = streamer.StreamSpoiler(ctx, spoilerRequest, onChunk)
+	} else {
+		spoiler, err = h.spoilerGenerator.GenerateSpoiler(ctx, spoilerRequest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate spoiler explanation: %w", err)
+	}
 
-	// Step 2: Generate spoiler explanation using Gemini (only on cache miss)
-	spoiler, err := h.geminiService.GenerateSpoiler(tmdbMovie.Title, year, tmdbMovie.Overview)
+	// A spoiler that fails validation, including the model's "Movie Not Found"
+	// reply, is neither cached nor stored, so the next request generates it again
+	structured, err := services.ParseSpoilerLevel(spoiler, level)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: fmt.Sprintf("failed to generate spoiler explanation: %v", err),
-		})
-		return
+		h.spoilerCache.Evict(spoilerRequest.CacheKey())
+		return nil, err
 	}
 
 	// Build response
-	response := models.MovieResponse{
+	response := h.newMovieResponse(ctx, tmdbMovie, year, level, language)
+	response.Spoiler = spoiler
+	response.Structured = structured
+
+	// Save full spoilers to the database in the background
+	if level == models.SpoilerFull {
+		h.saveMovieInBackground(ctx, response)
+	}
+
+	return &response, nil
+}
+
+// newMovieResponse builds the response for a TMDB movie at level in language,
+// without its spoiler
+func (h *MovieHandler) newMovieResponse(ctx context.Context, tmdbMovie *models.TMDBMovie, year string, level models.SpoilerLevel, language string) models.MovieResponse {
+	return models.MovieResponse{
+		TMDBID:   tmdbMovie.ID,
 		Title:    tmdbMovie.Title,
 		Year:     year,
 		Poster:   h.tmdbService.FormatPosterURL(tmdbMovie.PosterPath),
 		Backdrop: h.tmdbService.FormatBackdropURL(tmdbMovie.BackdropPath),
 		Rating:   tmdbMovie.VoteAverage,
-		Genres:   genres,
+		Genres:   h.tmdbService.ExtractGenreNames(tmdbMovie.GenreIDs, h.genres.Movie(ctx, language)),
 		Overview: h.tmdbService.TruncateOverview(tmdbMovie.Overview, 500),
-		Spoiler:  spoiler,
+		Level:    level,
+		Language: language,
+	}
+}
+
+// GetMovieDetails handles GET /api/movie/:id — returns full TMDB metadata without spoilers
+func (h *MovieHandler) GetMovieDetails(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		respondInvalid(c, "id", "id must be a positive TMDB movie ID")
+		return
 	}
 
-	// Step 3: Save to Supabase in the background
-	if h.supabaseService != nil {
-		go func() {
-			if err := h.supabaseService.SaveMovie(&response); err != nil {
-				log.Printf("Failed to save movie to Supabase: %v", err)
-			} else {
-				log.Printf("Saved '%s (%s)' to Supabase", response.Title, response.Year)
-			}
-		}()
+	language, ok := h.resolveLanguage(c)
+	if !ok {
+		return
 	}
 
-	// Return successful response
-	c.JSON(http.StatusOK, response)
+	details, err := h.tmdbService.GetMovieDetails(ctx, id, language)
+	if err != nil {
+		respondError(c, err, "failed to fetch movie details")
+		return
+	}
+
+	var genres []string
+	for _, genre := range details.Genres {
+		genres = append(genres, genre.Name)
+	}
+
+	c.JSON(http.StatusOK, models.MovieDetailsResponse{
+		TMDBID:        details.ID,
+		Title:         details.Title,
+		Year:          h.tmdbService.ExtractYear(details.ReleaseDate),
+		Poster:        h.tmdbService.FormatPosterURL(details.PosterPath),
+		Backdrop:      h.tmdbService.FormatBackdropURL(details.BackdropPath),
+		Rating:        details.VoteAverage,
+		Genres:        genres,
+		Overview:      details.Overview,
+		Runtime:       details.Runtime,
+		Tagline:       details.Tagline,
+		Certification: h.tmdbService.ExtractCertification(details, "US"),
+		Director:      h.tmdbService.ExtractDirector(details.Credits),
+		Cast:          h.tmdbService.ExtractTopCast(details.Credits, detailsCastSize),
+		Keywords:      h.tmdbService.ExtractKeywords(details),
+	})
 }
 
 // DiscoverMovies handles GET /api/movies?years=2025,2026&page=1 — returns trending movies with pagination
 // Also supports single year: GET /api/movies?year=2025&page=1
 func (h *MovieHandler) DiscoverMovies(c *gin.Context) {
+	ctx := c.Request.Context()
+
 	years := c.DefaultQuery("years", "")
 	year := c.DefaultQuery("year", "")
 	pageStr := c.DefaultQuery("page", "1")
@@ -127,6 +272,11 @@ func (h *MovieHandler) DiscoverMovies(c *gin.Context) {
 		page = p
 	}
 
+	language, ok := h.resolveLanguage(c)
+	if !ok {
+		return
+	}
+
 	var tmdbMovies []models.TMDBMovie
 	var totalPages int
 	var err error
@@ -136,33 +286,28 @@ func (h *MovieHandler) DiscoverMovies(c *gin.Context) {
 		parts := strings.Split(years, ",")
 		startYear := strings.TrimSpace(parts[0])
 		endYear := strings.TrimSpace(parts[len(parts)-1])
-		tmdbMovies, totalPages, err = h.tmdbService.DiscoverMoviesByDateRange(startYear, endYear, page)
+		tmdbMovies, totalPages, err = h.tmdbService.DiscoverMoviesByDateRange(ctx, startYear, endYear, page, language)
 		label = startYear + "-" + endYear
 	} else {
 		if year == "" {
 			year = "2025"
 		}
-		tmdbMovies, err = h.tmdbService.DiscoverMoviesByYear(year)
+		tmdbMovies, err = h.tmdbService.DiscoverMoviesByYear(ctx, year, language)
 		totalPages = 1
 		label = year
 	}
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: fmt.Sprintf("failed to discover movies: %v", err),
-		})
+		respondError(c, err, "failed to discover movies")
 		return
 	}
 
-	genreMap, err := h.tmdbService.GetGenres()
-	if err != nil {
-		log.Printf("Failed to fetch genres: %v", err)
-		genreMap = make(map[int]string)
-	}
+	genreMap := h.genres.Movie(ctx, language)
 
 	var movies []models.MovieResponse
 	for _, m := range tmdbMovies {
 		movies = append(movies, models.MovieResponse{
+			TMDBID:   m.ID,
 			Title:    m.Title,
 			Year:     h.tmdbService.ExtractYear(m.ReleaseDate),
 			Poster:   h.tmdbService.FormatPosterURL(m.PosterPath),
@@ -184,31 +329,31 @@ func (h *MovieHandler) DiscoverMovies(c *gin.Context) {
 
 // SearchMovies handles GET /api/search?q=term — returns search results without spoilers
 func (h *MovieHandler) SearchMovies(c *gin.Context) {
+	ctx := c.Request.Context()
+
 	query := c.Query("q")
 	if query == "" {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error: "q query parameter is required",
-		})
+		respondInvalid(c, "q", "q query parameter is required")
 		return
 	}
 
-	tmdbMovies, err := h.tmdbService.SearchMovies(query)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: fmt.Sprintf("failed to search movies: %v", err),
-		})
+	language, ok := h.resolveLanguage(c)
+	if !ok {
 		return
 	}
 
-	genreMap, err := h.tmdbService.GetGenres()
+	tmdbMovies, err := h.tmdbService.SearchMovies(ctx, query, language)
 	if err != nil {
-		log.Printf("Failed to fetch genres: %v", err)
-		genreMap = make(map[int]string)
+		respondError(c, err, "failed to search movies")
+		return
 	}
 
+	genreMap := h.genres.Movie(ctx, language)
+
 	var movies []models.MovieResponse
 	for _, m := range tmdbMovies {
 		movies = append(movies, models.MovieResponse{
+			TMDBID:   m.ID,
 			Title:    m.Title,
 			Year:     h.tmdbService.ExtractYear(m.ReleaseDate),
 			Poster:   h.tmdbService.FormatPosterURL(m.PosterPath),
@@ -228,18 +373,21 @@ func (h *MovieHandler) SearchMovies(c *gin.Context) {
 
 // GetTrendingMovies returns the most searched movies from the database
 func (h *MovieHandler) GetTrendingMovies(c *gin.Context) {
-	if h.supabaseService == nil {
-		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
-			Error: "database not configured",
-		})
+	ctx := c.Request.Context()
+
+	if h.movieStore == nil {
+		respondDatabaseRequired(c, "database not configured")
 		return
 	}
 
-	movies, err := h.supabaseService.GetAllMovies()
+	language, ok := h.resolveLanguage(c)
+	if !ok {
+		return
+	}
+
+	movies, err := h.movieStore.ListTrending(ctx, language, trendingLimit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: fmt.Sprintf("failed to fetch trending movies: %v", err),
-		})
+		respondError(c, err, "failed to fetch trending movies")
 		return
 	}
 
@@ -249,11 +397,289 @@ func (h *MovieHandler) GetTrendingMovies(c *gin.Context) {
 	})
 }
 
+// resolveLanguage negotiates the response language from the lang query
+// parameter or the Accept-Language header and announces it in
+// Content-Language. On failure it writes the error response and returns false.
+func (h *MovieHandler) resolveLanguage(c *gin.Context) (string, bool) {
+	language, err := services.NegotiateLanguage(c.Query("lang"), c.GetHeader("Accept-Language"), h.languages)
+	if err != nil {
+		respondInvalid(c, "lang", "lang must be one of: "+strings.Join(h.languages, ", "))
+		return "", false
+	}
+
+	c.Header("Content-Language", language)
+	c.Writer.Header().Add("Vary", "Accept-Language")
+	tracing.SetAttributes(c.Request.Context(), tracing.AttrLanguage.String(language))
+	return language, true
+}
+
+// resolveMovie finds the TMDB movie requested by the id, or title and optional
+// year, query parameters, with its title and overview in language. On failure
+// it writes the error response and returns false.
+func (h *MovieHandler) resolveMovie(c *gin.Context, language string) (*models.TMDBMovie, bool) {
+	ctx := c.Request.Context()
+
+	if idParam := c.Query("id"); idParam != "" {
+		id, err := strconv.Atoi(idParam)
+		if err != nil || id <= 0 {
+			respondInvalid(c, "id", "id must be a positive TMDB movie ID")
+			return nil, false
+		}
+
+		tmdbMovie, err := h.tmdbService.GetMovie(ctx, id, language)
+		if err != nil {
+			respondError(c, err, "failed to fetch movie")
+			return nil, false
+		}
+		return tmdbMovie, true
+	}
+
+	// Get title from query parameters
+	title := c.Query("title")
+
+	// Validate query parameter
+	if title == "" {
+		respondInvalid(c, "title", "title or id query parameter is required")
+		return nil, false
+	}
+
+	// Search for movie on TMDB first to get the canonical title and year
+	tmdbMovie, err := h.tmdbService.SearchMovie(ctx, title, c.Query("year"), language)
+
+	var ambiguous *services.AmbiguousMovieError
+	if errors.As(err, &ambiguous) {
+		var details models.AmbiguousMovieDetails
+		for _, candidate := range ambiguous.Candidates {
+			details.Candidates = append(details.Candidates, models.MovieCandidate{
+				TMDBID:   candidate.ID,
+				Title:    candidate.Title,
+				Year:     h.tmdbService.ExtractYear(candidate.ReleaseDate),
+				Poster:   h.tmdbService.FormatPosterURL(candidate.PosterPath),
+				Rating:   candidate.VoteAverage,
+				Overview: h.tmdbService.TruncateOverview(candidate.Overview, 200),
+			})
+		}
+		c.JSON(models.ErrorAmbiguous.Status(), models.ErrorResponse{
+			Code:    models.ErrorAmbiguous,
+			Error:   ambiguous.Error(),
+			Details: details,
+		})
+		return nil, false
+	}
+	if err != nil {
+		respondError(c, err, "failed to find movie")
+		return nil, false
+	}
+
+	tracing.SetAttributes(ctx, tracing.AttrMovieID.Int(tmdbMovie.ID), tracing.AttrMovieTitle.String(tmdbMovie.Title))
+	return tmdbMovie, true
+}
+
+// findCachedMovie returns the stored spoiler for a movie in language, or nil on a miss or when no database is configured
+func (h *MovieHandler) findCachedMovie(ctx context.Context, tmdbID int, language string) *models.MovieResponse {
+	if h.movieStore == nil {
+		return nil
+	}
+
+	cachedMovie, err := h.movieStore.FindMovie(ctx, tmdbID, language)
+	if err != nil {
+		slog.WarnContext(ctx, "Database lookup failed", "tmdb_id", tmdbID, "error", err)
+		return nil
+	}
+	if cachedMovie == nil {
+		return nil
+	}
+
+	slog.InfoContext(ctx, "Cache HIT: serving from database", "title", cachedMovie.Title, "year", cachedMovie.Year)
+	metrics.SpoilerLookups.WithLabelValues("database").Inc()
+	tracing.SetAttributes(ctx, tracing.AttrCacheTier.String("database"))
+
+	// Increment search count in the background
+	if err := h.writeQueue.EnqueueIncrement(tmdbID, language); err != nil {
+		slog.ErrorContext(ctx, "Failed to queue search count increment", "title", cachedMovie.Title, "year", cachedMovie.Year, "error", err)
+	}
+
+	if cachedMovie.Structured == nil {
+		cachedMovie.Structured = parseStructuredSpoiler(ctx, cachedMovie.Title, cachedMovie.Spoiler, models.SpoilerFull)
+	}
+	cachedMovie.Level = models.SpoilerFull
+	cachedMovie.Language = language
+	return cachedMovie
+}
+
+// saveMovieInBackground queues a generated spoiler for the database without blocking the response.
+// The write queue retries it until the database accepts it.
+func (h *MovieHandler) saveMovieInBackground(ctx context.Context, response models.MovieResponse) {
+	if h.writeQueue == nil || h.placeholderGenerator() {
+		return
+	}
+
+	if err := h.writeQueue.EnqueueSave(response); err != nil {
+		slog.ErrorContext(ctx, "Failed to queue movie for the database", "title", response.Title, "year", response.Year, "error", err)
+	}
+}
+
+// placeholderGenerator reports whether spoilers come from a stand-in such as
+// the offline provider, whose output is never stored
+func (h *MovieHandler) placeholderGenerator() bool {
+	placeholder, ok := h.spoilerGenerator.(services.PlaceholderGenerator)
+	return ok && placeholder.Placeholder()
+}
+
+// newSpoilerRequest builds the generator input for a TMDB movie at level in language,
+// adding the director and top-billed cast when the details endpoint is reachable
+func (h *MovieHandler) newSpoilerRequest(ctx context.Context, tmdbMovie *models.TMDBMovie, year string, level models.SpoilerLevel, language string) services.SpoilerRequest {
+	request := services.SpoilerRequest{
+		Level:    level,
+		TMDBID:   tmdbMovie.ID,
+		Title:    tmdbMovie.Title,
+		Year:     year,
+		Overview: tmdbMovie.Overview,
+		Language: language,
+	}
+
+	details, err := h.tmdbService.GetMovieDetails(ctx, tmdbMovie.ID, language)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to fetch credits", "title", tmdbMovie.Title, "year", year, "error", err)
+		return request
+	}
+
+	request.Director = h.tmdbService.ExtractDirector(details.Credits)
+	request.Cast = h.tmdbService.ExtractTopCast(details.Credits, promptCastSize)
+	return request
+}
+
+// parseStructuredSpoiler parses spoiler markdown written at level, logging and returning nil when it fails validation
+func parseStructuredSpoiler(ctx context.Context, title, spoiler string, level models.SpoilerLevel) *models.StructuredSpoiler {
+	structured, err := services.ParseSpoilerLevel(spoiler, level)
+	if err != nil {
+		slog.WarnContext(ctx, "Structured spoiler unavailable", "title", title, "error", err)
+		return nil
+	}
+	return structured
+}
+
+// cutMovieSpoiler cuts a stored full spoiler down to level, rewriting its
+// markdown to match
+func cutMovieSpoiler(movie models.MovieResponse, level models.SpoilerLevel) *models.MovieResponse {
+	movie.Structured = services.CutSpoiler(movie.Structured, level)
+	movie.Spoiler = services.RenderSpoiler(movie.Structured)
+	movie.Level = level
+	return &movie
+}
+
+// EvictMovie handles DELETE /api/admin/cache/:id — purges a movie's spoilers and scene
+// outline in every language from the in-memory cache and the database so the next
+// request regenerates them
+func (h *MovieHandler) EvictMovie(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		respondInvalid(c, "id", "id must be a positive TMDB movie ID")
+		return
+	}
+
+	evicted := false
+	for _, language := range h.languages {
+		for _, level := range []models.SpoilerLevel{models.SpoilerTeaser, models.SpoilerPartial, models.SpoilerFull} {
+			if h.spoilerCache.Evict(services.SpoilerRequest{TMDBID: id, Level: level, Language: language}.CacheKey()) {
+				evicted = true
+			}
+		}
+		if h.spoilerCache.Evict(services.SpoilerRequest{Kind: services.KindOutline, TMDBID: id, Language: language}.CacheKey()) {
+			evicted = true
+		}
+	}
+
+	if h.movieStore != nil {
+		if err := h.movieStore.DeleteMovie(ctx, id); err != nil {
+			respondError(c, err, "failed to delete stored spoiler")
+			return
+		}
+	}
+	if h.outlineStore != nil {
+		if err := h.outlineStore.DeleteOutline(ctx, id); err != nil {
+			respondError(c, err, "failed to delete stored scene outline")
+			return
+		}
+	}
+
+	slog.InfoContext(ctx, "Evicted spoiler", "tmdb_id", id, "in_memory", evicted)
+
+	c.JSON(http.StatusOK, gin.H{
+		"tmdb_id":          id,
+		"evicted_memory":   evicted,
+		"deleted_database": h.movieStore != nil,
+	})
+}
+
 // HealthCheck handles the health check endpoint
 func (h *MovieHandler) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status":     "healthy",
-		"cache_size": h.geminiService.GetCacheSize(),
-		"database":   h.supabaseService != nil,
+		"cache_size": h.spoilerCache.Len(),
+		"cache":      h.spoilerCache.Stats(),
+		"database":   h.movieStore != nil,
+		"generations": gin.H{
+			"in_flight": h.InFlightGenerations(),
+			"coalesced": h.generations.CoalescedCount() + h.textGenerations.CoalescedCount(),
+			"budget":    h.policy.Budget.Stats(),
+		},
+		"write_queue": h.writeQueueStats(),
+		"genres":      h.genres.Stats(),
 	})
 }
+
+// writeQueueStats reports pending database writes; an empty queue when no database is configured
+func (h *MovieHandler) writeQueueStats() services.WriteQueueStats {
+	if h.writeQueue == nil {
+		return services.WriteQueueStats{}
+	}
+	return h.writeQueue.Stats()
+}
+
+// admitGeneration checks the caller's scopes, the daily budget and the
+// client's generation limit before the spoiler cached under cacheKey is
+// generated, and counts the generation against the caller's API key. It runs
+// with each caller's own request context before the caller starts or joins a
+// shared generation. Spoilers still in the memory cache are free.
+func (h *MovieHandler) admitGeneration(ctx context.Context, cacheKey string) error {
+	if h.spoilerCache.Contains(cacheKey) {
+		return nil
+	}
+
+	caller := services.CallerFromContext(ctx)
+	if !caller.Has(services.ScopeGenerate) {
+		return services.ErrGenerateScopeRequired
+	}
+	if err := h.policy.Budget.Check(); err != nil {
+		return err
+	}
+	if err := h.policy.Limiter.Allow(ratelimit.Client(ctx)); err != nil {
+		return err
+	}
+
+	caller.RecordGeneration()
+	return nil
+}
+
+// WaitForGenerations blocks until running spoiler generations finish or ctx
+// ends, and reports whether they all finished
+func (h *MovieHandler) WaitForGenerations(ctx context.Context) bool {
+	return h.generations.Wait(ctx) && h.textGenerations.Wait(ctx)
+}
+
+// generationContext returns the context a spoiler generation runs under:
+// ctx itself, or ctx without its cancellation when generations detach
+func (h *MovieHandler) generationContext(ctx context.Context) context.Context {
+	if h.policy.Detach {
+		return context.WithoutCancel(ctx)
+	}
+	return ctx
+}
+
+// InFlightGenerations returns the number of spoiler generations still running
+func (h *MovieHandler) InFlightGenerations() int {
+	return h.generations.InFlight() + h.textGenerations.InFlight()
+}
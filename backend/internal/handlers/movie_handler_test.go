@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"spoiler_api/internal/models"
+	"spoiler_api/internal/ratelimit"
+	"spoiler_api/internal/services"
+)
+
+func TestGenerationAdmissionIsPerCaller(t *testing.T) {
+	tests := []struct {
+		name         string
+		joinerScopes []services.Scope
+		joinerLimit  bool // the joiner has used up its generations
+		wantStatus   int
+		wantCode     models.ErrorCode
+	}{
+		{
+			name:         "rate limited caller",
+			joinerScopes: []services.Scope{services.ScopeGenerate},
+			joinerLimit:  true,
+			wantStatus:   http.StatusTooManyRequests,
+			wantCode:     models.ErrorRateLimited,
+		},
+		{
+			name:         "caller without the generate scope",
+			joinerScopes: []services.Scope{services.ScopeReadCached},
+			wantStatus:   http.StatusForbidden,
+			wantCode:     models.ErrorGenerationRefused,
+		},
+		{
+			name:         "admitted caller",
+			joinerScopes: []services.Scope{services.ScopeGenerate},
+			wantStatus:   http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, generator := newTestHandler(t, fakeTMDB{"/movie/27205": inceptionJSON}, validSpoiler, nil)
+			generator.block = make(chan struct{})
+			handler.policy.Limiter = ratelimit.New("generations", 1, time.Hour, 1)
+			if tt.joinerLimit {
+				handler.policy.Limiter.Allow("joiner")
+			}
+
+			request := func(client string, scopes []services.Scope) <-chan *httptest.ResponseRecorder {
+				done := make(chan *httptest.ResponseRecorder, 1)
+				go func() {
+					done <- serveAs(handler.GetMovie, "/api/movie", "/api/movie?id=27205", client, scopes...)
+				}()
+				return done
+			}
+
+			// The joiner arrives while the leader's generation is in flight and
+			// must be admitted against its own limit and scopes
+			leader := request("leader", []services.Scope{services.ScopeGenerate})
+			waitUntil(t, func() bool { return generator.Calls() == 1 })
+			joiner := request("joiner", tt.joinerScopes)
+
+			var joined *httptest.ResponseRecorder
+			if tt.wantStatus == http.StatusOK {
+				waitUntil(t, func() bool { return handler.generations.CoalescedCount() == 1 })
+				close(generator.block)
+				joined = <-joiner
+			} else {
+				select {
+				case joined = <-joiner:
+				case <-time.After(time.Second):
+					close(generator.block)
+					t.Fatal("joiner waited on the leader's generation instead of being rejected")
+				}
+				close(generator.block)
+			}
+			if recorder := <-leader; recorder.Code != http.StatusOK {
+				t.Fatalf("leader: status = %d: %s", recorder.Code, recorder.Body)
+			}
+
+			if joined.Code != tt.wantStatus {
+				t.Fatalf("joiner: status = %d, want %d: %s", joined.Code, tt.wantStatus, joined.Body)
+			}
+			if tt.wantCode != "" {
+				var response models.ErrorResponse
+				if err := json.Unmarshal(joined.Body.Bytes(), &response); err != nil || response.Code != tt.wantCode {
+					t.Errorf("joiner: error response %s (%v), want code %q", joined.Body, err, tt.wantCode)
+				}
+			}
+			if calls := generator.Calls(); calls != 1 {
+				t.Errorf("generator called %d times, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestGetMovieClientDisconnect(t *testing.T) {
+	tests := []struct {
+		name       string
+		detach     bool
+		wantCached bool
+	}{
+		{name: "detached generation finishes and is cached", detach: true, wantCached: true},
+		{name: "generation is cancelled", detach: false, wantCached: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, generator := newTestHandler(t, fakeTMDB{"/movie/27205": inceptionJSON}, validSpoiler, nil)
+			generator.block = make(chan struct{})
+			handler.policy.Detach = tt.detach
+			handler.generations = services.NewCoalescer[*models.MovieResponse](tt.detach)
+
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				caller := services.NewCaller(nil, []services.Scope{services.ScopeGenerate}, nil)
+				c.Request = c.Request.WithContext(services.WithCaller(c.Request.Context(), caller))
+			})
+			router.GET("/api/movie", handler.GetMovie)
+
+			ctx, disconnect := context.WithCancel(context.Background())
+			recorder := httptest.NewRecorder()
+			served := make(chan struct{})
+			go func() {
+				defer close(served)
+				router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/movie?id=27205", nil).WithContext(ctx))
+			}()
+			waitUntil(t, func() bool { return generator.Calls() == 1 })
+			disconnect()
+			<-served
+			if recorder.Code != statusClientClosedRequest {
+				t.Errorf("status = %d, want %d", recorder.Code, statusClientClosedRequest)
+			}
+
+			// Releasing the generator only matters to a generation that is still running
+			close(generator.block)
+			waitUntil(t, func() bool { return handler.generations.InFlight() == 0 })
+			if cached := handler.spoilerCache.Contains("27205"); cached != tt.wantCached {
+				t.Errorf("spoiler cached = %v, want %v", cached, tt.wantCached)
+			}
+		})
+	}
+}
+
+// placeholderGenerator is a fakeGenerator standing in for the offline provider
+type placeholderGenerator struct {
+	*fakeGenerator
+}
+
+func (g placeholderGenerator) Placeholder() bool {
+	return true
+}
+
+func TestGetMovieStoresOnlyRealSpoilers(t *testing.T) {
+	tests := []struct {
+		name        string
+		placeholder bool
+		wantStored  bool
+	}{
+		{name: "real spoiler", wantStored: true},
+		{name: "placeholder spoiler", placeholder: true, wantStored: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, generator := newTestHandler(t, fakeTMDB{"/movie/27205": inceptionJSON}, validSpoiler, nil)
+			if tt.placeholder {
+				handler.spoilerGenerator = placeholderGenerator{generator}
+			}
+			store, err := services.NewSQLiteStore(filepath.Join(t.TempDir(), "spoilers.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { store.Close() })
+			handler.writeQueue, err = services.NewWriteQueue(store, filepath.Join(t.TempDir(), "writes.jsonl"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if recorder := serve(handler.GetMovie, "/api/movie", "/api/movie?id=27205"); recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", recorder.Code, recorder.Body)
+			}
+			if left := handler.writeQueue.Drain(context.Background()); left != 0 {
+				t.Fatalf("%d writes left in the queue", left)
+			}
+
+			stored, err := store.FindMovie(context.Background(), 27205, "en")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (stored != nil) != tt.wantStored {
+				t.Errorf("stored = %v, want %v", stored != nil, tt.wantStored)
+			}
+		})
+	}
+}
+
+func TestCutMovieSpoiler(t *testing.T) {
+	structured, err := services.ParseSpoilerLevel(validSpoiler, models.SpoilerFull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := models.MovieResponse{TMDBID: 27205, Title: "Inception", Spoiler: validSpoiler, Structured: structured, Level: models.SpoilerFull}
+
+	partial := cutMovieSpoiler(full, models.SpoilerPartial)
+	if partial.Level != models.SpoilerPartial {
+		t.Errorf("level = %q, want partial", partial.Level)
+	}
+	if !strings.Contains(partial.Spoiler, "three dreams deep") || strings.Contains(partial.Spoiler, "The kicks") || strings.Contains(partial.Spoiler, "top keeps spinning") {
+		t.Errorf("partial spoiler reveals the wrong sections:\n%s", partial.Spoiler)
+	}
+	if len(partial.Structured.StorySections) >= len(full.Structured.StorySections) {
+		t.Errorf("partial kept %d of %d story sections", len(partial.Structured.StorySections), len(full.Structured.StorySections))
+	}
+	if full.Level != models.SpoilerFull || full.Spoiler != validSpoiler || len(full.Structured.StorySections) != len(structured.StorySections) {
+		t.Error("cutting changed the full spoiler")
+	}
+}
+
+func TestGetMovieLevels(t *testing.T) {
+	tests := []struct {
+		name          string
+		level         string
+		wantCalls     int
+		wantContains  string
+		wantOmits     string
+		wantCachedKey string
+	}{
+		{name: "full is served from the database", level: "full", wantContains: "top keeps spinning"},
+		{name: "partial is cut from the stored full spoiler", level: "partial", wantContains: "three dreams deep", wantOmits: "top keeps spinning"},
+		{name: "teaser is generated under its own key", level: "teaser", wantCalls: 1, wantCachedKey: "27205:teaser"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, generator := newTestHandler(t, fakeTMDB{"/movie/27205": inceptionJSON}, validSpoiler, nil)
+			store, err := services.NewSQLiteStore(filepath.Join(t.TempDir(), "spoilers.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { store.Close() })
+			if err := store.SaveMovie(context.Background(), &models.MovieResponse{TMDBID: 27205, Title: "Inception", Year: "2010", Spoiler: validSpoiler, Language: "en"}); err != nil {
+				t.Fatal(err)
+			}
+			handler.movieStore = store
+			handler.writeQueue, err = services.NewWriteQueue(store, filepath.Join(t.TempDir(), "writes.jsonl"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { handler.writeQueue.Drain(context.Background()) })
+
+			recorder := serve(handler.GetMovie, "/api/movie", "/api/movie?id=27205&level="+tt.level)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", recorder.Code, recorder.Body)
+			}
+			var response models.MovieResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if string(response.Level) != tt.level {
+				t.Errorf("level = %q, want %q", response.Level, tt.level)
+			}
+			if !strings.Contains(response.Spoiler, tt.wantContains) || (tt.wantOmits != "" && strings.Contains(response.Spoiler, tt.wantOmits)) {
+				t.Errorf("spoiler at level %s:\n%s", tt.level, response.Spoiler)
+			}
+			if calls := generator.Calls(); calls != tt.wantCalls {
+				t.Errorf("generator called %d times, want %d", calls, tt.wantCalls)
+			}
+			if tt.wantCachedKey != "" && !handler.spoilerCache.Contains(tt.wantCachedKey) {
+				t.Errorf("spoiler not cached under %q", tt.wantCachedKey)
+			}
+		})
+	}
+}
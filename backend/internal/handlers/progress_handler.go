@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"spoiler_api/internal/models"
+	"spoiler_api/internal/services"
+	"spoiler_api/internal/tracing"
+)
+
+// GetMovieProgress handles GET /api/movie/progress?id=N&minute=M — returns the plot of a
+// movie up to minute M, so a viewer who paused can catch up without learning what comes next.
+// Scenes are sliced from the movie's stored scene outline, which is generated once.
+func (h *MovieHandler) GetMovieProgress(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil || id <= 0 {
+		respondInvalid(c, "id", "id must be a positive TMDB movie ID")
+		return
+	}
+	minute, err := strconv.Atoi(c.Query("minute"))
+	if err != nil || minute < 0 {
+		respondInvalid(c, "minute", "minute must be a number no less than 0")
+		return
+	}
+
+	language, ok := h.resolveLanguage(c)
+	if !ok {
+		return
+	}
+
+	details, err := h.tmdbService.GetMovieDetails(ctx, id, language)
+	if err != nil {
+		respondError(c, err, "failed to fetch movie details")
+		return
+	}
+	tracing.SetAttributes(ctx, tracing.AttrMovieID.Int(details.ID), tracing.AttrMovieTitle.String(details.Title))
+
+	// Past the end of the film there is nothing more to reveal
+	if details.Runtime > 0 && minute > details.Runtime {
+		minute = details.Runtime
+	}
+
+	request := services.SpoilerRequest{
+		Kind:     services.KindOutline,
+		TMDBID:   details.ID,
+		Title:    details.Title,
+		Year:     h.tmdbService.ExtractYear(details.ReleaseDate),
+		Overview: details.Overview,
+		Director: h.tmdbService.ExtractDirector(details.Credits),
+		Cast:     h.tmdbService.ExtractTopCast(details.Credits, promptCastSize),
+		Runtime:  details.Runtime,
+		Language: language,
+	}
+	key := request.CacheKey()
+
+	outline := h.findStoredOutline(ctx, request)
+	if outline == "" {
+		// Every caller is admitted on its own, even one that joins a generation already in flight
+		if err := h.admitGeneration(ctx, key); err != nil {
+			respondError(c, err, "failed to load scene outline")
+			return
+		}
+
+		var shared bool
+		outline, _, shared, err = h.textGenerations.Do(ctx, key, func(workCtx context.Context) (string, error) {
+			return h.generateOutline(h.generationContext(workCtx), request)
+		})
+		if shared {
+			slog.InfoContext(ctx, "Coalesced request onto in-flight generation", "key", key)
+		}
+		if err != nil {
+			respondError(c, err, "failed to load scene outline")
+			return
+		}
+	}
+
+	scenes, err := services.ParseOutline(outline)
+	if err != nil {
+		respondError(c, err, "failed to load scene outline")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ProgressResponse{
+		TMDBID:   details.ID,
+		Title:    details.Title,
+		Year:     request.Year,
+		Poster:   h.tmdbService.FormatPosterURL(details.PosterPath),
+		Runtime:  details.Runtime,
+		Minute:   minute,
+		Language: language,
+		Scenes:   services.ScenesUntil(scenes, minute),
+		Finished: details.Runtime > 0 && minute >= details.Runtime,
+	})
+}
+
+// findStoredOutline returns the stored scene outline for a movie in the
+// request's language, or "" on a miss or when no database is configured
+func (h *MovieHandler) findStoredOutline(ctx context.Context, request services.SpoilerRequest) string {
+	if h.outlineStore == nil {
+		return ""
+	}
+
+	outline, err := h.outlineStore.FindOutline(ctx, request.TMDBID, request.Language)
+	if err != nil {
+		slog.WarnContext(ctx, "Outline lookup failed", "title", request.Title, "error", err)
+		return ""
+	}
+	if outline != "" {
+		slog.InfoContext(ctx, "Cache HIT: serving scene outline from database", "title", request.Title)
+	}
+	return outline
+}
+
+// generateOutline generates the scene outline for a movie in the request's
+// language and queues it for the database. An outline that does not parse is
+// neither cached nor stored, so the next request generates it again.
+func (h *MovieHandler) generateOutline(ctx context.Context, request services.SpoilerRequest) (string, error) {
+	slog.InfoContext(ctx, "Cache MISS: generating scene outline", "title", request.Title, "year", request.Year)
+
+	outline, err := h.spoilerGenerator.GenerateSpoiler(ctx, request)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate scene outline: %w", err)
+	}
+
+	if _, err := services.ParseOutline(outline); err != nil {
+		h.spoilerCache.Evict(request.CacheKey())
+		return "", err
+	}
+
+	// Outlines are saved through the write queue like spoilers, so a slow or
+	// failing database neither holds up the response nor loses the outline
+	if h.outlineStore != nil && h.writeQueue != nil && !h.placeholderGenerator() {
+		if err := h.writeQueue.EnqueueSaveOutline(request.TMDBID, request.Language, outline); err != nil {
+			slog.ErrorContext(ctx, "Failed to queue scene outline for the database", "title", request.Title, "error", err)
+		}
+	}
+
+	return outline, nil
+}
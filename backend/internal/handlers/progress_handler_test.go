@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"spoiler_api/internal/models"
+	"spoiler_api/internal/services"
+)
+
+const inceptionOutline = `## Scene Outline
+- [0] **The Beach** — An old man remembers Cobb.
+- [20] **The Offer** — Saito hires Cobb for an inception.
+- [60] **The Van** — The team drops into the first dream.
+- [130] **The Kicks** — Every level wakes at once.
+`
+
+func TestGetMovieProgress(t *testing.T) {
+	tests := []struct {
+		name         string
+		placeholder  bool
+		target       string
+		wantScenes   int
+		wantFinished bool
+		wantStored   bool
+	}{
+		{name: "paused midway", target: "/api/movie/progress?id=27205&minute=60", wantScenes: 3, wantStored: true},
+		{name: "past the end", target: "/api/movie/progress?id=27205&minute=500", wantScenes: 4, wantFinished: true, wantStored: true},
+		{name: "placeholder outline", placeholder: true, target: "/api/movie/progress?id=27205&minute=60", wantScenes: 3, wantStored: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, generator := newTestHandler(t, fakeTMDB{"/movie/27205": inceptionJSON}, inceptionOutline, nil)
+			if tt.placeholder {
+				handler.spoilerGenerator = placeholderGenerator{generator}
+			}
+			store, err := services.NewSQLiteStore(filepath.Join(t.TempDir(), "spoilers.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { store.Close() })
+			handler.outlineStore = store
+			handler.writeQueue, err = services.NewWriteQueue(store, filepath.Join(t.TempDir(), "writes.jsonl"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recorder := serve(handler.GetMovieProgress, "/api/movie/progress", tt.target)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", recorder.Code, recorder.Body)
+			}
+			var response models.ProgressResponse
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if len(response.Scenes) != tt.wantScenes || response.Finished != tt.wantFinished {
+				t.Errorf("got %d scenes, finished %v; want %d, %v", len(response.Scenes), response.Finished, tt.wantScenes, tt.wantFinished)
+			}
+
+			// The outline is saved through the write queue, not on the request path
+			if left := handler.writeQueue.Drain(context.Background()); left != 0 {
+				t.Fatalf("%d writes left in the queue", left)
+			}
+			outline, err := store.FindOutline(context.Background(), 27205, "en")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (outline != "") != tt.wantStored {
+				t.Errorf("outline stored = %v, want %v", outline != "", tt.wantStored)
+			}
+		})
+	}
+}
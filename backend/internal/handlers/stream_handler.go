@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"spoiler_api/internal/models"
+	"spoiler_api/internal/services"
+)
+
+// StreamMovie handles GET /api/movie/stream?title=X[&year=YYYY] or ?id=N — streams the spoiler as Server-Sent Events.
+//
+// Events, in order:
+//   - movie: the movie metadata (MovieResponse without spoiler)
+//   - chunk: a SpoilerChunk, tagged with the markdown section being written
+//   - done:  the complete MovieResponse, including the structured spoiler
+//   - error: an ErrorResponse if generation fails after the stream started
+//
+// The event stream starts with the first generated text, so errors before
+// then, such as rate limits, are answered as plain JSON.
+func (h *MovieHandler) StreamMovie(c *gin.Context) {
+	// Resolve the language and movie before switching to SSE so lookup errors are plain JSON
+	language, ok := h.resolveLanguage(c)
+	if !ok {
+		return
+	}
+	tmdbMovie, ok := h.resolveMovie(c, language)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	year := h.tmdbService.ExtractYear(tmdbMovie.ReleaseDate)
+
+	tracker := services.NewSectionTracker()
+	sendChunks := func(chunks []models.SpoilerChunk) {
+		for _, chunk := range chunks {
+			h.sendEvent(c, "chunk", chunk)
+		}
+	}
+
+	// Replay stored spoilers section by section
+	if cachedMovie := h.findCachedMovie(ctx, tmdbMovie.ID, language); cachedMovie != nil {
+		startEventStream(c)
+		h.sendEvent(c, "movie", withoutSpoiler(*cachedMovie))
+		sendChunks(tracker.Write(cachedMovie.Spoiler))
+		sendChunks(tracker.Flush())
+		h.sendEvent(c, "done", cachedMovie)
+		return
+	}
+
+	// Streams share the generation, and its upstream stream, with each other and
+	// with GET /api/movie requests for the full spoiler
+	key := services.SpoilerRequest{TMDBID: tmdbMovie.ID, Language: language}.CacheKey()
+	if err := h.admitGeneration(ctx, key); err != nil {
+		respondError(c, err, "failed to generate spoiler explanation")
+		return
+	}
+	broadcast := h.joinSpoilerStream(key)
+	defer h.leaveSpoilerStream(key, broadcast)
+
+	results := make(chan streamResult, 1)
+	go func() {
+		response, _, shared, err := h.generations.Do(ctx, key, func(workCtx context.Context) (*models.MovieResponse, error) {
+			stream := h.joinSpoilerStream(key)
+			defer h.leaveSpoilerStream(key, stream)
+			defer stream.Close()
+
+			onChunk := func(chunk string) error {
+				stream.Write(chunk)
+				return nil
+			}
+			return h.generateMovie(h.generationContext(workCtx), tmdbMovie, year, models.SpoilerFull, language, onChunk)
+		})
+		results <- streamResult{response: response, shared: shared, err: err}
+	}()
+
+	metadata := h.newMovieResponse(ctx, tmdbMovie, year, models.SpoilerFull, language)
+	started := false
+	start := func() {
+		if !started {
+			startEventStream(c)
+			h.sendEvent(c, "movie", metadata)
+			started = true
+		}
+	}
+
+	// Forward the broadcast text until the generation returns; it closes the
+	// broadcast first, so one more read picks up everything it wrote
+	var streamed strings.Builder
+	forward := func() <-chan struct{} {
+		text, changed := broadcast.Read(streamed.Len())
+		if text != "" {
+			start()
+			streamed.WriteString(text)
+			sendChunks(tracker.Write(text))
+		}
+		return changed
+	}
+	var result streamResult
+	for waiting := true; waiting; {
+		select {
+		case <-forward():
+		case result = <-results:
+			waiting = false
+		}
+	}
+	forward()
+
+	if result.shared {
+		slog.InfoContext(ctx, "Coalesced stream onto in-flight generation", "title", tmdbMovie.Title, "year", year, "language", language)
+	}
+	if result.err != nil && ctx.Err() != nil {
+		slog.InfoContext(ctx, "Client disconnected; spoiler stream stopped", "title", tmdbMovie.Title, "error", result.err)
+		return
+	}
+	if result.err != nil {
+		if !started {
+			respondError(c, result.err, "failed to generate spoiler explanation")
+			return
+		}
+		slog.ErrorContext(ctx, "Spoiler stream failed", "title", tmdbMovie.Title, "error", result.err)
+		h.sendEvent(c, "error", newErrorResponse(result.err, "failed to generate spoiler explanation"))
+		return
+	}
+
+	// Send the text that was not streamed: all of it when the generation came
+	// from a request or provider that does not stream
+	response := result.response
+	start()
+	if rest, ok := strings.CutPrefix(response.Spoiler, streamed.String()); ok {
+		sendChunks(tracker.Write(rest))
+	}
+	sendChunks(tracker.Flush())
+	h.sendEvent(c, "done", response)
+}
+
+// streamResult is the outcome of the generation a spoiler stream follows
+type streamResult struct {
+	response *models.MovieResponse
+	shared   bool
+	err      error
+}
+
+// spoilerStream is the broadcast of one streamed generation and the number of
+// requests and generations holding it
+type spoilerStream struct {
+	broadcast *services.SpoilerBroadcast
+	refs      int
+}
+
+// joinSpoilerStream returns the broadcast for the generation with key,
+// starting a new one if the last has ended. Each call must be paired with
+// leaveSpoilerStream.
+func (h *MovieHandler) joinSpoilerStream(key string) *services.SpoilerBroadcast {
+	h.streamsMu.Lock()
+	defer h.streamsMu.Unlock()
+
+	stream, ok := h.streams[key]
+	if !ok || stream.broadcast.Closed() {
+		stream = &spoilerStream{broadcast: services.NewSpoilerBroadcast()}
+		h.streams[key] = stream
+	}
+	stream.refs++
+	return stream.broadcast
+}
+
+// leaveSpoilerStream releases a broadcast returned by joinSpoilerStream and
+// forgets it once nothing holds it
+func (h *MovieHandler) leaveSpoilerStream(key string, broadcast *services.SpoilerBroadcast) {
+	h.streamsMu.Lock()
+	defer h.streamsMu.Unlock()
+
+	stream, ok := h.streams[key]
+	if !ok || stream.broadcast != broadcast {
+		return
+	}
+	stream.refs--
+	if stream.refs == 0 {
+		delete(h.streams, key)
+	}
+}
+
+// startEventStream sets the Server-Sent Events response headers
+func startEventStream(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+}
+
+// sendEvent writes a single SSE event and flushes it to the client
+func (h *MovieHandler) sendEvent(c *gin.Context, event string, data interface{}) {
+	c.SSEvent(event, data)
+	c.Writer.Flush()
+}
+
+// withoutSpoiler returns a copy of a movie with the spoiler fields cleared
+func withoutSpoiler(movie models.MovieResponse) models.MovieResponse {
+	movie.Spoiler = ""
+	movie.Structured = nil
+	return movie
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"spoiler_api/internal/models"
+	"spoiler_api/internal/services"
+)
+
+// fakeStreamer streams the first half of its spoiler, waits for release and
+// then streams the rest
+type fakeStreamer struct {
+	*fakeGenerator
+	release chan struct{}
+}
+
+func (s *fakeStreamer) StreamSpoiler(ctx context.Context, request services.SpoilerRequest, onChunk func(chunk string) error) (string, error) {
+	s.mu.Lock()
+	s.calls++
+	s.mu.Unlock()
+
+	half := len(s.spoiler) / 2
+	if err := onChunk(s.spoiler[:half]); err != nil {
+		return "", err
+	}
+	select {
+	case <-s.release:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	if err := onChunk(s.spoiler[half:]); err != nil {
+		return "", err
+	}
+	s.cache.Set(request.CacheKey(), s.spoiler)
+	return s.spoiler, nil
+}
+
+// sseEvent is one Server-Sent Event read back from a response
+type sseEvent struct {
+	name string
+	data string
+}
+
+// parseEvents splits an SSE response body into its events
+func parseEvents(body string) []sseEvent {
+	var events []sseEvent
+	for _, block := range strings.Split(body, "\n\n") {
+		var event sseEvent
+		for _, line := range strings.Split(block, "\n") {
+			if name, ok := strings.CutPrefix(line, "event:"); ok {
+				event.name = name
+			} else if data, ok := strings.CutPrefix(line, "data:"); ok {
+				event.data = data
+			}
+		}
+		if event.name != "" {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+func TestStreamMovieSharesOneGeneration(t *testing.T) {
+	handler, generator := newTestHandler(t, fakeTMDB{"/movie/27205": inceptionJSON}, validSpoiler, nil)
+	streamer := &fakeStreamer{fakeGenerator: generator, release: make(chan struct{})}
+	handler.spoilerGenerator = streamer
+
+	const streams = 3
+	recorders := make([]*httptest.ResponseRecorder, streams)
+	var wg sync.WaitGroup
+	start := func(i int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			recorders[i] = serve(handler.StreamMovie, "/api/movie/stream", "/api/movie/stream?id=27205")
+		}()
+	}
+
+	// The first stream starts the generation and the others join it halfway through
+	start(0)
+	waitUntil(t, func() bool { return generator.Calls() == 1 })
+	for i := 1; i < streams; i++ {
+		start(i)
+	}
+	waitUntil(t, func() bool { return handler.generations.CoalescedCount() == streams-1 })
+	close(streamer.release)
+	wg.Wait()
+
+	if calls := generator.Calls(); calls != 1 {
+		t.Errorf("generator called %d times, want 1", calls)
+	}
+	for i, recorder := range recorders {
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("stream %d: status = %d: %s", i+1, recorder.Code, recorder.Body)
+		}
+
+		events := parseEvents(recorder.Body.String())
+		if len(events) < 3 || events[0].name != "movie" || events[len(events)-1].name != "done" {
+			t.Fatalf("stream %d: unexpected events %v", i+1, events)
+		}
+
+		// Every stream receives the whole text as chunks, including what was written before it joined
+		var text strings.Builder
+		for _, event := range events[1 : len(events)-1] {
+			var chunk models.SpoilerChunk
+			if event.name != "chunk" || json.Unmarshal([]byte(event.data), &chunk) != nil {
+				t.Fatalf("stream %d: unexpected event %v", i+1, event)
+			}
+			text.WriteString(chunk.Text)
+		}
+		if text.String() != validSpoiler {
+			t.Errorf("stream %d: chunks add up to %q, want the whole spoiler", i+1, text.String())
+		}
+
+		var done models.MovieResponse
+		if err := json.Unmarshal([]byte(events[len(events)-1].data), &done); err != nil || done.Spoiler != validSpoiler || done.Structured == nil {
+			t.Errorf("stream %d: done event %s (%v)", i+1, events[len(events)-1].data, err)
+		}
+	}
+}
+
+func TestStreamMovieFollowsGetMovieGeneration(t *testing.T) {
+	// Without streaming support the whole spoiler is chunked when the generation returns
+	handler, generator := newTestHandler(t, fakeTMDB{"/movie/27205": inceptionJSON}, validSpoiler, nil)
+
+	recorder := serve(handler.StreamMovie, "/api/movie/stream", "/api/movie/stream?id=27205")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", recorder.Code, recorder.Body)
+	}
+	events := parseEvents(recorder.Body.String())
+	if len(events) < 3 || events[0].name != "movie" || events[len(events)-1].name != "done" {
+		t.Fatalf("unexpected events %v", events)
+	}
+	if calls := generator.Calls(); calls != 1 {
+		t.Errorf("generator called %d times, want 1", calls)
+	}
+}
+
+func TestStreamMovieErrorsBeforeStreaming(t *testing.T) {
+	handler, _ := newTestHandler(t, fakeTMDB{"/movie/27205": inceptionJSON}, "## Movie Not Found\nUnknown.", nil)
+
+	recorder := serve(handler.StreamMovie, "/api/movie/stream", "/api/movie/stream?id=27205")
+	if recorder.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404: %s", recorder.Code, recorder.Body)
+	}
+	var response models.ErrorResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil || response.Code != models.ErrorNotFound {
+		t.Errorf("error response %s (%v), want code %q", recorder.Body, err, models.ErrorNotFound)
+	}
+	if handler.spoilerCache.Contains("27205") {
+		t.Error("rejected spoiler is still cached")
+	}
+}
+
+// waitUntil polls cond until it holds, failing the test after a second
+func waitUntil(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the streams")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
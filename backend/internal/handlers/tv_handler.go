@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"spoiler_api/internal/models"
+	"spoiler_api/internal/services"
+	"spoiler_api/internal/tracing"
+)
+
+// SearchTV handles GET /api/tv/search?q=term — returns matching shows without spoilers
+func (h *MovieHandler) SearchTV(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	query := c.Query("q")
+	if query == "" {
+		respondInvalid(c, "q", "q query parameter is required")
+		return
+	}
+
+	language, ok := h.resolveLanguage(c)
+	if !ok {
+		return
+	}
+
+	tmdbShows, err := h.tmdbService.SearchTV(ctx, query, language)
+	if err != nil {
+		respondError(c, err, "failed to search TV shows")
+		return
+	}
+
+	genreMap := h.genres.TV(ctx, language)
+
+	shows := []models.TVShow{}
+	for _, show := range tmdbShows {
+		shows = append(shows, models.TVShow{
+			TMDBID:   show.ID,
+			Name:     show.Name,
+			Year:     h.tmdbService.ExtractYear(show.FirstAirDate),
+			Poster:   h.tmdbService.FormatPosterURL(show.PosterPath),
+			Backdrop: h.tmdbService.FormatBackdropURL(show.BackdropPath),
+			Rating:   show.VoteAverage,
+			Genres:   h.tmdbService.ExtractGenreNames(show.GenreIDs, genreMap),
+			Overview: h.tmdbService.TruncateOverview(show.Overview, 500),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"shows": shows,
+		"count": len(shows),
+		"query": query,
+	})
+}
+
+// GetTVShow handles GET /api/tv/:id — returns a show with its seasons and a whole-series spoiler
+func (h *MovieHandler) GetTVShow(c *gin.Context) {
+	language, ok := h.resolveLanguage(c)
+	if !ok {
+		return
+	}
+	show, ok := h.resolveShow(c, language)
+	if !ok {
+		return
+	}
+
+	response := models.TVShow{
+		TMDBID:          show.ID,
+		Name:            show.Name,
+		Year:            h.tmdbService.ExtractYear(show.FirstAirDate),
+		Poster:          h.tmdbService.FormatPosterURL(show.PosterPath),
+		Backdrop:        h.tmdbService.FormatBackdropURL(show.BackdropPath),
+		Rating:          show.VoteAverage,
+		Overview:        show.Overview,
+		Status:          show.Status,
+		NumberOfSeasons: show.NumberOfSeasons,
+	}
+	for _, genre := range show.Genres {
+		response.Genres = append(response.Genres, genre.Name)
+	}
+	for _, season := range show.Seasons {
+		response.Seasons = append(response.Seasons, models.SeasonSummary{
+			SeasonNumber: season.SeasonNumber,
+			Name:         season.Name,
+			EpisodeCount: season.EpisodeCount,
+			AirDate:      season.AirDate,
+			Poster:       h.tmdbService.FormatPosterURL(season.PosterPath),
+		})
+	}
+
+	spoiler, ok := h.generateTVSpoiler(c, services.SpoilerRequest{
+		Kind:     services.KindSeries,
+		TMDBID:   show.ID,
+		Title:    show.Name,
+		Year:     response.Year,
+		Overview: show.Overview,
+		Cast:     h.tmdbService.ExtractTopCast(show.Credits, promptCastSize),
+		Language: language,
+	})
+	if !ok {
+		return
+	}
+	response.Spoiler = spoiler
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTVSeason handles GET /api/tv/:id/season/:n — returns a season's episodes and a recap of the season
+func (h *MovieHandler) GetTVSeason(c *gin.Context) {
+	language, ok := h.resolveLanguage(c)
+	if !ok {
+		return
+	}
+	show, season, ok := h.resolveSeason(c, language)
+	if !ok {
+		return
+	}
+
+	response := models.Season{
+		ShowID:       show.ID,
+		ShowName:     show.Name,
+		SeasonNumber: season.SeasonNumber,
+		Name:         season.Name,
+		AirDate:      season.AirDate,
+		Overview:     season.Overview,
+		Poster:       h.tmdbService.FormatPosterURL(season.PosterPath),
+		Episodes:     []models.Episode{},
+	}
+	var episodeTitles []string
+	for _, episode := range season.Episodes {
+		response.Episodes = append(response.Episodes, h.newEpisode(show, episode))
+		episodeTitles = append(episodeTitles, episode.Name)
+	}
+
+	overview := season.Overview
+	if overview == "" {
+		overview = show.Overview
+	}
+	spoiler, ok := h.generateTVSpoiler(c, services.SpoilerRequest{
+		Kind:          services.KindSeason,
+		TMDBID:        show.ID,
+		Title:         show.Name,
+		Year:          h.tmdbService.ExtractYear(season.AirDate),
+		Overview:      overview,
+		Cast:          h.tmdbService.ExtractTopCast(show.Credits, promptCastSize),
+		Season:        season.SeasonNumber,
+		EpisodeTitles: episodeTitles,
+		Language:      language,
+	})
+	if !ok {
+		return
+	}
+	response.Spoiler = spoiler
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTVEpisode handles GET /api/tv/:id/season/:n/episode/:e — returns an episode with its breakdown
+func (h *MovieHandler) GetTVEpisode(c *gin.Context) {
+	language, ok := h.resolveLanguage(c)
+	if !ok {
+		return
+	}
+	show, season, ok := h.resolveSeason(c, language)
+	if !ok {
+		return
+	}
+
+	episodeNumber, ok := pathNumber(c, "e", 1)
+	if !ok {
+		return
+	}
+	episode, err := h.tmdbService.FindEpisode(season, show.ID, episodeNumber)
+	if err != nil {
+		respondError(c, err, "failed to fetch episode")
+		return
+	}
+	tracing.SetAttributes(c.Request.Context(), tracing.AttrEpisode.Int(episodeNumber))
+
+	response := h.newEpisode(show, *episode)
+	response.ShowName = show.Name
+
+	spoiler, ok := h.generateTVSpoiler(c, services.SpoilerRequest{
+		Kind:         services.KindEpisode,
+		TMDBID:       show.ID,
+		Title:        show.Name,
+		Year:         h.tmdbService.ExtractYear(episode.AirDate),
+		Overview:     episode.Overview,
+		Cast:         h.tmdbService.ExtractTopCast(show.Credits, promptCastSize),
+		Season:       season.SeasonNumber,
+		Episode:      episode.EpisodeNumber,
+		EpisodeTitle: episode.Name,
+		Language:     language,
+	})
+	if !ok {
+		return
+	}
+	response.Spoiler = spoiler
+
+	c.JSON(http.StatusOK, response)
+}
+
+// resolveShow fetches the show named by the :id path parameter in language.
+// On failure it writes the error response and returns false.
+func (h *MovieHandler) resolveShow(c *gin.Context, language string) (*models.TMDBTVDetails, bool) {
+	ctx := c.Request.Context()
+
+	id, ok := pathNumber(c, "id", 1)
+	if !ok {
+		return nil, false
+	}
+
+	show, err := h.tmdbService.GetTVShow(ctx, id, language)
+	if err != nil {
+		respondError(c, err, "failed to fetch TV show")
+		return nil, false
+	}
+
+	tracing.SetAttributes(ctx, tracing.AttrShowID.Int(show.ID), tracing.AttrMovieTitle.String(show.Name))
+	return show, true
+}
+
+// resolveSeason fetches the show and season named by the :id and :n path
+// parameters in language. Season 0 holds a show's specials. On failure it
+// writes the error response and returns false.
+func (h *MovieHandler) resolveSeason(c *gin.Context, language string) (*models.TMDBTVDetails, *models.TMDBSeason, bool) {
+	ctx := c.Request.Context()
+
+	seasonNumber, ok := pathNumber(c, "n", 0)
+	if !ok {
+		return nil, nil, false
+	}
+	show, ok := h.resolveShow(c, language)
+	if !ok {
+		return nil, nil, false
+	}
+
+	season, err := h.tmdbService.GetTVSeason(ctx, show.ID, seasonNumber, language)
+	if err != nil {
+		respondError(c, err, "failed to fetch TV season")
+		return nil, nil, false
+	}
+
+	tracing.SetAttributes(ctx, tracing.AttrSeason.Int(seasonNumber))
+	return show, season, true
+}
+
+// newEpisode converts a TMDB episode, without its spoiler
+func (h *MovieHandler) newEpisode(show *models.TMDBTVDetails, episode models.TMDBEpisode) models.Episode {
+	return models.Episode{
+		ShowID:        show.ID,
+		SeasonNumber:  episode.SeasonNumber,
+		EpisodeNumber: episode.EpisodeNumber,
+		Name:          episode.Name,
+		AirDate:       episode.AirDate,
+		Overview:      episode.Overview,
+		Runtime:       episode.Runtime,
+		Still:         h.tmdbService.FormatStillURL(episode.StillPath),
+		Rating:        episode.VoteAverage,
+	}
+}
+
+// generateTVSpoiler returns the spoiler for a show, season or episode,
+// generating it on a memory cache miss. Each request is admitted on its own,
+// then concurrent requests for the same spoiler share one generation. A
+// spoiler that fails validation is evicted so the next request generates it
+// again. On failure it writes the error response and returns false.
+func (h *MovieHandler) generateTVSpoiler(c *gin.Context, request services.SpoilerRequest) (string, bool) {
+	ctx := c.Request.Context()
+	key := request.CacheKey()
+
+	if err := h.admitGeneration(ctx, key); err != nil {
+		respondError(c, err, "failed to generate spoiler explanation")
+		return "", false
+	}
+
+	spoiler, _, shared, err := h.textGenerations.Do(ctx, key, func(workCtx context.Context) (string, error) {
+		workCtx = h.generationContext(workCtx)
+		spoiler, err := h.spoilerGenerator.GenerateSpoiler(workCtx, request)
+		if err != nil {
+			return "", err
+		}
+		if err := services.ValidateTVSpoiler(spoiler); err != nil {
+			h.spoilerCache.Evict(key)
+			return "", err
+		}
+		return spoiler, nil
+	})
+	if shared {
+		slog.InfoContext(ctx, "Coalesced request onto in-flight generation", "key", key)
+	}
+	if err != nil {
+		respondError(c, err, "failed to generate spoiler explanation")
+		return "", false
+	}
+	return spoiler, true
+}
+
+// pathNumber parses a numeric path parameter that must be at least min. On
+// failure it writes an invalid_input response and returns false.
+func pathNumber(c *gin.Context, param string, min int) (int, bool) {
+	n, err := strconv.Atoi(c.Param(param))
+	if err != nil || n < min {
+		respondInvalid(c, param, param+" must be a number no less than "+strconv.Itoa(min))
+		return 0, false
+	}
+	return n, true
+}
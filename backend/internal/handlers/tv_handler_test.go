@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"spoiler_api/internal/models"
+)
+
+func TestTVSpoilerValidation(t *testing.T) {
+	tmdb := fakeTMDB{
+		"/tv/1399":          `{"id":1399,"name":"Game of Thrones","first_air_date":"2011-04-17","overview":"Noble families fight.","seasons":[{"season_number":1,"episode_count":1}],"credits":{"cast":[]}}`,
+		"/tv/1399/season/1": `{"season_number":1,"name":"Season 1","air_date":"2011-04-17","episodes":[{"episode_number":1,"season_number":1,"name":"Winter Is Coming"}]}`,
+	}
+
+	endpoints := []struct {
+		name     string
+		handle   func(*MovieHandler) gin.HandlerFunc
+		route    string
+		target   string
+		cacheKey string
+		notFound string
+	}{
+		{"show", func(h *MovieHandler) gin.HandlerFunc { return h.GetTVShow }, "/api/tv/:id", "/api/tv/1399", "tv:1399", "## Show Not Found\nUnknown."},
+		{"season", func(h *MovieHandler) gin.HandlerFunc { return h.GetTVSeason }, "/api/tv/:id/season/:n", "/api/tv/1399/season/1", "tv:1399:s1", "## Season Not Found\nUnknown."},
+		{"episode", func(h *MovieHandler) gin.HandlerFunc { return h.GetTVEpisode }, "/api/tv/:id/season/:n/episode/:e", "/api/tv/1399/season/1/episode/1", "tv:1399:s1e1", "## Episode Not Found\nUnknown."},
+	}
+
+	for _, endpoint := range endpoints {
+		tests := []struct {
+			name       string
+			spoiler    string
+			wantStatus int
+			wantCode   models.ErrorCode
+		}{
+			{"valid", "## Overview\nNoble families fight.\n\n## Ending Explained\nWinter comes.", http.StatusOK, ""},
+			{"not found", endpoint.notFound, http.StatusNotFound, models.ErrorNotFound},
+			{"malformed", "I'm sorry, I can't help with that.", http.StatusServiceUnavailable, models.ErrorUpstreamUnavailable},
+		}
+
+		for _, tt := range tests {
+			t.Run(endpoint.name+"/"+tt.name, func(t *testing.T) {
+				handler, _ := newTestHandler(t, tmdb, tt.spoiler, nil)
+				recorder := serve(endpoint.handle(handler), endpoint.route, endpoint.target)
+
+				if recorder.Code != tt.wantStatus {
+					t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+				}
+				cached := handler.spoilerCache.Contains(endpoint.cacheKey)
+				if cached != (tt.wantStatus == http.StatusOK) {
+					t.Errorf("spoiler cached = %v after status %d", cached, recorder.Code)
+				}
+				if tt.wantCode == "" {
+					return
+				}
+
+				var response models.ErrorResponse
+				if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+					t.Fatalf("decode error response: %v", err)
+				}
+				if response.Code != tt.wantCode {
+					t.Errorf("code = %q, want %q", response.Code, tt.wantCode)
+				}
+			})
+		}
+	}
+}
+
+func TestTVSpoilerRequestYear(t *testing.T) {
+	tmdb := fakeTMDB{
+		"/tv/1399":          `{"id":1399,"name":"Game of Thrones","first_air_date":"2011-04-17","overview":"Noble families fight.","seasons":[{"season_number":2,"episode_count":1}],"credits":{"cast":[]}}`,
+		"/tv/1399/season/2": `{"season_number":2,"name":"Season 2","air_date":"2012-04-01","episodes":[{"episode_number":1,"season_number":2,"name":"The North Remembers","air_date":"2012-04-02"}]}`,
+	}
+
+	tests := []struct {
+		name     string
+		handle   func(*MovieHandler) gin.HandlerFunc
+		route    string
+		target   string
+		wantYear string
+	}{
+		{"show", func(h *MovieHandler) gin.HandlerFunc { return h.GetTVShow }, "/api/tv/:id", "/api/tv/1399", "2011"},
+		{"season", func(h *MovieHandler) gin.HandlerFunc { return h.GetTVSeason }, "/api/tv/:id/season/:n", "/api/tv/1399/season/2", "2012"},
+		{"episode", func(h *MovieHandler) gin.HandlerFunc { return h.GetTVEpisode }, "/api/tv/:id/season/:n/episode/:e", "/api/tv/1399/season/2/episode/1", "2012"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, generator := newTestHandler(t, tmdb, "## Overview\nNoble families fight.\n\n## Ending Explained\nWinter comes.", nil)
+			if recorder := serve(tt.handle(handler), tt.route, tt.target); recorder.Code != http.StatusOK {
+				t.Fatalf("status = %d: %s", recorder.Code, recorder.Body)
+			}
+			if year := generator.LastRequest().Year; year != tt.wantYear {
+				t.Errorf("prompt year = %q, want %q", year, tt.wantYear)
+			}
+		})
+	}
+}
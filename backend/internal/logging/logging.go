@@ -0,0 +1,148 @@
+// Package logging configures the process-wide slog JSON logger. Every record
+// is tagged with the request ID and trace ID carried by its context and
+// scrubbed of API keys and other registered secrets before it is written.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// redacted replaces secrets in log lines and error messages
+const redacted = "[REDACTED]"
+
+// minSecretLength keeps short placeholder values from redacting common words
+const minSecretLength = 8
+
+var (
+	level = new(slog.LevelVar)
+
+	secretsMu sync.RWMutex
+	secrets   []string
+
+	// secretParams matches credentials passed as URL query parameters
+	secretParams = regexp.MustCompile(`(?i)\b((?:api_key|apikey|key|access_token|token)=)[^&\s"'\\]+`)
+)
+
+type requestIDKey struct{}
+
+// Init installs a JSON logger writing to w as the slog default. Output from
+// the standard log package is routed through it as well.
+func Init(w io.Writer) {
+	jsonHandler := slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})
+	slog.SetDefault(slog.New(&handler{next: jsonHandler}))
+}
+
+// SetLevel sets the minimum level logged: debug, info, warn or error
+func SetLevel(name string) error {
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(name)); err != nil {
+		return fmt.Errorf("invalid log level %q: %w", name, err)
+	}
+	level.Set(parsed)
+	return nil
+}
+
+// RegisterSecrets adds values that must never appear in logs or error
+// responses, such as API keys loaded from the environment
+func RegisterSecrets(values ...string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, value := range values {
+		if len(value) >= minSecretLength {
+			secrets = append(secrets, value)
+		}
+	}
+}
+
+// Redact removes registered secrets and credential query parameters from s
+func Redact(s string) string {
+	secretsMu.RLock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
+	}
+	secretsMu.RUnlock()
+
+	return secretParams.ReplaceAllString(s, "${1}"+redacted)
+}
+
+// WithRequestID returns a context carrying the request ID for log records
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID stored in ctx, or ""
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// handler adds the request ID and redacts secrets before passing records on
+type handler struct {
+	next slog.Handler
+}
+
+func (h *handler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.next.Enabled(ctx, l)
+}
+
+func (h *handler) Handle(ctx context.Context, record slog.Record) error {
+	clean := slog.NewRecord(record.Time, record.Level, Redact(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		clean.AddAttrs(redactAttr(attr))
+		return true
+	})
+	if id := RequestID(ctx); id != "" {
+		clean.AddAttrs(slog.String("request_id", id))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		clean.AddAttrs(slog.String("trace_id", spanContext.TraceID().String()))
+	}
+	return h.next.Handle(ctx, clean)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clean := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		clean[i] = redactAttr(attr)
+	}
+	return &handler{next: h.next.WithAttrs(clean)}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{next: h.next.WithGroup(name)}
+}
+
+// redactAttr scrubs string and error values, recursing into groups
+func redactAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, Redact(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		clean := make([]any, len(group))
+		for i, member := range group {
+			clean[i] = redactAttr(member)
+		}
+		return slog.Group(attr.Key, clean...)
+	case slog.KindAny:
+		switch v := value.Any().(type) {
+		case error:
+			return slog.String(attr.Key, Redact(v.Error()))
+		case fmt.Stringer:
+			return slog.String(attr.Key, Redact(v.String()))
+		}
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
@@ -0,0 +1,81 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	RegisterSecrets("tmdb-secret-key", "short", "")
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"registered secret", "calling TMDB with tmdb-secret-key", "calling TMDB with [REDACTED]"},
+		{"short values are not secrets", "a short answer", "a short answer"},
+		{"query parameter", `Get "https://api.themoviedb.org/3/movie/1?api_key=abc123&language=en"`, `Get "https://api.themoviedb.org/3/movie/1?api_key=[REDACTED]&language=en"`},
+		{"key parameter", "https://generativelanguage.googleapis.com/v1/models/gemini:generateContent?key=AIzaXYZ", "https://generativelanguage.googleapis.com/v1/models/gemini:generateContent?key=[REDACTED]"},
+		{"nothing to redact", "Server stopped", "Server stopped"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Redact(tt.in); got != tt.want {
+				t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlerRedactsRecords(t *testing.T) {
+	RegisterSecrets("gemini-secret-key")
+
+	var buf bytes.Buffer
+	logger := slog.New(&handler{next: slog.NewJSONHandler(&buf, nil)}).
+		With("client", "key gemini-secret-key")
+
+	ctx := WithRequestID(context.Background(), "req-42")
+	logger.ErrorContext(ctx, "Gemini call with gemini-secret-key failed",
+		"error", errors.New("POST ?key=gemini-secret-key: 403"),
+		slog.Group("upstream", "url", "https://example.com/?token=abc"),
+	)
+
+	line := buf.String()
+	if strings.Contains(line, "gemini-secret-key") || strings.Contains(line, "token=abc") {
+		t.Fatalf("secret written to the log: %s", line)
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("log line is not JSON: %v", err)
+	}
+	if record["msg"] != "Gemini call with [REDACTED] failed" {
+		t.Errorf("msg = %v", record["msg"])
+	}
+	if record["client"] != "key [REDACTED]" {
+		t.Errorf("client = %v", record["client"])
+	}
+	if record["request_id"] != "req-42" {
+		t.Errorf("request_id = %v, want req-42", record["request_id"])
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	defer level.Set(slog.LevelInfo)
+
+	if err := SetLevel("warn"); err != nil {
+		t.Fatal(err)
+	}
+	if level.Level() != slog.LevelWarn {
+		t.Errorf("level = %v, want WARN", level.Level())
+	}
+	if err := SetLevel("loud"); err == nil {
+		t.Error("SetLevel accepted an unknown level")
+	}
+}
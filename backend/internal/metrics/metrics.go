@@ -0,0 +1,75 @@
+// Package metrics defines the Prometheus series exported on /metrics. The
+// collectors are registered with the default registry on package load.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "spoilerhub"
+
+var (
+	// HTTPRequestDuration is the latency of API requests by Gin route template
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by route, method and status code.",
+		Buckets:   []float64{0.005, 0.025, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"route", "method", "status"})
+
+	// UpstreamRequests counts upstream HTTP attempts by outcome. code is the
+	// HTTP status code, or "error", "timeout", "canceled" (the caller gave up
+	// or its own deadline passed) or "circuit_open" when no response was received.
+	UpstreamRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_requests_total",
+		Help:      "Upstream HTTP attempts (TMDB, Gemini, OpenAI, Supabase) by service and status code.",
+	}, []string{"service", "code"})
+
+	// UpstreamRequestDuration is the latency of upstream HTTP attempts
+	UpstreamRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Latency of upstream HTTP attempts by service, until response headers arrive.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 90},
+	}, []string{"service"})
+
+	// SpoilerLookups counts where spoilers were served from: "database",
+	// "memory" or "miss" (generated by the LLM provider)
+	SpoilerLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "spoiler_cache_lookups_total",
+		Help:      "Spoiler lookups by cache tier: database, memory or miss.",
+	}, []string{"tier"})
+
+	// LLMTokens counts tokens reported by the LLM provider. type is "prompt"
+	// or "completion".
+	LLMTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_tokens_total",
+		Help:      "Tokens used by spoiler generation, from the provider's usage metadata.",
+	}, []string{"provider", "model", "type"})
+
+	// RateLimited counts requests rejected by a rate limit: "requests",
+	// "generations" or "daily_budget"
+	RateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected with 429 by a rate limit or the daily generation budget.",
+	}, []string{"limit"})
+
+	// DatabaseWriteFailures counts failed attempts to apply a queued database write
+	DatabaseWriteFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "database_write_failures_total",
+		Help:      "Failed attempts to apply a queued database write, by operation (save or increment).",
+	}, []string{"op"})
+
+	// DatabaseWritesDropped counts queued database writes the store rejected for good
+	DatabaseWritesDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "database_writes_dropped_total",
+		Help:      "Queued database writes dropped because the store rejected them and a retry would not help, by operation.",
+	}, []string{"op"})
+)
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"spoiler_api/internal/models"
+	"spoiler_api/internal/services"
+)
+
+// AdminAuth protects admin routes. It must run after APIKeyAuth: callers
+// whose API key has the admin scope are let through, as are requests with the
+// shared token in the X-Admin-Token header, which is how the first admin key
+// is minted. An empty token disables the header.
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caller := services.CallerFromContext(c.Request.Context())
+		if caller.Has(services.ScopeAdmin) {
+			c.Next()
+			return
+		}
+
+		provided := c.GetHeader("X-Admin-Token")
+		if provided == "" && caller != nil && caller.Key != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+				Code:  models.ErrorForbidden,
+				Error: "API key is missing the admin scope",
+			})
+			return
+		}
+
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+				Code:  models.ErrorForbidden,
+				Error: "admin API requires an API key with the admin scope (ADMIN_TOKEN not set)",
+			})
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+				Code:  models.ErrorUnauthorized,
+				Error: "invalid admin token",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
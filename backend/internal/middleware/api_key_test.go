@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"spoiler_api/internal/models"
+	"spoiler_api/internal/services"
+)
+
+// fakeKeyStore holds API keys by the hash of their secret
+type fakeKeyStore map[string]*models.APIKey
+
+func (s fakeKeyStore) CreateAPIKey(ctx context.Context, key *models.APIKey, hash string) error {
+	s[hash] = key
+	return nil
+}
+
+func (s fakeKeyStore) FindAPIKey(ctx context.Context, hash string) (*models.APIKey, error) {
+	return s[hash], nil
+}
+
+func (s fakeKeyStore) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	return nil, nil
+}
+
+func (s fakeKeyStore) RevokeAPIKey(ctx context.Context, id string) error {
+	return nil
+}
+
+func (s fakeKeyStore) AddAPIKeyUsage(ctx context.Context, id string, usage models.APIKeyUsage, lastUsed time.Time) error {
+	return nil
+}
+
+func TestAPIKeyAuthAnonymousScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  []string
+		wantErr bool
+	}{
+		{name: "none", scopes: nil},
+		{name: "known", scopes: []string{"read-cached", " generate "}},
+		{name: "typo", scopes: []string{"read-cached", "genrate"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := APIKeyAuth(nil, tt.scopes)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("APIKeyAuth(%q) error = %v, want error %v", tt.scopes, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	hash := func(secret string) string {
+		sum := sha256.Sum256([]byte(secret))
+		return hex.EncodeToString(sum[:])
+	}
+	store := fakeKeyStore{
+		hash("reader"):  {ID: "1", Scopes: []string{"read-cached"}},
+		hash("mangled"): {ID: "2", Scopes: []string{"read-cached", "everything"}},
+	}
+	keys := services.NewAPIKeyService(store)
+	t.Cleanup(func() { keys.Close(context.Background()) })
+
+	auth, err := APIKeyAuth(keys, []string{"read-cached", "generate"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(auth)
+	router.GET("/", func(c *gin.Context) {
+		caller := services.CallerFromContext(c.Request.Context())
+		c.JSON(http.StatusOK, gin.H{"generate": caller.Has(services.ScopeGenerate)})
+	})
+
+	tests := []struct {
+		name         string
+		key          string
+		wantStatus   int
+		wantGenerate bool
+	}{
+		{name: "anonymous", wantStatus: http.StatusOK, wantGenerate: true},
+		{name: "key scopes replace the anonymous ones", key: "reader", wantStatus: http.StatusOK},
+		{name: "unknown key", key: "stranger", wantStatus: http.StatusUnauthorized},
+		{name: "stored key with an invalid scope", key: "mangled", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.key != "" {
+				request.Header.Set(APIKeyHeader, tt.key)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			want := `{"generate":false}`
+			if tt.wantGenerate {
+				want = `{"generate":true}`
+			}
+			if got := recorder.Body.String(); got != want {
+				t.Errorf("body = %s, want %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSOptions configures cross-origin access to the API
+type CORSOptions struct {
+	// AllowedOrigins are exact origins ("https://spoilerhub.app"), wildcard
+	// subdomain patterns ("https://*.vercel.app") or "*" for any origin
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	MaxAge           time.Duration
+	AllowCredentials bool
+}
+
+// corsExposedHeaders are response headers browsers may read cross-origin
+const corsExposedHeaders = "X-Request-ID, Retry-After"
+
+// originPattern matches an Origin header against one allowed origin
+type originPattern struct {
+	exact  string // whole origin, when there is no wildcard
+	prefix string // scheme and "://", for wildcard subdomains
+	suffix string // "." and the parent domain (and port), for wildcard subdomains
+}
+
+// matches reports whether origin (already lowercased) is allowed by p
+func (p originPattern) matches(origin string) bool {
+	if p.exact != "" {
+		return origin == p.exact
+	}
+	if !strings.HasPrefix(origin, p.prefix) || !strings.HasSuffix(origin, p.suffix) {
+		return false
+	}
+	subdomain := origin[len(p.prefix) : len(origin)-len(p.suffix)]
+	return subdomain != "" && !strings.ContainsAny(subdomain, "/:@")
+}
+
+// CORS answers preflight requests and adds CORS headers for allowed origins,
+// echoing the request's Origin rather than "*" so credentials can be allowed.
+// Every response varies by Origin so caches keep them apart. Requests from other
+// origins get no CORS headers, which makes browsers block them.
+func CORS(opts CORSOptions) (gin.HandlerFunc, error) {
+	anyOrigin := false
+	var patterns []originPattern
+	for _, origin := range opts.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimRight(origin, "/"))
+		if origin == "*" {
+			anyOrigin = true
+			continue
+		}
+		pattern, err := parseOriginPattern(origin)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+	if anyOrigin && opts.AllowCredentials {
+		return nil, fmt.Errorf("CORS cannot allow credentials for any origin (\"*\"); list the allowed origins instead")
+	}
+
+	methods := strings.Join(opts.AllowedMethods, ", ")
+	headers := strings.Join(opts.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(int(opts.MaxAge.Seconds()))
+
+	allowed := func(origin string) bool {
+		if anyOrigin {
+			return true
+		}
+		origin = strings.ToLower(origin)
+		for _, pattern := range patterns {
+			if pattern.matches(origin) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(c *gin.Context) {
+		// Every response varies by Origin, including those to requests without
+		// one, so a shared cache never serves a response without CORS headers to
+		// an allowed origin
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		if !allowed(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusNoContent)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		header.Set("Access-Control-Allow-Origin", origin)
+		if opts.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			header.Set("Access-Control-Allow-Methods", methods)
+			header.Set("Access-Control-Allow-Headers", headers)
+			if opts.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		header.Set("Access-Control-Expose-Headers", corsExposedHeaders)
+		c.Next()
+	}, nil
+}
+
+// parseOriginPattern validates an allowed origin. A wildcard may only stand
+// for the leftmost labels of the host: "https://*.example.com".
+func parseOriginPattern(origin string) (originPattern, error) {
+	scheme, host, found := strings.Cut(origin, "://")
+	if !found || scheme == "" || host == "" || strings.Contains(host, "/") {
+		return originPattern{}, fmt.Errorf("invalid CORS origin %q (expected scheme://host[:port])", origin)
+	}
+	if !strings.Contains(host, "*") {
+		return originPattern{exact: origin}, nil
+	}
+
+	parent, found := strings.CutPrefix(host, "*.")
+	if !found || parent == "" || strings.Contains(parent, "*") {
+		return originPattern{}, fmt.Errorf("invalid CORS origin %q (a wildcard must be a leading \"*.\")", origin)
+	}
+	return originPattern{prefix: scheme + "://", suffix: "." + parent}, nil
+}
@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORSOriginPatterns(t *testing.T) {
+	allowed := []string{"https://spoilerhub.app", "https://*.vercel.app/", "http://localhost:3000"}
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://spoilerhub.app", true},
+		{"HTTPS://SpoilerHub.app", true},
+		{"http://spoilerhub.app", false},
+		{"https://spoilerhub.app:8443", false},
+		{"https://api.spoilerhub.app", false},
+		{"https://preview-42.vercel.app", true},
+		{"https://a.b.vercel.app", true},
+		{"https://vercel.app", false},
+		{"https://.vercel.app", false},
+		{"http://preview.vercel.app", false},
+		{"https://evil.com/.vercel.app", false},
+		{"https://user@x.vercel.app", false},
+		{"https://evilvercel.app", false},
+		{"http://localhost:3000", true},
+		{"http://localhost:3001", false},
+	}
+
+	handler := newCORSRouter(t, CORSOptions{AllowedOrigins: allowed})
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			recorder := corsRequest(handler, http.MethodGet, tt.origin)
+			got := recorder.Header().Get("Access-Control-Allow-Origin")
+			if (got == tt.origin) != tt.want || (!tt.want && got != "") {
+				t.Errorf("Access-Control-Allow-Origin = %q, want allowed = %v", got, tt.want)
+			}
+			if vary := recorder.Header().Get("Vary"); vary != "Origin" {
+				t.Errorf("Vary = %q, want Origin", vary)
+			}
+		})
+	}
+}
+
+func TestCORSResponses(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        CORSOptions
+		method      string
+		origin      string
+		wantStatus  int
+		wantAllow   string
+		wantMethods string
+		wantCreds   string
+	}{
+		{
+			name:       "no origin still varies",
+			opts:       CORSOptions{AllowedOrigins: []string{"https://spoilerhub.app"}},
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "allowed request",
+			opts:       CORSOptions{AllowedOrigins: []string{"https://spoilerhub.app"}, AllowCredentials: true},
+			method:     http.MethodGet,
+			origin:     "https://spoilerhub.app",
+			wantStatus: http.StatusOK,
+			wantAllow:  "https://spoilerhub.app",
+			wantCreds:  "true",
+		},
+		{
+			name:        "allowed preflight",
+			opts:        CORSOptions{AllowedOrigins: []string{"https://spoilerhub.app"}, AllowedMethods: []string{"GET", "POST"}},
+			method:      http.MethodOptions,
+			origin:      "https://spoilerhub.app",
+			wantStatus:  http.StatusNoContent,
+			wantAllow:   "https://spoilerhub.app",
+			wantMethods: "GET, POST",
+		},
+		{
+			name:       "blocked preflight",
+			opts:       CORSOptions{AllowedOrigins: []string{"https://spoilerhub.app"}, AllowedMethods: []string{"GET"}},
+			method:     http.MethodOptions,
+			origin:     "https://evil.com",
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "any origin",
+			opts:       CORSOptions{AllowedOrigins: []string{"*"}},
+			method:     http.MethodGet,
+			origin:     "https://anything.example",
+			wantStatus: http.StatusOK,
+			wantAllow:  "https://anything.example",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := corsRequest(newCORSRouter(t, tt.opts), tt.method, tt.origin)
+			header := recorder.Header()
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if got := header.Get("Vary"); got != "Origin" {
+				t.Errorf("Vary = %q, want Origin", got)
+			}
+			if got := header.Get("Access-Control-Allow-Origin"); got != tt.wantAllow {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantAllow)
+			}
+			if got := header.Get("Access-Control-Allow-Methods"); got != tt.wantMethods {
+				t.Errorf("Access-Control-Allow-Methods = %q, want %q", got, tt.wantMethods)
+			}
+			if got := header.Get("Access-Control-Allow-Credentials"); got != tt.wantCreds {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, tt.wantCreds)
+			}
+		})
+	}
+}
+
+func TestCORSRejectsInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts CORSOptions
+	}{
+		{"missing scheme", CORSOptions{AllowedOrigins: []string{"spoilerhub.app"}}},
+		{"path", CORSOptions{AllowedOrigins: []string{"https://spoilerhub.app/app"}}},
+		{"inner wildcard", CORSOptions{AllowedOrigins: []string{"https://app.*.com"}}},
+		{"bare wildcard host", CORSOptions{AllowedOrigins: []string{"https://*"}}},
+		{"any origin with credentials", CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := CORS(tt.opts); err == nil {
+				t.Errorf("CORS(%v) accepted an invalid configuration", tt.opts.AllowedOrigins)
+			}
+		})
+	}
+}
+
+// newCORSRouter returns a router with the CORS middleware in front of GET /
+func newCORSRouter(t *testing.T, opts CORSOptions) http.Handler {
+	t.Helper()
+	cors, err := CORS(opts)
+	if err != nil {
+		t.Fatalf("CORS: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(cors)
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+// corsRequest sends a request from origin, as a preflight for OPTIONS
+func corsRequest(handler http.Handler, method, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	if method == http.MethodOptions {
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	return recorder
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// requestCount returns how many requests were observed with the given labels
+func requestCount(t *testing.T, route, status string) uint64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "spoilerhub_http_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["route"] == route && labels["method"] == http.MethodGet && labels["status"] == status {
+				return metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	return 0
+}
+
+func TestMetricsLabelsByRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Metrics())
+	router.GET("/api/movie/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	templateBefore := requestCount(t, "/api/movie/:id", "200")
+	unmatchedBefore := requestCount(t, "unmatched", "404")
+
+	for _, target := range []string{"/api/movie/27205", "/api/movie/157336", "/nowhere"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	if got := requestCount(t, "/api/movie/:id", "200") - templateBefore; got != 2 {
+		t.Errorf("requests observed under the route template = %d, want 2", got)
+	}
+	if got := requestCount(t, "unmatched", "404") - unmatchedBefore; got != 1 {
+		t.Errorf("requests observed as unmatched = %d, want 1", got)
+	}
+	if got := requestCount(t, "/api/movie/27205", "200"); got != 0 {
+		t.Errorf("requests observed under the raw path = %d, want 0", got)
+	}
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"spoiler_api/internal/models"
+	"spoiler_api/internal/ratelimit"
+	"spoiler_api/internal/services"
+)
+
+// RateLimit identifies the client by API key, or by IP for anonymous callers,
+// stores that key in the request context for the generation limit, and
+// rejects requests over the client's request bucket with 429 and Retry-After.
+// It must run after APIKeyAuth.
+func RateLimit(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := "ip:" + c.ClientIP()
+		if caller := services.CallerFromContext(c.Request.Context()); caller != nil && caller.Key != nil {
+			key = "key:" + caller.Key.ID
+		}
+		c.Request = c.Request.WithContext(ratelimit.WithClient(c.Request.Context(), key))
+
+		var limitErr *ratelimit.Error
+		if err := limiter.Allow(key); errors.As(err, &limitErr) {
+			abortRateLimited(c, limitErr)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// abortRateLimited writes a 429 response for a rate limit error
+func abortRateLimited(c *gin.Context, err *ratelimit.Error) {
+	retryAfter := int(math.Ceil(err.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, models.ErrorResponse{
+		Code:       models.ErrorRateLimited,
+		Error:      err.Error(),
+		Details:    gin.H{"limit": err.Limit},
+		RetryAfter: retryAfter,
+	})
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// APIKey is a partner API key as shown by the admin API. The key itself is
+// never stored; only its hash and a short prefix to recognise it by.
+type APIKey struct {
+	ID         string      `json:"id"`
+	Name       string      `json:"name"`
+	Prefix     string      `json:"prefix"`
+	Scopes     []string    `json:"scopes"`
+	CreatedAt  time.Time   `json:"created_at"`
+	RevokedAt  *time.Time  `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time  `json:"last_used_at,omitempty"`
+	Usage      APIKeyUsage `json:"usage"`
+}
+
+// APIKeyUsage counts what a key has been used for
+type APIKeyUsage struct {
+	Requests    int64 `json:"requests"`
+	Generations int64 `json:"generations"`
+	Tokens      int64 `json:"tokens"`
+}
+
+// CreateAPIKeyRequest is the body of POST /api/admin/keys
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// CreateAPIKeyResponse returns a new key. Key is only ever shown here.
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import "net/http"
+
+// ErrorCode is a stable, machine-readable error identifier. Clients branch on
+// it rather than on the message text, which may change.
+type ErrorCode string
+
+const (
+	// ErrorNotFound means the requested movie or resource does not exist
+	ErrorNotFound ErrorCode = "not_found"
+	// ErrorAmbiguous means a title matches several movies; details lists them
+	ErrorAmbiguous ErrorCode = "ambiguous"
+	// ErrorUpstreamUnavailable means TMDB, the LLM or the database failed
+	ErrorUpstreamUnavailable ErrorCode = "upstream_unavailable"
+	// ErrorUpstreamTimeout means TMDB, the LLM or the database did not answer in time
+	ErrorUpstreamTimeout ErrorCode = "upstream_timeout"
+	// ErrorRateLimited means a rate limit or the daily generation budget was hit
+	ErrorRateLimited ErrorCode = "rate_limited"
+	// ErrorInvalidInput means a parameter or request body is missing or malformed
+	ErrorInvalidInput ErrorCode = "invalid_input"
+	// ErrorGenerationRefused means a spoiler will not be generated for this
+	// caller or movie: the API key lacks the generate scope, or the model declined
+	ErrorGenerationRefused ErrorCode = "generation_refused"
+	// ErrorUnauthorized means an API key is required, or the one sent is invalid
+	ErrorUnauthorized ErrorCode = "unauthorized"
+	// ErrorForbidden means the API key lacks the scope for the endpoint
+	ErrorForbidden ErrorCode = "forbidden"
+	// ErrorInternal is any other failure
+	ErrorInternal ErrorCode = "internal"
+)
+
+// Status returns the HTTP status that always accompanies the code
+func (c ErrorCode) Status() int {
+	switch c {
+	case ErrorNotFound:
+		return http.StatusNotFound
+	case ErrorAmbiguous:
+		return http.StatusMultipleChoices
+	case ErrorUpstreamUnavailable:
+		return http.StatusServiceUnavailable
+	case ErrorUpstreamTimeout:
+		return http.StatusGatewayTimeout
+	case ErrorRateLimited:
+		return http.StatusTooManyRequests
+	case ErrorInvalidInput:
+		return http.StatusBadRequest
+	case ErrorGenerationRefused, ErrorForbidden:
+		return http.StatusForbidden
+	case ErrorUnauthorized:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ErrorResponse represents an error response
+type ErrorResponse struct {
+	Code  ErrorCode `json:"code"`
+	Error string    `json:"error"`
+	// Details carries code-specific data, e.g. the candidates of an ambiguous title
+	Details interface{} `json:"details,omitempty"`
+	// RetryAfter is how many seconds to wait before retrying, mirroring the Retry-After header
+	RetryAfter int `json:"retry_after,omitempty"`
+}
@@ -2,6 +2,7 @@ package models
 
 // MovieResponse represents the API response for a movie with spoiler details
 type MovieResponse struct {
+	TMDBID   int      `json:"tmdb_id"`
 	Title    string   `json:"title"`
 	Year     string   `json:"year"`
 	Poster   string   `json:"poster"`
@@ -10,6 +11,62 @@ type MovieResponse struct {
 	Genres   []string `json:"genres"`
 	Overview string   `json:"overview"`
 	Spoiler  string   `json:"spoiler"`
+
+	// Level is how much of the plot Spoiler reveals
+	Level SpoilerLevel `json:"level,omitempty"`
+	// Language is the code of the language Spoiler is written in
+	Language string `json:"language,omitempty"`
+
+	// Structured is the parsed form of Spoiler; nil when the text could not be validated
+	Structured *StructuredSpoiler `json:"structured,omitempty"`
+}
+
+// ProgressResponse represents the API response for GET /api/movie/progress:
+// the plot of a movie up to the minute the viewer has reached
+type ProgressResponse struct {
+	TMDBID  int    `json:"tmdb_id"`
+	Title   string `json:"title"`
+	Year    string `json:"year"`
+	Poster  string `json:"poster"`
+	Runtime int    `json:"runtime"`
+	Minute  int    `json:"minute"`
+	// Language is the code of the language the scenes are written in
+	Language string  `json:"language"`
+	Scenes   []Scene `json:"scenes"`
+	// Finished is true once minute reaches the runtime, when every scene is included
+	Finished bool `json:"finished"`
+}
+
+// Scene is one timestamped scene of a movie's outline
+type Scene struct {
+	Minute      int    `json:"minute"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// MovieDetailsResponse represents the API response for GET /api/movie/:id
+type MovieDetailsResponse struct {
+	TMDBID        int          `json:"tmdb_id"`
+	Title         string       `json:"title"`
+	Year          string       `json:"year"`
+	Poster        string       `json:"poster"`
+	Backdrop      string       `json:"backdrop"`
+	Rating        float64      `json:"rating"`
+	Genres        []string     `json:"genres"`
+	Overview      string       `json:"overview"`
+	Runtime       int          `json:"runtime"`
+	Tagline       string       `json:"tagline"`
+	Certification string       `json:"certification"`
+	Director      string       `json:"director"`
+	Cast          []CastMember `json:"cast"`
+	Keywords      []string     `json:"keywords"`
+}
+
+// CastMember represents a top-billed actor and their character
+type CastMember struct {
+	Name      string `json:"name"`
+	Character string `json:"character"`
+	Profile   string `json:"profile"`
 }
 
 // TMDBSearchResult represents the TMDB API search response
@@ -19,14 +76,75 @@ type TMDBSearchResult struct {
 
 // TMDBMovie represents a single movie from TMDB API
 type TMDBMovie struct {
-	ID            int    `json:"id"`
-	Title         string `json:"title"`
-	ReleaseDate   string `json:"release_date"`
-	PosterPath    string `json:"poster_path"`
-	BackdropPath  string `json:"backdrop_path"`
+	ID            int     `json:"id"`
+	Title         string  `json:"title"`
+	OriginalTitle string  `json:"original_title"`
+	ReleaseDate   string  `json:"release_date"`
+	PosterPath    string  `json:"poster_path"`
+	BackdropPath  string  `json:"backdrop_path"`
 	VoteAverage   float64 `json:"vote_average"`
-	Overview      string `json:"overview"`
-	GenreIDs      []int  `json:"genre_ids"`
+	Popularity    float64 `json:"popularity"`
+	Overview      string  `json:"overview"`
+	GenreIDs      []int   `json:"genre_ids"`
+}
+
+// TMDBMovieDetails represents the TMDB /movie/{id} response with
+// credits, release_dates and keywords appended
+type TMDBMovieDetails struct {
+	ID            int         `json:"id"`
+	Title         string      `json:"title"`
+	OriginalTitle string      `json:"original_title"`
+	ReleaseDate   string      `json:"release_date"`
+	PosterPath    string      `json:"poster_path"`
+	BackdropPath  string      `json:"backdrop_path"`
+	VoteAverage   float64     `json:"vote_average"`
+	Popularity    float64     `json:"popularity"`
+	Overview      string      `json:"overview"`
+	Runtime       int         `json:"runtime"`
+	Tagline       string      `json:"tagline"`
+	Genres        []TMDBGenre `json:"genres"`
+	Credits       TMDBCredits `json:"credits"`
+	ReleaseDates  struct {
+		Results []TMDBCountryReleases `json:"results"`
+	} `json:"release_dates"`
+	Keywords struct {
+		Keywords []TMDBKeyword `json:"keywords"`
+	} `json:"keywords"`
+}
+
+// TMDBCredits represents the cast and crew of a movie
+type TMDBCredits struct {
+	Cast []TMDBCastMember `json:"cast"`
+	Crew []TMDBCrewMember `json:"crew"`
+}
+
+// TMDBCastMember represents an actor and the character they play
+type TMDBCastMember struct {
+	Name        string `json:"name"`
+	Character   string `json:"character"`
+	Order       int    `json:"order"`
+	ProfilePath string `json:"profile_path"`
+}
+
+// TMDBCrewMember represents a crew credit
+type TMDBCrewMember struct {
+	Name string `json:"name"`
+	Job  string `json:"job"`
+}
+
+// TMDBCountryReleases represents the release dates of a movie in one country
+type TMDBCountryReleases struct {
+	Country      string `json:"iso_3166_1"`
+	ReleaseDates []struct {
+		Certification string `json:"certification"`
+		Type          int    `json:"type"`
+	} `json:"release_dates"`
+}
+
+// TMDBKeyword represents a keyword tagged on a movie
+type TMDBKeyword struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
 }
 
 // TMDBGenreResponse represents the genres from TMDB API
@@ -57,7 +175,15 @@ type GeminiPart struct {
 
 // GeminiResponse represents the response from Gemini API
 type GeminiResponse struct {
-	Candidates []GeminiCandidate `json:"candidates"`
+	Candidates    []GeminiCandidate    `json:"candidates"`
+	UsageMetadata *GeminiUsageMetadata `json:"usageMetadata,omitempty"`
+}
+
+// GeminiUsageMetadata reports the tokens billed for a Gemini request
+type GeminiUsageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
 }
 
 // GeminiCandidate represents a candidate response from Gemini API
@@ -65,7 +191,40 @@ type GeminiCandidate struct {
 	Content GeminiContent `json:"content"`
 }
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error string `json:"error"`
+// OpenAIChatRequest represents a request to an OpenAI-compatible chat completions API
+type OpenAIChatRequest struct {
+	Model    string              `json:"model"`
+	Messages []OpenAIChatMessage `json:"messages"`
+}
+
+// OpenAIChatMessage represents a single chat message
+type OpenAIChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// OpenAIChatResponse represents the response from an OpenAI-compatible chat completions API
+type OpenAIChatResponse struct {
+	Choices []OpenAIChatChoice `json:"choices"`
+}
+
+// OpenAIChatChoice represents a completion choice
+type OpenAIChatChoice struct {
+	Message OpenAIChatMessage `json:"message"`
+}
+
+// AmbiguousMovieDetails are the details of an ambiguous error: the movies a
+// title could refer to
+type AmbiguousMovieDetails struct {
+	Candidates []MovieCandidate `json:"candidates"`
+}
+
+// MovieCandidate is one possible match for an ambiguous title
+type MovieCandidate struct {
+	TMDBID   int     `json:"tmdb_id"`
+	Title    string  `json:"title"`
+	Year     string  `json:"year"`
+	Poster   string  `json:"poster"`
+	Rating   float64 `json:"rating"`
+	Overview string  `json:"overview"`
 }
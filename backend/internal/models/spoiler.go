@@ -0,0 +1,73 @@
+package models
+
+// CharacterStatus is the final fate of a character at the end of a film
+type CharacterStatus string
+
+const (
+	CharacterAlive   CharacterStatus = "ALIVE"
+	CharacterDead    CharacterStatus = "DEAD"
+	CharacterUnknown CharacterStatus = "UNKNOWN"
+)
+
+// SpoilerLevel is how much of the plot a spoiler reveals
+type SpoilerLevel string
+
+const (
+	// SpoilerTeaser covers only the setup, without twists
+	SpoilerTeaser SpoilerLevel = "teaser"
+	// SpoilerPartial covers the story up to the major turning point, but not the ending
+	SpoilerPartial SpoilerLevel = "partial"
+	// SpoilerFull covers everything, including the ending and character fates
+	SpoilerFull SpoilerLevel = "full"
+)
+
+// Valid reports whether l is a known level
+func (l SpoilerLevel) Valid() bool {
+	switch l {
+	case SpoilerTeaser, SpoilerPartial, SpoilerFull:
+		return true
+	default:
+		return false
+	}
+}
+
+// StructuredSpoiler is the typed, validated form of a generated spoiler
+type StructuredSpoiler struct {
+	Overview            string          `json:"overview"`
+	StorySections       []StorySection  `json:"story_sections"`
+	KeyMoments          []KeyMoment     `json:"key_moments"`
+	CharacterFates      []CharacterFate `json:"character_fates"`
+	Symbolism           []string        `json:"symbolism"`
+	HiddenClues         []string        `json:"hidden_clues"`
+	FanTheories         []string        `json:"fan_theories"`
+	UnansweredQuestions []string        `json:"unanswered_questions"`
+}
+
+// StorySection represents one chronological part of the plot breakdown
+type StorySection struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// KeyMoment represents a pivotal scene
+type KeyMoment struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// CharacterFate represents a main character and how their arc ends
+type CharacterFate struct {
+	Name    string          `json:"name"`
+	Actor   string          `json:"actor"`
+	Status  CharacterStatus `json:"status"`
+	Summary string          `json:"summary"`
+}
+
+// SpoilerChunk is a piece of streamed spoiler text tagged with the markdown
+// section it belongs to
+type SpoilerChunk struct {
+	Section string `json:"section"`
+	Heading string `json:"heading"`
+	Text    string `json:"text"`
+}
@@ -0,0 +1,121 @@
+package models
+
+// TVShow represents the API response for a TV show, with the series spoiler
+// when requested through GET /api/tv/:id
+type TVShow struct {
+	TMDBID          int             `json:"tmdb_id"`
+	Name            string          `json:"name"`
+	Year            string          `json:"year"`
+	Poster          string          `json:"poster"`
+	Backdrop        string          `json:"backdrop"`
+	Rating          float64         `json:"rating"`
+	Genres          []string        `json:"genres"`
+	Overview        string          `json:"overview"`
+	Status          string          `json:"status,omitempty"`
+	NumberOfSeasons int             `json:"number_of_seasons,omitempty"`
+	Seasons         []SeasonSummary `json:"seasons,omitempty"`
+	Spoiler         string          `json:"spoiler,omitempty"`
+}
+
+// SeasonSummary is one season in a show's season list
+type SeasonSummary struct {
+	SeasonNumber int    `json:"season_number"`
+	Name         string `json:"name"`
+	EpisodeCount int    `json:"episode_count"`
+	AirDate      string `json:"air_date"`
+	Poster       string `json:"poster"`
+}
+
+// Season represents the API response for GET /api/tv/:id/season/:n with the season recap
+type Season struct {
+	ShowID       int       `json:"show_id"`
+	ShowName     string    `json:"show_name"`
+	SeasonNumber int       `json:"season_number"`
+	Name         string    `json:"name"`
+	AirDate      string    `json:"air_date"`
+	Overview     string    `json:"overview"`
+	Poster       string    `json:"poster"`
+	Episodes     []Episode `json:"episodes"`
+	Spoiler      string    `json:"spoiler,omitempty"`
+}
+
+// Episode represents a single episode, with its breakdown when requested
+// through GET /api/tv/:id/season/:n/episode/:e
+type Episode struct {
+	ShowID        int     `json:"show_id"`
+	ShowName      string  `json:"show_name,omitempty"`
+	SeasonNumber  int     `json:"season_number"`
+	EpisodeNumber int     `json:"episode_number"`
+	Name          string  `json:"name"`
+	AirDate       string  `json:"air_date"`
+	Overview      string  `json:"overview"`
+	Runtime       int     `json:"runtime"`
+	Still         string  `json:"still"`
+	Rating        float64 `json:"rating"`
+	Spoiler       string  `json:"spoiler,omitempty"`
+}
+
+// TMDBTVSearchResult represents the TMDB /search/tv response
+type TMDBTVSearchResult struct {
+	Results []TMDBTVShow `json:"results"`
+}
+
+// TMDBTVShow represents a single show from TMDB search results
+type TMDBTVShow struct {
+	ID           int     `json:"id"`
+	Name         string  `json:"name"`
+	FirstAirDate string  `json:"first_air_date"`
+	PosterPath   string  `json:"poster_path"`
+	BackdropPath string  `json:"backdrop_path"`
+	VoteAverage  float64 `json:"vote_average"`
+	Popularity   float64 `json:"popularity"`
+	Overview     string  `json:"overview"`
+	GenreIDs     []int   `json:"genre_ids"`
+}
+
+// TMDBTVDetails represents the TMDB /tv/{id} response with credits appended
+type TMDBTVDetails struct {
+	ID              int                 `json:"id"`
+	Name            string              `json:"name"`
+	FirstAirDate    string              `json:"first_air_date"`
+	PosterPath      string              `json:"poster_path"`
+	BackdropPath    string              `json:"backdrop_path"`
+	VoteAverage     float64             `json:"vote_average"`
+	Overview        string              `json:"overview"`
+	Status          string              `json:"status"`
+	NumberOfSeasons int                 `json:"number_of_seasons"`
+	Genres          []TMDBGenre         `json:"genres"`
+	Seasons         []TMDBSeasonSummary `json:"seasons"`
+	Credits         TMDBCredits         `json:"credits"`
+}
+
+// TMDBSeasonSummary represents a season in the TMDB /tv/{id} response
+type TMDBSeasonSummary struct {
+	SeasonNumber int    `json:"season_number"`
+	Name         string `json:"name"`
+	EpisodeCount int    `json:"episode_count"`
+	AirDate      string `json:"air_date"`
+	PosterPath   string `json:"poster_path"`
+}
+
+// TMDBSeason represents the TMDB /tv/{id}/season/{n} response
+type TMDBSeason struct {
+	SeasonNumber int           `json:"season_number"`
+	Name         string        `json:"name"`
+	AirDate      string        `json:"air_date"`
+	Overview     string        `json:"overview"`
+	PosterPath   string        `json:"poster_path"`
+	Episodes     []TMDBEpisode `json:"episodes"`
+}
+
+// TMDBEpisode represents an episode in a TMDB season response
+type TMDBEpisode struct {
+	EpisodeNumber int     `json:"episode_number"`
+	SeasonNumber  int     `json:"season_number"`
+	Name          string  `json:"name"`
+	AirDate       string  `json:"air_date"`
+	Overview      string  `json:"overview"`
+	Runtime       int     `json:"runtime"`
+	StillPath     string  `json:"still_path"`
+	VoteAverage   float64 `json:"vote_average"`
+}
@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"spoiler_api/internal/metrics"
+)
+
+// budgetLimit names the daily budget in errors and metrics
+const budgetLimit = "daily_budget"
+
+// DailyBudget caps the LLM tokens spent per UTC day across all clients. A nil
+// DailyBudget is unlimited. Spend is held in memory, so a restart starts the
+// day's count afresh.
+type DailyBudget struct {
+	limit int64
+
+	mu    sync.Mutex
+	day   time.Time
+	spent int64
+}
+
+// BudgetStats is a snapshot of the budget, exposed through /health
+type BudgetStats struct {
+	Limit    int64     `json:"limit"`
+	Spent    int64     `json:"spent"`
+	ResetsAt time.Time `json:"resets_at"`
+}
+
+// NewDailyBudget creates a budget of limit tokens per day. It returns nil (no
+// budget) when limit is not positive.
+func NewDailyBudget(limit int64) *DailyBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &DailyBudget{limit: limit, day: today()}
+}
+
+// Spend records tokens used by a generation
+func (b *DailyBudget) Spend(tokens int) {
+	if b == nil || tokens <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rollover()
+	b.spent += int64(tokens)
+}
+
+// Check returns an *Error lasting until the next reset once the day's budget
+// is used up. Generations already running may overshoot it slightly.
+func (b *DailyBudget) Check() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rollover()
+	if b.spent < b.limit {
+		return nil
+	}
+	metrics.RateLimited.WithLabelValues(budgetLimit).Inc()
+	return &Error{Limit: budgetLimit, RetryAfter: time.Until(b.day.AddDate(0, 0, 1))}
+}
+
+// Stats returns the current spend, or nil when there is no budget
+func (b *DailyBudget) Stats() *BudgetStats {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.rollover()
+	return &BudgetStats{Limit: b.limit, Spent: b.spent, ResetsAt: b.day.AddDate(0, 0, 1)}
+}
+
+// rollover resets the spend when a new UTC day has started
+func (b *DailyBudget) rollover() {
+	if day := today(); day.After(b.day) {
+		b.day = day
+		b.spent = 0
+	}
+}
+
+// today returns midnight UTC of the current day
+func today() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
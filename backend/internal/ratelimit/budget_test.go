@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDailyBudget(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     int64
+		spend     []int
+		wantSpent int64
+		wantErr   bool
+	}{
+		{name: "under the limit", limit: 100, spend: []int{40, 59}, wantSpent: 99},
+		{name: "at the limit", limit: 100, spend: []int{60, 40}, wantSpent: 100, wantErr: true},
+		{name: "overshoot", limit: 100, spend: []int{250}, wantSpent: 250, wantErr: true},
+		{name: "non-positive spends ignored", limit: 100, spend: []int{0, -50, 10}, wantSpent: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := NewDailyBudget(tt.limit)
+			for _, tokens := range tt.spend {
+				budget.Spend(tokens)
+			}
+
+			err := budget.Check()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Check() = %v, want error: %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var limitErr *Error
+				if !errors.As(err, &limitErr) || limitErr.Limit != budgetLimit {
+					t.Fatalf("Check() = %v, want a %s *Error", err, budgetLimit)
+				}
+				if limitErr.RetryAfter <= 0 || limitErr.RetryAfter > 24*time.Hour {
+					t.Errorf("RetryAfter = %v, want the time until midnight UTC", limitErr.RetryAfter)
+				}
+			}
+
+			stats := budget.Stats()
+			if stats.Limit != tt.limit || stats.Spent != tt.wantSpent || !stats.ResetsAt.Equal(today().AddDate(0, 0, 1)) {
+				t.Errorf("Stats() = %+v, want %d of %d spent", stats, tt.wantSpent, tt.limit)
+			}
+		})
+	}
+}
+
+func TestDailyBudgetRollsOver(t *testing.T) {
+	budget := NewDailyBudget(100)
+	budget.Spend(100)
+	if budget.Check() == nil {
+		t.Fatal("spent budget allowed a generation")
+	}
+
+	// The spend was recorded yesterday
+	budget.day = budget.day.AddDate(0, 0, -1)
+	if err := budget.Check(); err != nil {
+		t.Errorf("Check() on a new day = %v", err)
+	}
+	if spent := budget.Stats().Spent; spent != 0 {
+		t.Errorf("spent = %d on a new day, want 0", spent)
+	}
+}
+
+func TestNoDailyBudget(t *testing.T) {
+	budget := NewDailyBudget(0)
+	if budget != nil {
+		t.Fatal("NewDailyBudget(0) should mean no budget")
+	}
+	budget.Spend(1000)
+	if err := budget.Check(); err != nil {
+		t.Errorf("nil budget Check() = %v", err)
+	}
+	if stats := budget.Stats(); stats != nil {
+		t.Errorf("nil budget Stats() = %+v", stats)
+	}
+}
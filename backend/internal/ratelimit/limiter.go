@@ -0,0 +1,126 @@
+// Package ratelimit provides the per-client token buckets that throttle API
+// requests and spoiler generations, and the daily token budget that caps
+// Gemini spend across all clients.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"spoiler_api/internal/metrics"
+)
+
+// sweepInterval is how often idle buckets are dropped from a Limiter
+const sweepInterval = 10 * time.Minute
+
+// Error is returned when a client or the whole service is over a limit named
+// Limit. RetryAfter is when the request would next be allowed (maps to 429).
+type Error struct {
+	Limit      string
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s limit exceeded, retry in %s", strings.ReplaceAll(e.Limit, "_", " "), e.RetryAfter.Round(time.Second))
+}
+
+// Limiter keeps one token bucket per client key. Each bucket holds up to
+// burst tokens and refills at rate tokens per second; a request takes one.
+// A nil Limiter allows everything.
+type Limiter struct {
+	name  string
+	rate  float64
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// bucket is a single client's token balance as of updated
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// New creates a limiter allowing count requests per period with bursts of up
+// to burst. name identifies the limit in errors and metrics, e.g. "requests".
+// It returns nil (no limit) when count is not positive.
+func New(name string, count int, period time.Duration, burst int) *Limiter {
+	if count <= 0 || period <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		name:      name,
+		rate:      float64(count) / period.Seconds(),
+		burst:     float64(burst),
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow takes a token from key's bucket. When the bucket is empty it returns
+// an *Error carrying how long until a token is available.
+func (l *Limiter) Allow(key string) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &bucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+	} else {
+		b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+		b.updated = now
+	}
+
+	if b.tokens < 1 {
+		metrics.RateLimited.WithLabelValues(l.name).Inc()
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return &Error{Limit: l.name, RetryAfter: wait}
+	}
+	b.tokens--
+	return nil
+}
+
+// sweep drops buckets that have refilled completely, which are equivalent to
+// no bucket at all, so the map only holds recently active clients
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+type clientKey struct{}
+
+// WithClient returns a context carrying the key the caller is rate limited by
+func WithClient(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, clientKey{}, key)
+}
+
+// Client returns the rate limit key stored in ctx, or ""
+func Client(ctx context.Context) string {
+	key, _ := ctx.Value(clientKey{}).(string)
+	return key
+}
@@ -0,0 +1,123 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	tests := []struct {
+		name        string
+		count       int
+		period      time.Duration
+		burst       int
+		requests    int
+		wantAllowed int
+		wantRetry   time.Duration // approximate wait reported once denied
+	}{
+		{name: "burst then denied", count: 60, period: time.Minute, burst: 3, requests: 5, wantAllowed: 3, wantRetry: time.Second},
+		{name: "burst below one is one", count: 10, period: time.Hour, burst: 0, requests: 3, wantAllowed: 1, wantRetry: 6 * time.Minute},
+		{name: "disabled", count: 0, period: time.Minute, burst: 1, requests: 100, wantAllowed: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := New("requests", tt.count, tt.period, tt.burst)
+
+			allowed := 0
+			var limitErr *Error
+			for i := 0; i < tt.requests; i++ {
+				err := limiter.Allow("client")
+				if err == nil {
+					allowed++
+					continue
+				}
+				if !errors.As(err, &limitErr) || limitErr.Limit != "requests" {
+					t.Fatalf("Allow returned %v, want a requests *Error", err)
+				}
+			}
+
+			if allowed != tt.wantAllowed {
+				t.Errorf("allowed %d of %d requests, want %d", allowed, tt.requests, tt.wantAllowed)
+			}
+			if tt.wantRetry > 0 {
+				if limitErr == nil {
+					t.Fatal("no request was denied")
+				}
+				if limitErr.RetryAfter <= 0 || limitErr.RetryAfter > tt.wantRetry {
+					t.Errorf("RetryAfter = %v, want up to %v", limitErr.RetryAfter, tt.wantRetry)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterClientsAreIndependent(t *testing.T) {
+	limiter := New("generations", 1, time.Hour, 1)
+
+	if err := limiter.Allow("a"); err != nil {
+		t.Fatalf("first request from a: %v", err)
+	}
+	if err := limiter.Allow("a"); err == nil {
+		t.Error("second request from a was allowed")
+	}
+	if err := limiter.Allow("b"); err != nil {
+		t.Errorf("first request from b: %v", err)
+	}
+}
+
+func TestLimiterRefills(t *testing.T) {
+	limiter := New("requests", 100, time.Second, 1)
+
+	if err := limiter.Allow("client"); err != nil {
+		t.Fatal(err)
+	}
+	if err := limiter.Allow("client"); err == nil {
+		t.Fatal("request allowed with an empty bucket")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if err := limiter.Allow("client"); err != nil {
+		t.Errorf("request denied after the bucket refilled: %v", err)
+	}
+}
+
+func TestLimiterSweepsFullBuckets(t *testing.T) {
+	limiter := New("requests", 60, time.Minute, 5)
+	limiter.Allow("idle")
+	limiter.Allow("busy")
+	for i := 0; i < 4; i++ {
+		limiter.Allow("busy")
+	}
+
+	// "idle" has refilled by the next sweep, "busy" has not
+	past := time.Now().Add(-2 * time.Second)
+	limiter.buckets["idle"].updated = past
+	limiter.buckets["busy"].updated = past
+	limiter.lastSweep = time.Now().Add(-sweepInterval)
+	limiter.Allow("other")
+
+	if _, ok := limiter.buckets["idle"]; ok {
+		t.Error("full bucket was not swept")
+	}
+	if _, ok := limiter.buckets["busy"]; !ok {
+		t.Error("partly empty bucket was swept")
+	}
+}
+
+func TestNilLimiterAllowsEverything(t *testing.T) {
+	var limiter *Limiter
+	if err := limiter.Allow("client"); err != nil {
+		t.Errorf("nil limiter returned %v", err)
+	}
+}
+
+func TestClientContext(t *testing.T) {
+	if key := Client(context.Background()); key != "" {
+		t.Errorf("Client without a key = %q", key)
+	}
+	if key := Client(WithClient(context.Background(), "key:abc")); key != "key:abc" {
+		t.Errorf("Client = %q, want key:abc", key)
+	}
+}
@@ -2,21 +2,46 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"spoiler_api/internal/handlers"
+	"spoiler_api/internal/middleware"
+	"spoiler_api/internal/services"
 )
 
+// Guards are the middleware protecting the API, applied in this order
+type Guards struct {
+	// Auth identifies the caller by API key
+	Auth gin.HandlerFunc
+	// RateLimit throttles the public API per caller
+	RateLimit gin.HandlerFunc
+	// Admin protects the admin API
+	Admin gin.HandlerFunc
+}
+
 // SetupRoutes configures all API routes
-func SetupRoutes(router *gin.Engine, movieHandler *handlers.MovieHandler) {
+func SetupRoutes(router *gin.Engine, movieHandler *handlers.MovieHandler, apiKeyHandler *handlers.APIKeyHandler, guards Guards) {
 	// Health check endpoint
 	router.GET("/health", movieHandler.HealthCheck)
 
+	// Prometheus metrics
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
 	// API routes
-	api := router.Group("/api")
+	api := router.Group("/api", guards.Auth, guards.RateLimit, middleware.RequireScope(services.ScopeReadCached))
 	{
 		// Single movie with spoiler
 		api.GET("/movie", movieHandler.GetMovie)
 
+		// Single movie with spoiler, streamed as Server-Sent Events
+		api.GET("/movie/stream", movieHandler.StreamMovie)
+
+		// Plot up to a minute of the movie, from its stored scene outline
+		api.GET("/movie/progress", movieHandler.GetMovieProgress)
+
+		// Full movie metadata by TMDB ID (no spoiler generation)
+		api.GET("/movie/:id", movieHandler.GetMovieDetails)
+
 		// Discover movies by year
 		api.GET("/movies", movieHandler.DiscoverMovies)
 
@@ -25,5 +50,25 @@ func SetupRoutes(router *gin.Engine, movieHandler *handlers.MovieHandler) {
 
 		// Trending/cached movies endpoint
 		api.GET("/trending", movieHandler.GetTrendingMovies)
+
+		// Search TV shows (no spoiler generation)
+		api.GET("/tv/search", movieHandler.SearchTV)
+
+		// TV show, season and episode with spoilers
+		api.GET("/tv/:id", movieHandler.GetTVShow)
+		api.GET("/tv/:id/season/:n", movieHandler.GetTVSeason)
+		api.GET("/tv/:id/season/:n/episode/:e", movieHandler.GetTVEpisode)
+	}
+
+	// Admin routes
+	admin := router.Group("/api/admin", guards.Auth, guards.Admin)
+	{
+		// Purge a single movie's spoiler from the cache and database
+		admin.DELETE("/cache/:id", movieHandler.EvictMovie)
+
+		// Mint, list and revoke API keys
+		admin.POST("/keys", apiKeyHandler.CreateKey)
+		admin.GET("/keys", apiKeyHandler.ListKeys)
+		admin.DELETE("/keys/:id", apiKeyHandler.RevokeKey)
 	}
 }
@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"spoiler_api/internal/handlers"
+)
+
+func TestSetupRoutesGuards(t *testing.T) {
+	var ran []string
+	guard := func(name string, status int) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			ran = append(ran, name)
+			if status != 0 {
+				c.AbortWithStatus(status)
+			}
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	SetupRoutes(router, handlers.NewMovieHandler(nil, nil, nil, nil, nil, nil, nil, nil, handlers.GenerationPolicy{}), handlers.NewAPIKeyHandler(nil), Guards{
+		Auth:      guard("auth", 0),
+		RateLimit: guard("rate limit", 0),
+		Admin:     guard("admin", http.StatusForbidden),
+	})
+
+	tests := []struct {
+		method     string
+		target     string
+		wantGuards []string
+		wantStatus int
+	}{
+		// No caller was attached, so the read-cached scope check refuses the request
+		{http.MethodGet, "/api/movie?id=27205", []string{"auth", "rate limit"}, http.StatusUnauthorized},
+		{http.MethodGet, "/api/tv/1399/season/1/episode/1", []string{"auth", "rate limit"}, http.StatusUnauthorized},
+		{http.MethodDelete, "/api/admin/cache/27205", []string{"auth", "admin"}, http.StatusForbidden},
+		{http.MethodPost, "/api/admin/keys", []string{"auth", "admin"}, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			ran = nil
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if !reflect.DeepEqual(ran, tt.wantGuards) {
+				t.Errorf("guards = %q, want %q", ran, tt.wantGuards)
+			}
+		})
+	}
+}
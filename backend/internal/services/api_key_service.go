@@ -0,0 +1,353 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"spoiler_api/internal/models"
+)
+
+// Scope is a permission granted to an API key
+type Scope string
+
+const (
+	// ScopeReadCached allows the read endpoints, serving only spoilers that
+	// are already stored or cached
+	ScopeReadCached Scope = "read-cached"
+	// ScopeGenerate also allows cache misses that generate a new spoiler
+	ScopeGenerate Scope = "generate"
+	// ScopeAdmin allows the /api/admin endpoints
+	ScopeAdmin Scope = "admin"
+)
+
+const (
+	// apiKeyPrefix starts every key so leaked keys are easy to recognise
+	apiKeyPrefix = "sh_"
+	// apiKeyDisplayLength is how much of a key is kept to identify it
+	apiKeyDisplayLength = 11
+	// apiKeyCacheTTL bounds how long a revoked key keeps working on other instances
+	apiKeyCacheTTL = time.Minute
+	// maxCachedAPIKeys bounds the lookup cache, which also remembers unknown keys
+	maxCachedAPIKeys = 10000
+	// apiKeyUsageFlushInterval is how often usage counters are written to the store
+	apiKeyUsageFlushInterval = 30 * time.Second
+)
+
+var (
+	// ErrInvalidAPIKey means the key is unknown or revoked (maps to 401)
+	ErrInvalidAPIKey = errors.New("invalid or revoked API key")
+	// ErrGenerateScopeRequired means the caller may only read cached spoilers (maps to 403)
+	ErrGenerateScopeRequired = errors.New("this spoiler has not been generated yet, and generating it requires the generate scope")
+	// ErrAPIKeyNotFound means no key has the given ID
+	ErrAPIKeyNotFound = errors.New("API key not found")
+)
+
+// APIKeyStore persists API keys by the SHA-256 hash of the key
+type APIKeyStore interface {
+	// CreateAPIKey stores a new key
+	CreateAPIKey(ctx context.Context, key *models.APIKey, hash string) error
+	// FindAPIKey returns the key with hash, or nil (and no error) when there is none
+	FindAPIKey(ctx context.Context, hash string) (*models.APIKey, error)
+	// ListAPIKeys returns every key, including revoked ones, newest first
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	// RevokeAPIKey marks a key revoked, returning ErrAPIKeyNotFound for an unknown ID
+	RevokeAPIKey(ctx context.Context, id string) error
+	// AddAPIKeyUsage adds usage to a key's counters and sets its last use
+	AddAPIKeyUsage(ctx context.Context, id string, usage models.APIKeyUsage, lastUsed time.Time) error
+}
+
+// APIKeyService mints, authenticates and revokes API keys, and counts their
+// usage in memory, writing it to the store periodically and on Close
+type APIKeyService struct {
+	store APIKeyStore
+
+	mu      sync.Mutex
+	cache   map[string]cachedAPIKey // by hash
+	pending map[string]*pendingUsage
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// cachedAPIKey is a lookup result; key is nil for unknown or revoked keys
+type cachedAPIKey struct {
+	key       *models.APIKey
+	expiresAt time.Time
+}
+
+// pendingUsage is usage not yet written to the store
+type pendingUsage struct {
+	usage    models.APIKeyUsage
+	lastUsed time.Time
+}
+
+// NewAPIKeyService creates the service and starts its usage flusher
+func NewAPIKeyService(store APIKeyStore) *APIKeyService {
+	s := &APIKeyService{
+		store:   store,
+		cache:   make(map[string]cachedAPIKey),
+		pending: make(map[string]*pendingUsage),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go s.flushLoop()
+	return s
+}
+
+// ParseScopes validates scope names, dropping blanks and duplicates
+func ParseScopes(names []string) ([]Scope, error) {
+	var scopes []Scope
+	seen := make(map[Scope]bool)
+	for _, name := range names {
+		scope := Scope(strings.TrimSpace(name))
+		switch scope {
+		case "":
+			continue
+		case ScopeReadCached, ScopeGenerate, ScopeAdmin:
+		default:
+			return nil, fmt.Errorf("unknown scope %q (expected %s, %s or %s)", name, ScopeReadCached, ScopeGenerate, ScopeAdmin)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+// Create mints a key with the given name and scopes. The returned secret is
+// the only copy of the key.
+func (s *APIKeyService) Create(ctx context.Context, name string, scopes []Scope) (*models.APIKey, string, error) {
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate API key ID: %w", err)
+	}
+	secret = apiKeyPrefix + secret
+
+	key := &models.APIKey{
+		ID:        id,
+		Name:      name,
+		Prefix:    secret[:apiKeyDisplayLength],
+		CreatedAt: time.Now().UTC(),
+	}
+	for _, scope := range scopes {
+		key.Scopes = append(key.Scopes, string(scope))
+	}
+
+	if err := s.store.CreateAPIKey(ctx, key, hashAPIKey(secret)); err != nil {
+		return nil, "", fmt.Errorf("failed to store API key: %w", err)
+	}
+	return key, secret, nil
+}
+
+// Authenticate returns the active key for secret, or ErrInvalidAPIKey
+func (s *APIKeyService) Authenticate(ctx context.Context, secret string) (*models.APIKey, error) {
+	hash := hashAPIKey(secret)
+
+	s.mu.Lock()
+	cached, exists := s.cache[hash]
+	s.mu.Unlock()
+
+	if !exists || time.Now().After(cached.expiresAt) {
+		key, err := s.store.FindAPIKey(ctx, hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up API key: %w", err)
+		}
+		if key != nil && key.RevokedAt != nil {
+			key = nil
+		}
+		cached = cachedAPIKey{key: key, expiresAt: time.Now().Add(apiKeyCacheTTL)}
+
+		s.mu.Lock()
+		if len(s.cache) >= maxCachedAPIKeys {
+			s.cache = make(map[string]cachedAPIKey)
+		}
+		s.cache[hash] = cached
+		s.mu.Unlock()
+	}
+
+	if cached.key == nil {
+		return nil, ErrInvalidAPIKey
+	}
+	return cached.key, nil
+}
+
+// List returns every key with its stored usage
+func (s *APIKeyService) List(ctx context.Context) ([]models.APIKey, error) {
+	keys, err := s.store.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// Revoke disables a key. Other instances stop accepting it within apiKeyCacheTTL.
+func (s *APIKeyService) Revoke(ctx context.Context, id string) error {
+	if err := s.store.RevokeAPIKey(ctx, id); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for hash, cached := range s.cache {
+		if cached.key != nil && cached.key.ID == id {
+			delete(s.cache, hash)
+		}
+	}
+	return nil
+}
+
+// record adds usage for a key, to be written at the next flush
+func (s *APIKeyService) record(id string, usage models.APIKeyUsage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, exists := s.pending[id]
+	if !exists {
+		p = &pendingUsage{}
+		s.pending[id] = p
+	}
+	p.usage.Requests += usage.Requests
+	p.usage.Generations += usage.Generations
+	p.usage.Tokens += usage.Tokens
+	p.lastUsed = time.Now().UTC()
+}
+
+// flushLoop writes usage periodically until Close
+func (s *APIKeyService) flushLoop() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(apiKeyUsageFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.flush(context.Background())
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// flush writes pending usage to the store. Usage that fails to write is kept
+// for the next flush.
+func (s *APIKeyService) flush(ctx context.Context) {
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = make(map[string]*pendingUsage)
+	s.mu.Unlock()
+
+	for id, p := range pending {
+		if err := s.store.AddAPIKeyUsage(ctx, id, p.usage, p.lastUsed); err != nil {
+			slog.WarnContext(ctx, "Failed to write API key usage", "key_id", id, "error", err)
+			s.mu.Lock()
+			if current, exists := s.pending[id]; exists {
+				p.usage.Requests += current.usage.Requests
+				p.usage.Generations += current.usage.Generations
+				p.usage.Tokens += current.usage.Tokens
+				p.lastUsed = current.lastUsed
+			}
+			s.pending[id] = p
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Close stops the flusher and writes the remaining usage
+func (s *APIKeyService) Close(ctx context.Context) {
+	close(s.stop)
+	<-s.done
+	s.flush(ctx)
+}
+
+// hashAPIKey returns the hex SHA-256 of a key. Keys are random, so a fast
+// unsalted hash is enough to make a leaked table useless.
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex returns n random bytes, hex encoded
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Caller is who a request is made by: an API key, or an anonymous client
+// with the scopes configured for anonymous access
+type Caller struct {
+	Key    *models.APIKey
+	scopes []Scope
+	keys   *APIKeyService
+}
+
+type callerKey struct{}
+
+// NewCaller returns a caller with the given scopes. key is nil for
+// anonymous callers; keys counts the usage of key callers.
+func NewCaller(key *models.APIKey, scopes []Scope, keys *APIKeyService) *Caller {
+	return &Caller{Key: key, scopes: scopes, keys: keys}
+}
+
+// Has reports whether the caller was granted scope. Generating implies
+// reading cached spoilers.
+func (c *Caller) Has(scope Scope) bool {
+	if c == nil {
+		return false
+	}
+	for _, granted := range c.scopes {
+		if granted == scope || (granted == ScopeGenerate && scope == ScopeReadCached) {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordRequest counts a request against the caller's key
+func (c *Caller) RecordRequest() {
+	c.recordUsage(models.APIKeyUsage{Requests: 1})
+}
+
+// RecordGeneration counts a spoiler generation against the caller's key
+func (c *Caller) RecordGeneration() {
+	c.recordUsage(models.APIKeyUsage{Generations: 1})
+}
+
+// recordUsage adds usage to the caller's key; anonymous callers are not counted
+func (c *Caller) recordUsage(usage models.APIKeyUsage) {
+	if c == nil || c.Key == nil || c.keys == nil {
+		return
+	}
+	c.keys.record(c.Key.ID, usage)
+}
+
+// WithCaller returns a context carrying the caller
+func WithCaller(ctx context.Context, caller *Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// CallerFromContext returns the caller stored in ctx, or nil
+func CallerFromContext(ctx context.Context) *Caller {
+	caller, _ := ctx.Value(callerKey{}).(*Caller)
+	return caller
+}
+
+// recordTokens counts LLM tokens against the key of the caller in ctx
+func recordTokens(ctx context.Context, tokens int) {
+	CallerFromContext(ctx).recordUsage(models.APIKeyUsage{Tokens: int64(tokens)})
+}
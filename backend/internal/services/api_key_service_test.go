@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestAPIKeyService returns a key service backed by a fresh SQLite store
+func newTestAPIKeyService(t *testing.T) (*APIKeyService, *SQLiteStore) {
+	t.Helper()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "spoilers.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	keys := NewAPIKeyService(store)
+	t.Cleanup(func() { keys.Close(context.Background()) })
+	return keys, store
+}
+
+func TestHashAPIKey(t *testing.T) {
+	// SHA-256 test vector from FIPS 180-2
+	if got := hashAPIKey("abc"); got != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad" {
+		t.Errorf("hashAPIKey(abc) = %s", got)
+	}
+	if hashAPIKey("sh_a") == hashAPIKey("sh_b") {
+		t.Error("different keys hash the same")
+	}
+}
+
+func TestAPIKeyCreateStoresOnlyTheHash(t *testing.T) {
+	keys, store := newTestAPIKeyService(t)
+	ctx := context.Background()
+
+	key, secret, err := keys.Create(ctx, "partner", []Scope{ScopeReadCached, ScopeGenerate})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !strings.HasPrefix(secret, apiKeyPrefix) || len(secret) != len(apiKeyPrefix)+64 {
+		t.Errorf("secret %q is not %s followed by 64 hex digits", secret, apiKeyPrefix)
+	}
+	if key.Prefix != secret[:apiKeyDisplayLength] {
+		t.Errorf("prefix = %q, want %q", key.Prefix, secret[:apiKeyDisplayLength])
+	}
+	if !reflect.DeepEqual(key.Scopes, []string{"read-cached", "generate"}) {
+		t.Errorf("scopes = %v", key.Scopes)
+	}
+
+	var hash string
+	var leaked int
+	if err := store.db.QueryRow(`SELECT key_hash FROM api_keys WHERE id = ?`, key.ID).Scan(&hash); err != nil {
+		t.Fatal(err)
+	}
+	if hash != hashAPIKey(secret) {
+		t.Errorf("stored hash %s, want the SHA-256 of the secret", hash)
+	}
+	err = store.db.QueryRow(`SELECT COUNT(*) FROM api_keys WHERE id = ? OR name = ? OR prefix = ? OR key_hash = ? OR scopes = ?`,
+		secret, secret, secret, secret, secret).Scan(&leaked)
+	if err != nil || leaked != 0 {
+		t.Errorf("secret stored in plain text (%d rows, %v)", leaked, err)
+	}
+
+	// A second key never collides with the first
+	other, otherSecret, err := keys.Create(ctx, "other", []Scope{ScopeReadCached})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if other.ID == key.ID || otherSecret == secret {
+		t.Error("two keys share an ID or secret")
+	}
+}
+
+func TestAPIKeyAuthenticateAndRevoke(t *testing.T) {
+	keys, store := newTestAPIKeyService(t)
+	ctx := context.Background()
+
+	key, secret, err := keys.Create(ctx, "partner", []Scope{ScopeGenerate})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr error
+	}{
+		{"valid key", secret, nil},
+		{"unknown key", apiKeyPrefix + strings.Repeat("0", 64), ErrInvalidAPIKey},
+		{"key with a changed character", secret[:len(secret)-1] + "x", ErrInvalidAPIKey},
+		{"empty key", "", ErrInvalidAPIKey},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := keys.Authenticate(ctx, tt.secret)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got.ID != key.ID {
+				t.Errorf("authenticated as %s, want %s", got.ID, key.ID)
+			}
+		})
+	}
+
+	// Another instance sharing the store has the key cached
+	other := NewAPIKeyService(store)
+	defer other.Close(ctx)
+	if _, err := other.Authenticate(ctx, secret); err != nil {
+		t.Fatalf("other instance: %v", err)
+	}
+
+	if err := keys.Revoke(ctx, key.ID); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if err := keys.Revoke(ctx, "missing"); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("Revoke(missing) = %v, want ErrAPIKeyNotFound", err)
+	}
+
+	// The revoking instance rejects the key at once
+	if _, err := keys.Authenticate(ctx, secret); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Authenticate after Revoke = %v, want ErrInvalidAPIKey", err)
+	}
+
+	// Other instances reject it once their cached lookup expires
+	other.mu.Lock()
+	for hash, cached := range other.cache {
+		cached.expiresAt = time.Now().Add(-time.Second)
+		other.cache[hash] = cached
+	}
+	other.mu.Unlock()
+	if _, err := other.Authenticate(ctx, secret); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("other instance after its cache expired = %v, want ErrInvalidAPIKey", err)
+	}
+
+	listed, err := keys.List(ctx)
+	if err != nil || len(listed) != 1 || listed[0].RevokedAt == nil {
+		t.Errorf("List after Revoke = %+v, %v", listed, err)
+	}
+}
+
+func TestAPIKeyUsageIsFlushed(t *testing.T) {
+	keys, _ := newTestAPIKeyService(t)
+	ctx := context.Background()
+
+	key, _, err := keys.Create(ctx, "partner", []Scope{ScopeGenerate})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	caller := NewCaller(key, []Scope{ScopeGenerate}, keys)
+	caller.RecordRequest()
+	caller.RecordRequest()
+	caller.RecordGeneration()
+	recordTokens(WithCaller(ctx, caller), 1200)
+	NewCaller(nil, []Scope{ScopeGenerate}, keys).RecordRequest() // anonymous callers are not counted
+
+	keys.flush(ctx)
+	listed, err := keys.List(ctx)
+	if err != nil || len(listed) != 1 {
+		t.Fatalf("List = %+v, %v", listed, err)
+	}
+	usage := listed[0].Usage
+	if usage.Requests != 2 || usage.Generations != 1 || usage.Tokens != 1200 || listed[0].LastUsedAt == nil {
+		t.Errorf("usage = %+v, last used %v", usage, listed[0].LastUsedAt)
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	tests := []struct {
+		names   []string
+		want    []Scope
+		wantErr bool
+	}{
+		{names: []string{"read-cached", "generate"}, want: []Scope{ScopeReadCached, ScopeGenerate}},
+		{names: []string{" admin ", "", "admin"}, want: []Scope{ScopeAdmin}},
+		{names: nil, want: nil},
+		{names: []string{"generate", "superuser"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseScopes(tt.names)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseScopes(%q) = %v, %v; want %v, error %v", tt.names, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestCallerHas(t *testing.T) {
+	tests := []struct {
+		name    string
+		granted []Scope
+		scope   Scope
+		want    bool
+	}{
+		{"granted", []Scope{ScopeReadCached}, ScopeReadCached, true},
+		{"generate implies read-cached", []Scope{ScopeGenerate}, ScopeReadCached, true},
+		{"read-cached does not imply generate", []Scope{ScopeReadCached}, ScopeGenerate, false},
+		{"admin is separate", []Scope{ScopeGenerate}, ScopeAdmin, false},
+		{"no scopes", nil, ScopeReadCached, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewCaller(nil, tt.granted, nil).Has(tt.scope); got != tt.want {
+				t.Errorf("Has(%s) = %v, want %v", tt.scope, got, tt.want)
+			}
+		})
+	}
+
+	var nobody *Caller
+	if nobody.Has(ScopeReadCached) {
+		t.Error("nil caller has a scope")
+	}
+}
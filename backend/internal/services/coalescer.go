@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Coalescer deduplicates concurrent work for the same key: while a call is in
+// flight, later callers with the same key wait for it and share its result or
+// error instead of starting their own.
+//
+// The work runs in its own goroutine with a context that keeps the first
+// caller's values (trace, request ID) but not its cancellation. A caller whose
+// context ends stops waiting. Once every caller has gone the work is either
+// cancelled or, when the coalescer detaches, left to finish in the background.
+// Either way it stays in flight until fn returns; callers arriving while it
+// is being cancelled wait for it to stop and then start afresh.
+type Coalescer[T any] struct {
+	mu        sync.Mutex
+	calls     map[string]*coalescedCall[T]
+	coalesced atomic.Int64
+	detach    bool
+}
+
+// coalescedCall is a single in-flight call and the callers waiting on it
+type coalescedCall[T any] struct {
+	done      chan struct{}
+	result    T
+	err       error
+	waiters   int
+	refs      int  // callers still waiting
+	abandoned bool // every caller left and the work was cancelled
+	cancel    context.CancelFunc
+}
+
+// NewCoalescer creates an empty coalescer. With detach, work abandoned by all
+// of its callers keeps running; otherwise it is cancelled.
+func NewCoalescer[T any](detach bool) *Coalescer[T] {
+	return &Coalescer[T]{calls: make(map[string]*coalescedCall[T]), detach: detach}
+}
+
+// Do runs fn for key unless a call for key is already in flight, in which case
+// it waits for that call. shared reports whether the result came from another
+// caller. The leader receives the number of callers that waited on it. If ctx
+// ends first, Do returns ctx.Err() without waiting for fn.
+func (c *Coalescer[T]) Do(ctx context.Context, key string, fn func(ctx context.Context) (T, error)) (result T, waiters int, shared bool, err error) {
+	c.mu.Lock()
+	call, shared := c.calls[key]
+	for shared && call.abandoned {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			var zero T
+			return zero, 0, false, ctx.Err()
+		}
+		c.mu.Lock()
+		call, shared = c.calls[key]
+	}
+	if shared {
+		call.waiters++
+		call.refs++
+		c.mu.Unlock()
+		c.coalesced.Add(1)
+	} else {
+		workCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &coalescedCall[T]{done: make(chan struct{}), refs: 1, cancel: cancel}
+		c.calls[key] = call
+		c.mu.Unlock()
+		go c.run(key, call, workCtx, fn)
+	}
+
+	select {
+	case <-call.done:
+		if !shared {
+			c.mu.Lock()
+			waiters = call.waiters
+			c.mu.Unlock()
+		}
+		return call.result, waiters, shared, call.err
+	case <-ctx.Done():
+		c.release(call)
+		var zero T
+		return zero, 0, shared, ctx.Err()
+	}
+}
+
+// run executes the work for a call and wakes its callers. A panic in fn
+// would take the whole server down, since no handler's recovery covers this
+// goroutine, so it becomes the call's error instead.
+func (c *Coalescer[T]) run(key string, call *coalescedCall[T], ctx context.Context, fn func(ctx context.Context) (T, error)) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			slog.ErrorContext(ctx, "Coalesced call panicked", "key", key, "panic", recovered, "stack", string(debug.Stack()))
+			var zero T
+			call.result, call.err = zero, fmt.Errorf("coalesced call for %q panicked: %v", key, recovered)
+		}
+
+		c.mu.Lock()
+		if c.calls[key] == call {
+			delete(c.calls, key)
+		}
+		c.mu.Unlock()
+		call.cancel()
+		close(call.done)
+	}()
+
+	call.result, call.err = fn(ctx)
+}
+
+// release drops a caller that stopped waiting, cancelling the work when it
+// was the last one and the coalescer does not detach
+func (c *Coalescer[T]) release(call *coalescedCall[T]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	call.refs--
+	if call.refs > 0 || c.detach {
+		return
+	}
+
+	// The call stays in flight until run sees fn return, so it is still
+	// counted and a later caller cannot start a duplicate alongside it
+	call.abandoned = true
+	call.cancel()
+}
+
+// Wait blocks until no calls are in flight or ctx ends, and reports whether
+// everything finished
+func (c *Coalescer[T]) Wait(ctx context.Context) bool {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for c.InFlight() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// InFlight returns the number of calls currently running
+func (c *Coalescer[T]) InFlight() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.calls)
+}
+
+// CoalescedCount returns the total number of callers that waited on another call
+func (c *Coalescer[T]) CoalescedCount() int64 {
+	return c.coalesced.Load()
+}
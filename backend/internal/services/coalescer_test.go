@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor polls cond until it holds, failing the test after a second
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCoalescerSharesResult(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  string
+		err     error
+		callers int
+	}{
+		{name: "result", result: "spoiler", callers: 5},
+		{name: "error", err: errors.New("generation failed"), callers: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCoalescer[string](false)
+			release := make(chan struct{})
+			var runs atomic.Int32
+			fn := func(ctx context.Context) (string, error) {
+				runs.Add(1)
+				<-release
+				return tt.result, tt.err
+			}
+
+			type outcome struct {
+				result  string
+				waiters int
+				shared  bool
+				err     error
+			}
+			outcomes := make(chan outcome, tt.callers)
+			var wg sync.WaitGroup
+			for i := 0; i < tt.callers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					result, waiters, shared, err := c.Do(context.Background(), "key", fn)
+					outcomes <- outcome{result, waiters, shared, err}
+				}()
+			}
+			waitFor(t, "callers to join", func() bool { return c.CoalescedCount() == int64(tt.callers-1) })
+			close(release)
+			wg.Wait()
+			close(outcomes)
+
+			leaders := 0
+			for o := range outcomes {
+				if o.result != tt.result || !errors.Is(o.err, tt.err) {
+					t.Errorf("got %q, %v; want %q, %v", o.result, o.err, tt.result, tt.err)
+				}
+				if !o.shared {
+					leaders++
+					if o.waiters != tt.callers-1 {
+						t.Errorf("leader saw %d waiters, want %d", o.waiters, tt.callers-1)
+					}
+				}
+			}
+			if leaders != 1 || runs.Load() != 1 {
+				t.Errorf("%d leaders and %d runs, want 1 of each", leaders, runs.Load())
+			}
+			if n := c.InFlight(); n != 0 {
+				t.Errorf("InFlight() = %d after completion, want 0", n)
+			}
+		})
+	}
+}
+
+func TestCoalescerAbandonedWork(t *testing.T) {
+	tests := []struct {
+		name          string
+		detach        bool
+		wantCancelled bool
+	}{
+		{name: "detach finishes the work", detach: true, wantCancelled: false},
+		{name: "cancel stops the work", detach: false, wantCancelled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCoalescer[string](tt.detach)
+
+			finish := make(chan struct{})
+			var running, maxRunning, runs atomic.Int32
+			var cancelled atomic.Bool
+			fn := func(ctx context.Context) (string, error) {
+				runs.Add(1)
+				if n := running.Add(1); n > maxRunning.Load() {
+					maxRunning.Store(n)
+				}
+				defer running.Add(-1)
+
+				select {
+				case <-ctx.Done():
+					cancelled.Store(true)
+				case <-time.After(20 * time.Millisecond):
+				}
+				// Like a provider finishing its HTTP call, the work returns a little after it is cancelled
+				<-finish
+				return "spoiler", ctx.Err()
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() {
+				_, _, _, err := c.Do(ctx, "key", fn)
+				done <- err
+			}()
+			waitFor(t, "the work to start", func() bool { return runs.Load() == 1 })
+
+			cancel()
+			if err := <-done; !errors.Is(err, context.Canceled) {
+				t.Fatalf("abandoned caller got %v, want context.Canceled", err)
+			}
+			if tt.wantCancelled {
+				waitFor(t, "the work to be cancelled", cancelled.Load)
+			}
+			if n := c.InFlight(); n != 1 {
+				t.Errorf("InFlight() = %d while the abandoned work is still running, want 1", n)
+			}
+			waitCtx, waitCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			if c.Wait(waitCtx) {
+				t.Error("Wait() reported everything finished while the abandoned work is still running")
+			}
+			waitCancel()
+
+			// A new caller must not run the work alongside the abandoned call
+			next := make(chan string, 1)
+			go func() {
+				result, _, _, _ := c.Do(context.Background(), "key", fn)
+				next <- result
+			}()
+			time.Sleep(10 * time.Millisecond)
+			close(finish)
+
+			if result := <-next; result != "spoiler" {
+				t.Errorf("new caller got %q, want the spoiler", result)
+			}
+			if maxRunning.Load() != 1 {
+				t.Errorf("%d calls ran at once, want 1", maxRunning.Load())
+			}
+			if cancelled.Load() != tt.wantCancelled {
+				t.Errorf("work cancelled = %v, want %v", cancelled.Load(), tt.wantCancelled)
+			}
+
+			wantRuns := int32(1)
+			if tt.wantCancelled {
+				wantRuns = 2 // the new caller starts afresh once the cancelled work stops
+			}
+			if runs.Load() != wantRuns {
+				t.Errorf("work ran %d times, want %d", runs.Load(), wantRuns)
+			}
+			if !c.Wait(context.Background()) {
+				t.Error("Wait() = false after all work finished")
+			}
+		})
+	}
+}
+
+func TestCoalescerRecoversPanic(t *testing.T) {
+	c := NewCoalescer[string](false)
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (string, error) {
+		<-release
+		panic("provider bug")
+	}
+
+	const callers = 3
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			_, _, _, err := c.Do(context.Background(), "key", fn)
+			errs <- err
+		}()
+	}
+	waitFor(t, "callers to join", func() bool { return c.CoalescedCount() == callers-1 })
+	close(release)
+
+	for i := 0; i < callers; i++ {
+		if err := <-errs; err == nil || !strings.Contains(err.Error(), "provider bug") {
+			t.Errorf("caller %d got %v, want the panic as an error", i+1, err)
+		}
+	}
+	if n := c.InFlight(); n != 0 {
+		t.Errorf("InFlight() = %d after the panic, want 0", n)
+	}
+
+	// The key is usable again
+	result, _, _, err := c.Do(context.Background(), "key", func(ctx context.Context) (string, error) { return "spoiler", nil })
+	if result != "spoiler" || err != nil {
+		t.Errorf("after the panic got %q, %v; want the spoiler", result, err)
+	}
+}
@@ -1,47 +1,61 @@
 package services
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"sync"
+	"net/url"
+	"strings"
 
+	"go.opentelemetry.io/otel/trace"
+
+	"spoiler_api/internal/metrics"
 	"spoiler_api/internal/models"
+	"spoiler_api/internal/ratelimit"
+	"spoiler_api/internal/tracing"
+	"spoiler_api/internal/upstream"
 )
 
 // GeminiService handles Gemini API interactions with caching
 type GeminiService struct {
 	apiKey string
-	client *http.Client
-	cache  map[string]string
-	mu     sync.RWMutex
+	model  string
+	client *upstream.Client
+	cache  *SpoilerCache
+	budget *ratelimit.DailyBudget
 }
 
-// NewGeminiService creates a new Gemini service instance
-func NewGeminiService(apiKey string) *GeminiService {
+// NewGeminiService creates a new Gemini service instance. Tokens used are
+// charged to budget, which may be nil.
+func NewGeminiService(apiKey, model string, client *upstream.Client, cache *SpoilerCache, budget *ratelimit.DailyBudget) *GeminiService {
 	return &GeminiService{
 		apiKey: apiKey,
-		client: &http.Client{},
-		cache:  make(map[string]string),
+		model:  model,
+		client: client,
+		cache:  cache,
+		budget: budget,
 	}
 }
 
 // GenerateSpoiler generates a detailed spoiler explanation for a movie
-func (s *GeminiService) GenerateSpoiler(title, year, overview string) (string, error) {
+func (s *GeminiService) GenerateSpoiler(ctx context.Context, movie SpoilerRequest) (_ string, err error) {
+	ctx, span := s.startSpan(ctx, "llm.generate_spoiler", movie)
+	defer func() { tracing.End(span, err) }()
+
 	// Check cache first
-	cacheKey := fmt.Sprintf("%s_%s", title, year)
-	
-	s.mu.RLock()
-	if cachedSpoiler, exists := s.cache[cacheKey]; exists {
-		s.mu.RUnlock()
+	cacheKey := movie.CacheKey()
+	if cachedSpoiler, exists := s.cache.Get(cacheKey); exists {
+		span.SetAttributes(tracing.AttrCacheTier.String("memory"))
 		return cachedSpoiler, nil
 	}
-	s.mu.RUnlock()
+	span.SetAttributes(tracing.AttrCacheTier.String("miss"))
 
 	// Construct the prompt
-	prompt := s.constructPrompt(title, year, overview)
+	prompt := buildSpoilerPrompt(movie)
 
 	// Create Gemini API request
 	request := models.GeminiRequest{
@@ -63,12 +77,12 @@ func (s *GeminiService) GenerateSpoiler(title, year, overview string) (string, e
 	}
 
 	// Make request to Gemini API
-	url := fmt.Sprintf(
-		"https://generativelanguage.googleapis.com/v1/models/gemini-2.5-flash:generateContent?key=%s",
-		s.apiKey,
+	endpoint := fmt.Sprintf(
+		"https://generativelanguage.googleapis.com/v1/models/%s:generateContent",
+		url.PathEscape(s.model),
 	)
 
-	resp, err := s.client.Post(url, "application/json", bytes.NewBuffer(requestBody))
+	resp, err := s.post(ctx, endpoint, requestBody)
 	if err != nil {
 		return "", fmt.Errorf("failed to call Gemini API: %w", err)
 	}
@@ -76,8 +90,7 @@ func (s *GeminiService) GenerateSpoiler(title, year, overview string) (string, e
 
 	// Check for HTTP errors
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("Gemini API error: status code %d, response: %s", resp.StatusCode, string(body))
+		return "", fmt.Errorf("Gemini API error: %w", s.client.ResponseError(resp))
 	}
 
 	// Read response body
@@ -92,94 +105,158 @@ func (s *GeminiService) GenerateSpoiler(title, year, overview string) (string, e
 		return "", fmt.Errorf("failed to parse Gemini response: %w", err)
 	}
 
+	s.recordUsage(ctx, span, geminiResp.UsageMetadata)
+
 	// Extract text from response
 	if len(geminiResp.Candidates) == 0 {
-		return "", fmt.Errorf("no candidates in Gemini response")
+		return "", fmt.Errorf("%w: no candidates in Gemini response", ErrGenerationRefused)
 	}
 
 	spoilerText := ""
 	if len(geminiResp.Candidates[0].Content.Parts) > 0 {
 		spoilerText = geminiResp.Candidates[0].Content.Parts[0].Text
 	}
+	// A candidate without text, e.g. one stopped by a safety filter, must
+	// not be cached as the spoiler
+	if spoilerText == "" {
+		return "", fmt.Errorf("%w: empty candidate in Gemini response", ErrGenerationRefused)
+	}
 
 	// Cache the result
-	s.mu.Lock()
-	s.cache[cacheKey] = spoilerText
-	s.mu.Unlock()
+	s.cache.Set(cacheKey, spoilerText)
 
 	return spoilerText, nil
 }
 
-// constructPrompt creates a detailed prompt for Gemini
-func (s *GeminiService) constructPrompt(title, year, overview string) string {
-	prompt := fmt.Sprintf(`You are an elite film analyst writing for a premium movie spoiler platform.
-
-Movie Title: %s
-Release Year: %s
-Movie Overview: %s
-
-You MUST structure your response using EXACTLY these markdown headings. Do NOT skip any section.
-
-## Movie Overview
-Write a compelling 2-3 sentence non-spoiler summary that hooks the reader.
-
-## ⚠️ SPOILER WARNING
-Write exactly: "Everything below contains major plot spoilers, twists, and ending details."
+// StreamSpoiler generates a spoiler via Gemini's streamGenerateContent API,
+// calling onChunk with each piece of text as it arrives. The complete text is
+// cached and returned once the stream ends.
+func (s *GeminiService) StreamSpoiler(ctx context.Context, movie SpoilerRequest, onChunk func(chunk string) error) (_ string, err error) {
+	ctx, span := s.startSpan(ctx, "llm.stream_spoiler", movie)
+	defer func() { tracing.End(span, err) }()
+
+	// Serve cached spoilers as a single chunk
+	cacheKey := movie.CacheKey()
+	if cachedSpoiler, exists := s.cache.Get(cacheKey); exists {
+		span.SetAttributes(tracing.AttrCacheTier.String("memory"))
+		return cachedSpoiler, onChunk(cachedSpoiler)
+	}
+	span.SetAttributes(tracing.AttrCacheTier.String("miss"))
 
-## The Beginning
-Describe the setup, world-building, and introduction of main characters. 2-3 paragraphs.
+	request := models.GeminiRequest{
+		Contents: []models.GeminiContent{
+			{
+				Parts: []models.GeminiPart{
+					{
+						Text: buildSpoilerPrompt(movie),
+					},
+				},
+			},
+		},
+	}
 
-## Major Turning Point
-Describe the key event that changes everything. What shifts? What revelation occurs? 2-3 paragraphs.
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal Gemini request: %w", err)
+	}
 
-## The Climax
-Describe the peak conflict, major confrontations, and pivotal decisions. 2-3 paragraphs.
+	// alt=sse makes Gemini send one "data: {...}" line per partial response
+	endpoint := fmt.Sprintf(
+		"https://generativelanguage.googleapis.com/v1/models/%s:streamGenerateContent?alt=sse",
+		url.PathEscape(s.model),
+	)
 
-## Ending Explained
-Explain the ending in detail. If ambiguous, provide multiple interpretations. 2-3 paragraphs.
+	resp, err := s.post(ctx, endpoint, requestBody)
+	if err != nil {
+		return "", fmt.Errorf("failed to call Gemini API: %w", err)
+	}
+	defer resp.Body.Close()
 
-## Post-Credit Scene
-If there is a post-credit scene, describe it. If not, write "This film does not have a post-credit scene."
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Gemini API error: %w", s.client.ResponseError(resp))
+	}
 
-## Key Moments
-List exactly 5 pivotal scenes. Format each as:
-- **[Scene Title]** — One sentence description of what happens and why it matters.
+	var spoilerText strings.Builder
+	var usage *models.GeminiUsageMetadata
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		var geminiResp models.GeminiResponse
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &geminiResp); err != nil {
+			return "", fmt.Errorf("failed to parse Gemini stream event: %w", err)
+		}
+
+		// Usage is cumulative; the last event carries the totals
+		if geminiResp.UsageMetadata != nil {
+			usage = geminiResp.UsageMetadata
+		}
+
+		for _, candidate := range geminiResp.Candidates {
+			for _, part := range candidate.Content.Parts {
+				if part.Text == "" {
+					continue
+				}
+				spoilerText.WriteString(part.Text)
+				if err := onChunk(part.Text); err != nil {
+					return "", err
+				}
+			}
+		}
+	}
+	s.recordUsage(ctx, span, usage)
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read Gemini stream: %w", err)
+	}
 
-## Character Fates
-List the main characters (up to 6). Format each as:
-- **[Character Name]** | [Actor Name] | [ALIVE/DEAD/UNKNOWN] | One sentence about their arc and final fate.
+	if spoilerText.Len() == 0 {
+		return "", fmt.Errorf("%w: no candidates in Gemini response", ErrGenerationRefused)
+	}
 
-## What It Really Means
-### Symbolism
-Explain 2-3 key symbols or motifs in the film.
-### Hidden Clues
-Describe 2-3 subtle details viewers might have missed.
-### Fan Theories
-Present 2-3 popular or plausible fan theories.
-### Unanswered Questions
-List 2-3 questions the film leaves unanswered.
+	// Cache the complete result
+	s.cache.Set(cacheKey, spoilerText.String())
 
-RULES:
-- Total length: 800-1200 words.
-- Use bold (**text**) for character names and important terms.
-- Do NOT fabricate facts — if you're unsure, say so.
-- If you do not have spoiler information for this specific movie, respond with ONLY: "## Movie Not Found\nWe don't have spoiler information for this movie yet." Do NOT substitute another film's spoiler.
-- Write in an engaging, editorial tone — like a premium film magazine.
-- Every section heading must start with ## exactly as shown above.`, title, year, overview)
+	return spoilerText.String(), nil
+}
 
-	return prompt
+// post sends a JSON request to Gemini with the API key in the
+// x-goog-api-key header, keeping it out of URLs and error messages
+func (s *GeminiService) post(ctx context.Context, endpoint string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", s.apiKey)
+	return s.client.Do(req)
 }
 
-// ClearCache clears the spoiler cache (useful for testing or admin operations)
-func (s *GeminiService) ClearCache() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.cache = make(map[string]string)
+// startSpan starts a span for a generation with the movie and model attributes
+func (s *GeminiService) startSpan(ctx context.Context, name string, movie SpoilerRequest) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name,
+		tracing.AttrMovieID.Int(movie.TMDBID),
+		tracing.AttrLLMProvider.String("gemini"),
+		tracing.AttrLLMModel.String(s.model),
+	)
 }
 
-// GetCacheSize returns the number of cached items
-func (s *GeminiService) GetCacheSize() int {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return len(s.cache)
+// recordUsage adds the tokens Gemini reported for a request to the metrics,
+// the span, the daily budget and the caller's API key
+func (s *GeminiService) recordUsage(ctx context.Context, span trace.Span, usage *models.GeminiUsageMetadata) {
+	if usage == nil {
+		return
+	}
+	span.SetAttributes(
+		tracing.AttrPromptTokens.Int(usage.PromptTokenCount),
+		tracing.AttrCompletionTokens.Int(usage.CandidatesTokenCount),
+	)
+	metrics.LLMTokens.WithLabelValues("gemini", s.model, "prompt").Add(float64(usage.PromptTokenCount))
+	metrics.LLMTokens.WithLabelValues("gemini", s.model, "completion").Add(float64(usage.CandidatesTokenCount))
+	s.budget.Spend(usage.TotalTokenCount)
+	recordTokens(ctx, usage.TotalTokenCount)
 }
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"spoiler_api/internal/upstream"
+)
+
+// geminiReply answers every Gemini request with a fixed status and body
+type geminiReply struct {
+	status int
+	body   string
+}
+
+func (r geminiReply) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: r.status,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(r.body)),
+		Request:    req,
+	}, nil
+}
+
+func TestGeminiGenerateSpoilerErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   geminiReply
+		wantErr error
+	}{
+		{
+			name:    "no candidates",
+			reply:   geminiReply{http.StatusOK, `{"candidates":[]}`},
+			wantErr: ErrGenerationRefused,
+		},
+		{
+			name:    "candidate without text",
+			reply:   geminiReply{http.StatusOK, `{"candidates":[{"content":{"parts":[]}}]}`},
+			wantErr: ErrGenerationRefused,
+		},
+		{
+			name:    "empty text",
+			reply:   geminiReply{http.StatusOK, `{"candidates":[{"content":{"parts":[{"text":""}]}}]}`},
+			wantErr: ErrGenerationRefused,
+		},
+		{
+			name:    "bad request",
+			reply:   geminiReply{http.StatusBadRequest, `{"error":{"message":"` + strings.Repeat("Invalid argument. ", 500) + `"}}`},
+			wantErr: upstream.ErrRejected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewSpoilerCache(10, 1<<20, time.Hour)
+			client := upstream.New("gemini", upstream.Options{MaxRetries: -1, Transport: tt.reply})
+			service := NewGeminiService("gemini-key", "gemini-test", client, cache, nil)
+
+			request := SpoilerRequest{TMDBID: 27205, Title: "Inception", Year: "2010"}
+			_, err := service.GenerateSpoiler(context.Background(), request)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if len(err.Error()) > 512 {
+				t.Errorf("error quotes %d bytes of the response, want it truncated", len(err.Error()))
+			}
+			if cache.Contains(request.CacheKey()) {
+				t.Error("failed generation was cached")
+			}
+		})
+	}
+}
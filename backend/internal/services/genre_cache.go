@@ -0,0 +1,278 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	genresMovie = "movie"
+	genresTV    = "tv"
+
+	// genreFailureTTL is how long a list that failed to load is served from
+	// the fallbacks before TMDB is asked for it again
+	genreFailureTTL = time.Minute
+)
+
+// genreStartupWait bounds how long NewGenreCache waits for the first load
+var genreStartupWait = 5 * time.Second
+
+// fallbackGenres are TMDB's English genre names, served only when a list has
+// never been fetched in any language. TMDB genre IDs are stable.
+var fallbackGenres = map[string]map[int]string{
+	genresMovie: {
+		28:    "Action",
+		12:    "Adventure",
+		16:    "Animation",
+		35:    "Comedy",
+		80:    "Crime",
+		99:    "Documentary",
+		18:    "Drama",
+		10751: "Family",
+		14:    "Fantasy",
+		36:    "History",
+		27:    "Horror",
+		10402: "Music",
+		9648:  "Mystery",
+		10749: "Romance",
+		878:   "Science Fiction",
+		10770: "TV Movie",
+		53:    "Thriller",
+		10752: "War",
+		37:    "Western",
+	},
+	genresTV: {
+		10759: "Action & Adventure",
+		16:    "Animation",
+		35:    "Comedy",
+		80:    "Crime",
+		99:    "Documentary",
+		18:    "Drama",
+		10751: "Family",
+		10762: "Kids",
+		9648:  "Mystery",
+		10763: "News",
+		10764: "Reality",
+		10765: "Sci-Fi & Fantasy",
+		10766: "Soap",
+		10767: "Talk",
+		10768: "War & Politics",
+		37:    "Western",
+	},
+}
+
+// genreList identifies one TMDB genre list
+type genreList struct {
+	mediaType string
+	language  string
+}
+
+// GenreCache holds TMDB's movie and TV genre lists per language. The lists
+// are loaded in the background when the cache is created and then refreshed
+// periodically; a failed refresh keeps the last good copy, so lookups never
+// wait on TMDB once a list has been loaded. A list that failed to load is not
+// fetched again for genreFailureTTL.
+type GenreCache struct {
+	tmdb *TMDBService
+
+	mu          sync.RWMutex
+	lists       map[genreList]map[int]string
+	wanted      map[genreList]bool      // every list to refresh, loaded or not
+	failedAt    map[genreList]time.Time // when a list that never loaded last failed
+	refreshedAt time.Time               // when a list was last fetched
+
+	stop      chan struct{}
+	closeOnce sync.Once
+}
+
+// GenreCacheStats describes the cached genre lists, exposed through /health
+type GenreCacheStats struct {
+	Lists       int        `json:"lists"`
+	RefreshedAt *time.Time `json:"refreshed_at,omitempty"`
+}
+
+// NewGenreCache starts loading the movie and TV genre lists in each language
+// and then refreshes them every interval until Close. A zero interval
+// disables the periodic refresh. It waits at most genreStartupWait for the
+// first load, so a slow TMDB does not hold up startup; until a list arrives
+// lookups fetch it themselves or fall back.
+func NewGenreCache(tmdb *TMDBService, languages []string, interval time.Duration) *GenreCache {
+	c := &GenreCache{
+		tmdb:     tmdb,
+		lists:    make(map[genreList]map[int]string),
+		wanted:   make(map[genreList]bool),
+		failedAt: make(map[genreList]time.Time),
+		stop:     make(chan struct{}),
+	}
+
+	for _, language := range languages {
+		c.wanted[genreList{genresMovie, language}] = true
+		c.wanted[genreList{genresTV, language}] = true
+	}
+
+	loaded := make(chan struct{})
+	go func() {
+		c.refresh()
+		close(loaded)
+		if interval > 0 {
+			c.run(interval)
+		}
+	}()
+
+	select {
+	case <-loaded:
+	case <-time.After(genreStartupWait):
+		slog.Warn("Genre lists are still loading, continuing startup", "waited", genreStartupWait)
+	}
+	return c
+}
+
+// Movie returns the movie genre names by ID in language
+func (c *GenreCache) Movie(ctx context.Context, language string) map[int]string {
+	return c.get(ctx, genreList{genresMovie, language})
+}
+
+// TV returns the TV genre names by ID in language
+func (c *GenreCache) TV(ctx context.Context, language string) map[int]string {
+	return c.get(ctx, genreList{genresTV, language})
+}
+
+// Stats returns the number of cached lists and when one was last fetched
+func (c *GenreCache) Stats() GenreCacheStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	stats := GenreCacheStats{Lists: len(c.lists)}
+	if !c.refreshedAt.IsZero() {
+		refreshedAt := c.refreshedAt
+		stats.RefreshedAt = &refreshedAt
+	}
+	return stats
+}
+
+// Close stops the background refresh
+func (c *GenreCache) Close() {
+	c.closeOnce.Do(func() { close(c.stop) })
+}
+
+// get returns a cached list, fetching it on a miss unless it failed to load
+// within genreFailureTTL. When the list is unavailable it falls back to the
+// list in DefaultLanguage and then to fallbackGenres.
+func (c *GenreCache) get(ctx context.Context, list genreList) map[int]string {
+	genres, ok, failed := c.lookup(list)
+	if ok {
+		return genres
+	}
+
+	if !failed {
+		genres, err := c.fetch(ctx, list)
+		if err == nil {
+			c.store(list, genres)
+			return genres
+		}
+		c.fail(list)
+		slog.WarnContext(ctx, "Failed to fetch genres, using fallback", "media_type", list.mediaType, "language", list.language, "error", err)
+	}
+
+	if genres, ok := c.cached(genreList{list.mediaType, DefaultLanguage}); ok {
+		return genres
+	}
+	return fallbackGenres[list.mediaType]
+}
+
+// cached returns the last good copy of a list
+func (c *GenreCache) cached(list genreList) (map[int]string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	genres, ok := c.lists[list]
+	return genres, ok
+}
+
+// lookup returns the last good copy of a list, or reports whether it failed
+// to load within genreFailureTTL
+func (c *GenreCache) lookup(list genreList) (genres map[int]string, ok bool, failed bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if genres, ok := c.lists[list]; ok {
+		return genres, true, false
+	}
+	failedAt, failed := c.failedAt[list]
+	return nil, false, failed && time.Since(failedAt) < genreFailureTTL
+}
+
+// store replaces the cached copy of a list and keeps it refreshed. Stored
+// maps are never modified, so callers may keep reading the ones they were given.
+func (c *GenreCache) store(list genreList, genres map[int]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lists[list] = genres
+	c.wanted[list] = true
+	delete(c.failedAt, list)
+	c.refreshedAt = time.Now()
+}
+
+// fail records that a list failed to load, unless a good copy is cached
+func (c *GenreCache) fail(list genreList) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.lists[list]; !ok {
+		c.failedAt[list] = time.Now()
+	}
+}
+
+// fetch retrieves a list from TMDB
+func (c *GenreCache) fetch(ctx context.Context, list genreList) (map[int]string, error) {
+	if list.mediaType == genresTV {
+		return c.tmdb.GetTVGenres(ctx, list.language)
+	}
+	return c.tmdb.GetGenres(ctx, list.language)
+}
+
+// run refreshes the lists each interval until Close
+func (c *GenreCache) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.refresh()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// refresh fetches every wanted list concurrently, keeping the last good copy
+// of any that fail
+func (c *GenreCache) refresh() {
+	c.mu.RLock()
+	lists := make([]genreList, 0, len(c.wanted))
+	for list := range c.wanted {
+		lists = append(lists, list)
+	}
+	c.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, list := range lists {
+		wg.Add(1)
+		go func(list genreList) {
+			defer wg.Done()
+
+			genres, err := c.fetch(context.Background(), list)
+			if err != nil {
+				c.fail(list)
+				slog.Warn("Genre refresh failed, keeping last good copy", "media_type", list.mediaType, "language", list.language, "error", err)
+				return
+			}
+			c.store(list, genres)
+		}(list)
+	}
+	wg.Wait()
+}
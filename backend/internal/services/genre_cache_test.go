@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"spoiler_api/internal/upstream"
+)
+
+// genreTMDB serves genre lists keyed by "movie/en"-style media type and
+// language, answering 404 for the rest, and counts the requests for each.
+// While gate is set every request waits for it to close.
+type genreTMDB struct {
+	lists map[string]string
+	gate  chan struct{}
+
+	mu       sync.Mutex
+	requests map[string]int
+}
+
+func (f *genreTMDB) RoundTrip(req *http.Request) (*http.Response, error) {
+	mediaType := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/3/genre/"), "/list")
+	key := mediaType + "/" + req.URL.Query().Get("language")
+
+	f.mu.Lock()
+	f.requests[key]++
+	f.mu.Unlock()
+
+	if f.gate != nil {
+		<-f.gate
+	}
+
+	status, body := http.StatusNotFound, `{"status_code":34}`
+	if list, ok := f.lists[key]; ok {
+		status, body = http.StatusOK, list
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+// Requests returns how many times the list for key was requested
+func (f *genreTMDB) Requests(key string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[key]
+}
+
+// newGenreTMDB returns a fake TMDB serving lists and a TMDB service talking to it
+func newGenreTMDB(lists map[string]string) (*genreTMDB, *TMDBService) {
+	fake := &genreTMDB{lists: lists, requests: make(map[string]int)}
+	return fake, NewTMDBService("test-key", upstream.New("tmdb", upstream.Options{
+		Timeout:    time.Second,
+		MaxRetries: -1,
+		Transport:  fake,
+	}))
+}
+
+func TestGenreCache(t *testing.T) {
+	lists := map[string]string{
+		"movie/en": `{"genres":[{"id":28,"name":"Action"}]}`,
+		"tv/en":    `{"genres":[{"id":16,"name":"Animation"}]}`,
+		"movie/fr": `{"genres":[{"id":28,"name":"Film d'action"}]}`,
+	}
+
+	tests := []struct {
+		name         string
+		mediaType    string
+		language     string
+		want         string
+		wantRequests int
+	}{
+		{name: "loaded at startup", mediaType: genresMovie, language: "en", want: "Action", wantRequests: 1},
+		{name: "tv list", mediaType: genresTV, language: "en", want: "Animation", wantRequests: 1},
+		{name: "fetched on first use", mediaType: genresMovie, language: "fr", want: "Film d'action", wantRequests: 1},
+		{name: "startup failure falls back to English", mediaType: genresMovie, language: "es", want: "Action", wantRequests: 1},
+		{name: "first use failure falls back to English", mediaType: genresMovie, language: "de", want: "Action", wantRequests: 1},
+		{name: "tv falls back to English", mediaType: genresTV, language: "es", want: "Animation", wantRequests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, tmdb := newGenreTMDB(lists)
+			cache := NewGenreCache(tmdb, []string{"en", "es"}, 0)
+			defer cache.Close()
+
+			// Repeated lookups are served from the cache, and failures are remembered
+			key := tt.mediaType + "/" + tt.language
+			for i := 0; i < 3; i++ {
+				genres := cache.get(context.Background(), genreList{tt.mediaType, tt.language})
+				if got := genres[28] + genres[16]; got != tt.want {
+					t.Fatalf("lookup %d = %q, want %q", i+1, got, tt.want)
+				}
+			}
+			if requests := fake.Requests(key); requests != tt.wantRequests {
+				t.Errorf("%s requested %d times, want %d", key, requests, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestGenreCacheRetriesFailedListsAfterTTL(t *testing.T) {
+	fake, tmdb := newGenreTMDB(map[string]string{"movie/en": `{"genres":[{"id":28,"name":"Action"}]}`})
+	cache := NewGenreCache(tmdb, []string{"en", "es"}, 0)
+	defer cache.Close()
+
+	list := genreList{genresMovie, "es"}
+	cache.get(context.Background(), list)
+	if requests := fake.Requests("movie/es"); requests != 1 {
+		t.Fatalf("movie/es requested %d times within the failure TTL, want 1", requests)
+	}
+
+	// Once the failure expires the list is fetched again, and kept when it loads
+	cache.mu.Lock()
+	cache.failedAt[list] = time.Now().Add(-genreFailureTTL)
+	cache.mu.Unlock()
+	fake.lists["movie/es"] = `{"genres":[{"id":28,"name":"Acción"}]}`
+
+	for i := 0; i < 2; i++ {
+		if got := cache.Movie(context.Background(), "es")[28]; got != "Acción" {
+			t.Fatalf("lookup %d after the TTL = %q, want Acción", i+1, got)
+		}
+	}
+	if requests := fake.Requests("movie/es"); requests != 2 {
+		t.Errorf("movie/es requested %d times, want 2", requests)
+	}
+}
+
+func TestGenreCacheStartupWaitIsBounded(t *testing.T) {
+	defer func(wait time.Duration) { genreStartupWait = wait }(genreStartupWait)
+	genreStartupWait = 20 * time.Millisecond
+
+	fake, tmdb := newGenreTMDB(map[string]string{
+		"movie/en": `{"genres":[{"id":28,"name":"Action"}]}`,
+		"tv/en":    `{"genres":[{"id":16,"name":"Animation"}]}`,
+	})
+	fake.gate = make(chan struct{})
+
+	started := time.Now()
+	cache := NewGenreCache(tmdb, []string{"en"}, 0)
+	defer cache.Close()
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Fatalf("NewGenreCache waited %v on a stalled TMDB", elapsed)
+	}
+	if lists := cache.Stats().Lists; lists != 0 {
+		t.Fatalf("%d lists cached before TMDB answered", lists)
+	}
+
+	// The first load finishes in the background
+	close(fake.gate)
+	deadline := time.Now().Add(time.Second)
+	for cache.Stats().Lists != 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("genre lists never loaded in the background")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
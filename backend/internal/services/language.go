@@ -0,0 +1,128 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is the language spoilers were written in before requests
+// could choose one. Its spoilers keep the unsuffixed cache keys.
+const DefaultLanguage = "en"
+
+// ErrUnsupportedLanguage is returned when a client asks for a language the server is not configured for
+var ErrUnsupportedLanguage = errors.New("unsupported language")
+
+// languageNames are the English names the prompt uses for common language codes
+var languageNames = map[string]string{
+	"am": "Amharic",
+	"ar": "Arabic",
+	"de": "German",
+	"en": "English",
+	"es": "Spanish",
+	"fr": "French",
+	"hi": "Hindi",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"pt": "Portuguese",
+	"ru": "Russian",
+	"sw": "Swahili",
+	"zh": "Chinese",
+}
+
+// LanguageName returns the English name of a language code such as "es" or
+// "es-MX", or the code itself when the name is not known
+func LanguageName(code string) string {
+	if name, ok := languageNames[primarySubtag(code)]; ok {
+		return name
+	}
+	return code
+}
+
+// NegotiateLanguage picks the response language from the supported ones.
+// An explicit lang parameter wins and must be supported; otherwise the
+// Accept-Language header is tried in order of preference, falling back to
+// the first supported language.
+func NegotiateLanguage(lang, acceptLanguage string, supported []string) (string, error) {
+	if lang != "" {
+		if match := matchLanguage(lang, supported); match != "" {
+			return match, nil
+		}
+		return "", fmt.Errorf("%w %q", ErrUnsupportedLanguage, lang)
+	}
+
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if match := matchLanguage(tag, supported); match != "" {
+			return match, nil
+		}
+	}
+
+	if len(supported) == 0 {
+		return DefaultLanguage, nil
+	}
+	return supported[0], nil
+}
+
+// matchLanguage returns the supported language equal to tag, or else the
+// first one sharing its primary subtag ("es-MX" matches "es"), or ""
+func matchLanguage(tag string, supported []string) string {
+	for _, language := range supported {
+		if strings.EqualFold(language, tag) {
+			return language
+		}
+	}
+	for _, language := range supported {
+		if primarySubtag(language) == primarySubtag(tag) {
+			return language
+		}
+	}
+	return ""
+}
+
+// parseAcceptLanguage returns the language tags of an Accept-Language header,
+// most preferred first. Wildcards and tags with q=0 are dropped.
+func parseAcceptLanguage(header string) []string {
+	type weightedTag struct {
+		tag    string
+		weight float64
+	}
+
+	var tags []weightedTag
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			weight = parsed
+		}
+		if weight <= 0 {
+			continue
+		}
+		tags = append(tags, weightedTag{tag: tag, weight: weight})
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].weight > tags[j].weight })
+
+	ordered := make([]string, len(tags))
+	for i, tag := range tags {
+		ordered[i] = tag.tag
+	}
+	return ordered
+}
+
+// primarySubtag returns the lower-cased language part of a tag: "es" for "es-MX"
+func primarySubtag(tag string) string {
+	primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+	return primary
+}
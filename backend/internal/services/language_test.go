@@ -0,0 +1,106 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"spoiler_api/internal/models"
+)
+
+func TestNegotiateLanguage(t *testing.T) {
+	supported := []string{"en", "es", "pt-BR", "am"}
+
+	tests := []struct {
+		name           string
+		lang           string
+		acceptLanguage string
+		supported      []string
+		want           string
+		wantErr        bool
+	}{
+		{name: "explicit lang", lang: "es", acceptLanguage: "am", supported: supported, want: "es"},
+		{name: "explicit lang ignores case", lang: "ES", supported: supported, want: "es"},
+		{name: "explicit region falls back to its language", lang: "es-MX", supported: supported, want: "es"},
+		{name: "explicit language matches a supported region", lang: "pt", supported: supported, want: "pt-BR"},
+		{name: "unsupported explicit lang", lang: "fr", acceptLanguage: "es", supported: supported, wantErr: true},
+		{name: "header order", acceptLanguage: "am, es", supported: supported, want: "am"},
+		{name: "header weights", acceptLanguage: "fr;q=0.9, es;q=0.8, am;q=0.95", supported: supported, want: "am"},
+		{name: "header skips unsupported", acceptLanguage: "fr-CA, de;q=0.9, es-419;q=0.5", supported: supported, want: "es"},
+		{name: "header q=0 excludes", acceptLanguage: "es;q=0, am;q=0.1", supported: supported, want: "am"},
+		{name: "header wildcard", acceptLanguage: "*", supported: supported, want: "en"},
+		{name: "no preference", supported: supported, want: "en"},
+		{name: "nothing supported matches", acceptLanguage: "fr", supported: []string{"es", "am"}, want: "es"},
+		{name: "no supported languages", acceptLanguage: "fr", want: DefaultLanguage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NegotiateLanguage(tt.lang, tt.acceptLanguage, tt.supported)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsupportedLanguage) {
+					t.Errorf("NegotiateLanguage = %q, %v; want ErrUnsupportedLanguage", got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("NegotiateLanguage = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"", []string{}},
+		{"es", []string{"es"}},
+		{"en-US,en;q=0.9,es;q=0.8", []string{"en-US", "en", "es"}},
+		{"es;q=0.5, am", []string{"am", "es"}},
+		{"fr;q=0.8, de;q=0.8, it", []string{"it", "fr", "de"}},
+		{"*, es;q=0.1, am;q=0, fr;q=oops", []string{"es"}},
+		{" , es ; q=0.7 ,", []string{"es"}},
+	}
+
+	for _, tt := range tests {
+		if got := parseAcceptLanguage(tt.header); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseAcceptLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestLanguageName(t *testing.T) {
+	tests := map[string]string{
+		"es":    "Spanish",
+		"es-MX": "Spanish",
+		"AM":    "Amharic",
+		"tlh":   "tlh",
+	}
+	for code, want := range tests {
+		if got := LanguageName(code); got != want {
+			t.Errorf("LanguageName(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
+
+func TestSpoilerRequestCacheKeyLanguage(t *testing.T) {
+	tests := []struct {
+		request SpoilerRequest
+		want    string
+	}{
+		{SpoilerRequest{TMDBID: 27205}, "27205"},
+		{SpoilerRequest{TMDBID: 27205, Language: "en"}, "27205"},
+		{SpoilerRequest{TMDBID: 27205, Language: "es"}, "27205:es"},
+		{SpoilerRequest{TMDBID: 27205, Level: models.SpoilerTeaser, Language: "am"}, "27205:teaser:am"},
+		{SpoilerRequest{TMDBID: 27205, Kind: KindOutline, Language: "es"}, "27205:outline:es"},
+		{SpoilerRequest{TMDBID: 1399, Kind: KindEpisode, Season: 1, Episode: 2, Language: "es"}, "tv:1399:s1e2:es"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.request.CacheKey(); got != tt.want {
+			t.Errorf("CacheKey(%+v) = %q, want %q", tt.request, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"spoiler_api/internal/models"
+)
+
+const (
+	// matchMargin is how far the best result's score must be ahead of the
+	// runner-up for it to be picked without asking the user
+	matchMargin = 0.15
+	// maxCandidates is the number of alternatives returned for an ambiguous title
+	maxCandidates = 5
+
+	titleWeight      = 0.6
+	popularityWeight = 0.4
+	yearBonus        = 0.5
+)
+
+// AmbiguousMovieError is returned when a title matches several movies about
+// equally well. Candidates are ordered best match first.
+type AmbiguousMovieError struct {
+	Title      string
+	Candidates []models.TMDBMovie
+}
+
+func (e *AmbiguousMovieError) Error() string {
+	return fmt.Sprintf("multiple movies match title: %s", e.Title)
+}
+
+// scoredMovie is a search result with its match score
+type scoredMovie struct {
+	movie models.TMDBMovie
+	score float64
+}
+
+// pickBestMatch ranks search results by title similarity, popularity and
+// release year and returns the best one, or an *AmbiguousMovieError when the
+// runner-up is within matchMargin of it
+func pickBestMatch(title, year string, results []models.TMDBMovie) (*models.TMDBMovie, error) {
+	if len(results) == 1 {
+		return &results[0], nil
+	}
+
+	maxPopularity := 0.0
+	for _, result := range results {
+		maxPopularity = math.Max(maxPopularity, result.Popularity)
+	}
+
+	query := normalizeTitle(title)
+	scored := make([]scoredMovie, len(results))
+	for i, result := range results {
+		// Localized results carry the translated title, so a query in the original title must still match
+		similarity := math.Max(
+			titleSimilarity(query, normalizeTitle(result.Title)),
+			titleSimilarity(query, normalizeTitle(result.OriginalTitle)),
+		)
+		score := titleWeight * similarity
+
+		// Log scaling keeps a blockbuster from drowning out an older film with the same name
+		if maxPopularity > 0 {
+			score += popularityWeight * math.Log1p(result.Popularity) / math.Log1p(maxPopularity)
+		}
+
+		if year != "" && strings.HasPrefix(result.ReleaseDate, year) {
+			score += yearBonus
+		}
+
+		scored[i] = scoredMovie{movie: result, score: score}
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].score > scored[j].score })
+
+	if scored[0].score-scored[1].score >= matchMargin {
+		return &scored[0].movie, nil
+	}
+
+	ambiguous := &AmbiguousMovieError{Title: title}
+	for _, candidate := range scored {
+		if len(ambiguous.Candidates) == maxCandidates {
+			break
+		}
+		ambiguous.Candidates = append(ambiguous.Candidates, candidate.movie)
+	}
+	return nil, ambiguous
+}
+
+// normalizeTitle lowercases a title and reduces punctuation to single spaces
+func normalizeTitle(title string) string {
+	fields := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// titleSimilarity returns a similarity between 0 and 1 based on edit distance
+func titleSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein computes the edit distance between two rune slices
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
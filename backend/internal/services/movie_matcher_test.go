@@ -0,0 +1,128 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"spoiler_api/internal/models"
+)
+
+func TestPickBestMatch(t *testing.T) {
+	movie := func(id int, title, releaseDate string, popularity float64) models.TMDBMovie {
+		return models.TMDBMovie{ID: id, Title: title, ReleaseDate: releaseDate, Popularity: popularity}
+	}
+	remakes := make([]models.TMDBMovie, 7)
+	for i := range remakes {
+		remakes[i] = movie(i+1, "Hamlet", "", float64(100-i))
+	}
+
+	tests := []struct {
+		name           string
+		title          string
+		year           string
+		results        []models.TMDBMovie
+		wantID         int
+		wantCandidates []int
+	}{
+		{
+			name:    "single result",
+			title:   "Inceptoin",
+			results: []models.TMDBMovie{movie(27205, "Inception", "2010-07-15", 80)},
+			wantID:  27205,
+		},
+		{
+			name:    "exact title beats a longer one",
+			title:   "Inception",
+			results: []models.TMDBMovie{movie(64956, "Inception: The Cobol Job", "2010-12-07", 80), movie(27205, "Inception", "2010-07-15", 80)},
+			wantID:  27205,
+		},
+		{
+			name:    "punctuation and case are ignored",
+			title:   "spider man no way home",
+			results: []models.TMDBMovie{movie(557, "Spider-Man", "2002-05-01", 60), movie(634649, "Spider-Man: No Way Home", "2021-12-15", 60)},
+			wantID:  634649,
+		},
+		{
+			name:    "much more popular film wins a shared title",
+			title:   "Dune",
+			results: []models.TMDBMovie{movie(841, "Dune", "1984-12-14", 2), movie(438631, "Dune", "2021-09-15", 200)},
+			wantID:  438631,
+		},
+		{
+			name:    "year settles a shared title",
+			title:   "The Thing",
+			year:    "2011",
+			results: []models.TMDBMovie{movie(1091, "The Thing", "1982-06-25", 40), movie(60935, "The Thing", "2011-10-12", 20)},
+			wantID:  60935,
+		},
+		{
+			name:  "original title matches a localized result",
+			title: "千と千尋の神隠し",
+			results: []models.TMDBMovie{
+				{ID: 129, Title: "Spirited Away", OriginalTitle: "千と千尋の神隠し", Popularity: 50},
+				movie(12477, "Grave of the Fireflies", "1988-04-16", 50),
+			},
+			wantID: 129,
+		},
+		{
+			name:           "similar popularity is ambiguous",
+			title:          "The Thing",
+			results:        []models.TMDBMovie{movie(1091, "The Thing", "1982-06-25", 40), movie(60935, "The Thing", "2011-10-12", 20)},
+			wantCandidates: []int{1091, 60935},
+		},
+		{
+			name:           "candidates are capped and ordered best first",
+			title:          "Hamlet",
+			results:        remakes,
+			wantCandidates: []int{1, 2, 3, 4, 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pickBestMatch(tt.title, tt.year, tt.results)
+
+			if tt.wantCandidates == nil {
+				if err != nil {
+					t.Fatalf("pickBestMatch: %v", err)
+				}
+				if got.ID != tt.wantID {
+					t.Errorf("picked %d (%s), want %d", got.ID, got.Title, tt.wantID)
+				}
+				return
+			}
+
+			var ambiguous *AmbiguousMovieError
+			if !errors.As(err, &ambiguous) {
+				t.Fatalf("got %v, %v; want an *AmbiguousMovieError", got, err)
+			}
+			var ids []int
+			for _, candidate := range ambiguous.Candidates {
+				ids = append(ids, candidate.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantCandidates) {
+				t.Errorf("candidates = %v, want %v", ids, tt.wantCandidates)
+			}
+		})
+	}
+}
+
+func TestTitleSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"inception", "inception", 1},
+		{"", "", 1},
+		{"abc", "", 0},
+		{"kitten", "sitting", 1 - 3.0/7},
+		{"amélie", "amelie", 1 - 1.0/6},
+	}
+
+	for _, tt := range tests {
+		if got := titleSimilarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("titleSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
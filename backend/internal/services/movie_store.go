@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"spoiler_api/internal/models"
+	"spoiler_api/internal/tracing"
+)
+
+// ErrWriteRejected means the store refused a write for good, e.g. a malformed
+// row; unlike other write errors, retrying it will not help
+var ErrWriteRejected = errors.New("write rejected by the store")
+
+// MovieStore persists generated spoilers so they survive restarts and are
+// shared between instances. Movies are keyed by TMDB ID and language, so
+// each translation of a spoiler is stored separately.
+type MovieStore interface {
+	// FindMovie returns the stored movie in language, or nil (and no error) when it is not stored
+	FindMovie(ctx context.Context, tmdbID int, language string) (*models.MovieResponse, error)
+	// SaveMovie inserts a movie in its Language or replaces the stored copy
+	SaveMovie(ctx context.Context, movie *models.MovieResponse) error
+	// IncrementSearchCount records another lookup of a stored movie in language
+	IncrementSearchCount(ctx context.Context, tmdbID int, language string) error
+	// ListTrending returns up to limit movies in language ordered by search count
+	ListTrending(ctx context.Context, language string, limit int) ([]models.MovieResponse, error)
+	// DeleteMovie removes a stored movie in every language; deleting a missing movie is not an error
+	DeleteMovie(ctx context.Context, tmdbID int) error
+}
+
+// storedLanguage returns the language a spoiler is stored under. Spoilers
+// saved without one were written in DefaultLanguage.
+func storedLanguage(language string) string {
+	if language == "" {
+		return DefaultLanguage
+	}
+	return language
+}
+
+// tracedStore wraps a MovieStore with a span around every call
+type tracedStore struct {
+	store   MovieStore
+	backend string
+}
+
+// tracedOutlineStore is a tracedStore over a store that also keeps scene outlines
+type tracedOutlineStore struct {
+	*tracedStore
+	outlines OutlineStore
+}
+
+// NewTracedStore returns store with each call traced as a "store.*" span
+// tagged with the backend name and TMDB ID. The result implements
+// OutlineStore, with outline calls traced too, when store does.
+func NewTracedStore(store MovieStore, backend string) MovieStore {
+	traced := &tracedStore{store: store, backend: backend}
+	if outlines, ok := store.(OutlineStore); ok {
+		return &tracedOutlineStore{tracedStore: traced, outlines: outlines}
+	}
+	return traced
+}
+
+func (t *tracedStore) FindMovie(ctx context.Context, tmdbID int, language string) (movie *models.MovieResponse, err error) {
+	ctx, span := tracing.Start(ctx, "store.find_movie", tracing.AttrStoreBackend.String(t.backend), tracing.AttrMovieID.Int(tmdbID), tracing.AttrLanguage.String(language))
+	defer func() {
+		span.SetAttributes(tracing.AttrStoreHit.Bool(movie != nil))
+		tracing.End(span, err)
+	}()
+	return t.store.FindMovie(ctx, tmdbID, language)
+}
+
+func (t *tracedStore) SaveMovie(ctx context.Context, movie *models.MovieResponse) (err error) {
+	ctx, span := tracing.Start(ctx, "store.save_movie", tracing.AttrStoreBackend.String(t.backend), tracing.AttrMovieID.Int(movie.TMDBID), tracing.AttrLanguage.String(movie.Language))
+	defer func() { tracing.End(span, err) }()
+	return t.store.SaveMovie(ctx, movie)
+}
+
+func (t *tracedStore) IncrementSearchCount(ctx context.Context, tmdbID int, language string) (err error) {
+	ctx, span := tracing.Start(ctx, "store.increment_search_count", tracing.AttrStoreBackend.String(t.backend), tracing.AttrMovieID.Int(tmdbID), tracing.AttrLanguage.String(language))
+	defer func() { tracing.End(span, err) }()
+	return t.store.IncrementSearchCount(ctx, tmdbID, language)
+}
+
+func (t *tracedStore) ListTrending(ctx context.Context, language string, limit int) (_ []models.MovieResponse, err error) {
+	ctx, span := tracing.Start(ctx, "store.list_trending", tracing.AttrStoreBackend.String(t.backend), tracing.AttrLanguage.String(language))
+	defer func() { tracing.End(span, err) }()
+	return t.store.ListTrending(ctx, language, limit)
+}
+
+func (t *tracedStore) DeleteMovie(ctx context.Context, tmdbID int) (err error) {
+	ctx, span := tracing.Start(ctx, "store.delete_movie", tracing.AttrStoreBackend.String(t.backend), tracing.AttrMovieID.Int(tmdbID))
+	defer func() { tracing.End(span, err) }()
+	return t.store.DeleteMovie(ctx, tmdbID)
+}
+
+func (t *tracedOutlineStore) FindOutline(ctx context.Context, tmdbID int, language string) (outline string, err error) {
+	ctx, span := tracing.Start(ctx, "store.find_outline", tracing.AttrStoreBackend.String(t.backend), tracing.AttrMovieID.Int(tmdbID), tracing.AttrLanguage.String(language))
+	defer func() {
+		span.SetAttributes(tracing.AttrStoreHit.Bool(outline != ""))
+		tracing.End(span, err)
+	}()
+	return t.outlines.FindOutline(ctx, tmdbID, language)
+}
+
+func (t *tracedOutlineStore) SaveOutline(ctx context.Context, tmdbID int, language, outline string) (err error) {
+	ctx, span := tracing.Start(ctx, "store.save_outline", tracing.AttrStoreBackend.String(t.backend), tracing.AttrMovieID.Int(tmdbID), tracing.AttrLanguage.String(language))
+	defer func() { tracing.End(span, err) }()
+	return t.outlines.SaveOutline(ctx, tmdbID, language, outline)
+}
+
+func (t *tracedOutlineStore) DeleteOutline(ctx context.Context, tmdbID int) (err error) {
+	ctx, span := tracing.Start(ctx, "store.delete_outline", tracing.AttrStoreBackend.String(t.backend), tracing.AttrMovieID.Int(tmdbID))
+	defer func() { tracing.End(span, err) }()
+	return t.outlines.DeleteOutline(ctx, tmdbID)
+}
+
+// Close closes the wrapped store when it holds resources
+func (t *tracedStore) Close() error {
+	if closer, ok := t.store.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestTracedStoreKeepsOutlines(t *testing.T) {
+	if _, ok := NewTracedStore(&recordingStore{}, "test").(OutlineStore); ok {
+		t.Error("traced store claims to keep outlines for a store that does not")
+	}
+
+	sqlite, err := NewSQLiteStore(filepath.Join(t.TempDir(), "spoilers.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlite.Close()
+
+	outlines, ok := NewTracedStore(sqlite, "sqlite").(OutlineStore)
+	if !ok {
+		t.Fatal("traced SQLite store does not keep outlines")
+	}
+	ctx := context.Background()
+	if err := outlines.SaveOutline(ctx, 27205, "en", "- [0] **Opening** — It begins."); err != nil {
+		t.Fatalf("SaveOutline: %v", err)
+	}
+	if outline, err := outlines.FindOutline(ctx, 27205, "en"); err != nil || outline == "" {
+		t.Errorf("FindOutline = %q, %v; want the saved outline", outline, err)
+	}
+	if err := outlines.DeleteOutline(ctx, 27205); err != nil {
+		t.Fatalf("DeleteOutline: %v", err)
+	}
+	if outline, _ := outlines.FindOutline(ctx, 27205, "en"); outline != "" {
+		t.Errorf("FindOutline after delete = %q, want none", outline)
+	}
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"spoiler_api/internal/models"
+)
+
+// OfflineService generates deterministic template spoilers without calling
+// any model. It is meant for local development and tests: the output follows
+// the same section layout as the real prompt so the rest of the pipeline
+// (parsing, storage, the frontend) can be exercised end to end.
+type OfflineService struct{}
+
+// NewOfflineService creates a new offline template service instance
+func NewOfflineService() *OfflineService {
+	return &OfflineService{}
+}
+
+// Placeholder reports that offline spoilers must not be stored
+func (s *OfflineService) Placeholder() bool {
+	return true
+}
+
+// GenerateSpoiler renders the placeholder spoiler for a movie or TV show
+func (s *OfflineService) GenerateSpoiler(ctx context.Context, movie SpoilerRequest) (string, error) {
+	if movie.Kind == KindOutline {
+		return s.outline(movie), nil
+	}
+	if movie.Kind != KindMovie {
+		return s.tvSpoiler(movie), nil
+	}
+	if movie.Level == models.SpoilerTeaser || movie.Level == models.SpoilerPartial {
+		// Shorter levels are the full placeholder cut down
+		full := movie
+		full.Level = models.SpoilerFull
+		text, err := s.GenerateSpoiler(ctx, full)
+		if err != nil {
+			return "", err
+		}
+		structured, err := ParseSpoiler(text)
+		if err != nil {
+			return "", err
+		}
+		return RenderSpoiler(CutSpoiler(structured, movie.Level)), nil
+	}
+
+	overview := movie.Overview
+	if overview == "" {
+		overview = "No overview is available for this film."
+	}
+
+	title := fmt.Sprintf("**%s** (%s)", movie.Title, movie.Year)
+	if movie.Year == "" {
+		title = fmt.Sprintf("**%s**", movie.Title)
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "## Movie Overview\n%s\n\n", overview)
+	b.WriteString("## ⚠️ SPOILER WARNING\nEverything below contains major plot spoilers, twists, and ending details.\n\n")
+	fmt.Fprintf(&b, "## The Beginning\nThis is an offline placeholder for %s. The setup follows the premise described in the overview.\n\n", title)
+	fmt.Fprintf(&b, "## Major Turning Point\nThe offline provider does not know the plot of %s, so no turning point is described.\n\n", title)
+	b.WriteString("## The Climax\nPlaceholder climax. Configure a real LLM provider to generate the full breakdown.\n\n")
+	b.WriteString("## Ending Explained\nPlaceholder ending. No real spoiler has been generated for this film.\n\n")
+	b.WriteString("## Post-Credit Scene\nThis film does not have a post-credit scene.\n\n")
+
+	b.WriteString("## Key Moments\n")
+	for i := 1; i <= 5; i++ {
+		fmt.Fprintf(&b, "- **Moment %d** — Placeholder key moment %d.\n", i, i)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## Character Fates\n")
+	if len(movie.Cast) == 0 {
+		b.WriteString("- **Protagonist** | Unknown | UNKNOWN | Placeholder character generated offline.\n")
+	}
+	for i, member := range movie.Cast {
+		if i == 6 {
+			break
+		}
+		character := member.Character
+		if character == "" {
+			character = member.Name
+		}
+		fmt.Fprintf(&b, "- **%s** | %s | UNKNOWN | Placeholder fate generated offline.\n", character, member.Name)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("## What It Really Means\n")
+	b.WriteString("### Symbolism\n- Placeholder symbol.\n")
+	b.WriteString("### Hidden Clues\n- Placeholder clue.\n")
+	b.WriteString("### Fan Theories\n- Placeholder theory.\n")
+	b.WriteString("### Unanswered Questions\n- Placeholder question.\n")
+
+	return b.String(), nil
+}
+
+// tvSpoiler renders the placeholder for a series, season or episode, with
+// the headings of the matching prompt
+func (s *OfflineService) tvSpoiler(show SpoilerRequest) string {
+	overview := show.Overview
+	if overview == "" {
+		overview = "No overview is available."
+	}
+
+	var b strings.Builder
+	switch show.Kind {
+	case KindSeries:
+		fmt.Fprintf(&b, "## Series Overview\n%s\n\n", overview)
+		b.WriteString("## ⚠️ SPOILER WARNING\nEverything below contains major plot spoilers for every season.\n\n")
+		fmt.Fprintf(&b, "## Season by Season\nThis is an offline placeholder for **%s**.\n\n", show.Title)
+		b.WriteString("## How It Ends\nPlaceholder ending. Configure a real LLM provider to generate the full breakdown.\n\n")
+		b.WriteString("## Character Fates\n- **Protagonist** | Unknown | UNKNOWN | Placeholder character generated offline.\n\n")
+		b.WriteString("## Key Moments\n- **Moment 1** (Season 1) — Placeholder key moment.\n")
+	case KindSeason:
+		fmt.Fprintf(&b, "## Season Overview\n%s\n\n", overview)
+		b.WriteString("## ⚠️ SPOILER WARNING\nEverything below contains major plot spoilers for this season, including its finale.\n\n")
+		fmt.Fprintf(&b, "## Where It Starts\nThis is an offline placeholder for season %d of **%s**.\n\n", show.Season, show.Title)
+		b.WriteString("## Episode by Episode\n")
+		for i, title := range show.EpisodeTitles {
+			fmt.Fprintf(&b, "- **E%d: %s** — Placeholder episode summary.\n", i+1, title)
+		}
+		b.WriteString("\n## The Finale\nPlaceholder finale. Configure a real LLM provider to generate the full recap.\n\n")
+		b.WriteString("## Character Arcs\n- **Protagonist** | Unknown | UNKNOWN | Placeholder arc generated offline.\n\n")
+		b.WriteString("## Loose Ends\n- Placeholder open thread.\n")
+	default:
+		fmt.Fprintf(&b, "## Episode Overview\n%s\n\n", overview)
+		b.WriteString("## ⚠️ SPOILER WARNING\nEverything below contains major plot spoilers for this episode.\n\n")
+		fmt.Fprintf(&b, "## What Happens\nThis is an offline placeholder for **%s** season %d, episode %d (%s).\n\n", show.Title, show.Season, show.Episode, show.EpisodeTitle)
+		b.WriteString("## Key Moments\n- **Moment 1** — Placeholder key moment.\n\n")
+		b.WriteString("## The Ending\nPlaceholder ending. Configure a real LLM provider to generate the full breakdown.\n\n")
+		b.WriteString("## Character Developments\n- **Protagonist** | Unknown | UNKNOWN | Placeholder development generated offline.\n")
+	}
+	return b.String()
+}
+
+// outline renders a placeholder scene outline with ten scenes spread evenly
+// over the runtime
+func (s *OfflineService) outline(movie SpoilerRequest) string {
+	runtime := movie.Runtime
+	if runtime <= 0 {
+		runtime = 100
+	}
+
+	var b strings.Builder
+	b.WriteString("## Scene Outline\n")
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(&b, "- [%d] **Scene %d** — Placeholder scene %d of **%s**, generated offline.\n", i*runtime/10, i+1, i+1, movie.Title)
+	}
+	return b.String()
+}
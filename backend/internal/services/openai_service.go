@@ -0,0 +1,114 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"spoiler_api/internal/models"
+	"spoiler_api/internal/tracing"
+	"spoiler_api/internal/upstream"
+)
+
+// OpenAIService generates spoilers through any OpenAI-compatible
+// /v1/chat/completions endpoint, such as a local llama.cpp or Ollama server
+type OpenAIService struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *upstream.Client
+	cache   *SpoilerCache
+}
+
+// NewOpenAIService creates a new OpenAI-compatible service instance.
+// apiKey may be empty for local servers that do not require one.
+func NewOpenAIService(baseURL, apiKey, model string, client *upstream.Client, cache *SpoilerCache) *OpenAIService {
+	return &OpenAIService{
+		baseURL: strings.TrimSuffix(strings.TrimRight(baseURL, "/"), "/v1"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  client,
+		cache:   cache,
+	}
+}
+
+// GenerateSpoiler generates a detailed spoiler explanation for a movie
+func (s *OpenAIService) GenerateSpoiler(ctx context.Context, movie SpoilerRequest) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "llm.generate_spoiler",
+		tracing.AttrMovieID.Int(movie.TMDBID),
+		tracing.AttrLLMProvider.String("openai"),
+		tracing.AttrLLMModel.String(s.model),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	// Check cache first
+	cacheKey := movie.CacheKey()
+	if cachedSpoiler, exists := s.cache.Get(cacheKey); exists {
+		span.SetAttributes(tracing.AttrCacheTier.String("memory"))
+		return cachedSpoiler, nil
+	}
+	span.SetAttributes(tracing.AttrCacheTier.String("miss"))
+
+	request := models.OpenAIChatRequest{
+		Model: s.model,
+		Messages: []models.OpenAIChatMessage{
+			{
+				Role:    "user",
+				Content: buildSpoilerPrompt(movie),
+			},
+		},
+	}
+
+	requestBody, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal chat completion request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL+"/v1/chat/completions", bytes.NewBuffer(requestBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create chat completion request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to call chat completion API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("chat completion API error: %w", s.client.ResponseError(resp))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read chat completion response: %w", err)
+	}
+
+	var chatResp models.OpenAIChatResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return "", fmt.Errorf("failed to parse chat completion response: %w", err)
+	}
+
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("%w: no choices in chat completion response", ErrGenerationRefused)
+	}
+
+	spoilerText := chatResp.Choices[0].Message.Content
+	if spoilerText == "" {
+		return "", fmt.Errorf("%w: empty choice in chat completion response", ErrGenerationRefused)
+	}
+
+	// Cache the result
+	s.cache.Set(cacheKey, spoilerText)
+
+	return spoilerText, nil
+}
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"spoiler_api/internal/models"
+)
+
+// buildSpoilerPrompt creates the detailed spoiler prompt shared by all LLM
+// providers, picking the variant for the kind of spoiler requested and
+// asking for the answer in the requested language
+func buildSpoilerPrompt(movie SpoilerRequest) string {
+	return buildKindPrompt(movie) + buildLanguagePrompt(movie.Language)
+}
+
+// buildLanguagePrompt asks for the answer in language, keeping the English
+// headings the spoiler parser reads. It is empty for DefaultLanguage.
+func buildLanguagePrompt(language string) string {
+	if language == "" || language == DefaultLanguage {
+		return ""
+	}
+
+	return fmt.Sprintf(`
+
+LANGUAGE:
+- Write all text under the headings in %[1]s (language code %[2]q), including the bold titles. Write names as they are commonly written in %[1]s.
+- Keep every ## heading exactly as written above, in English, so the response can be parsed. Only the text under the headings is translated.
+- If you do not have plot information, still use the Not Found heading above, in English, followed by the message in %[1]s.`, LanguageName(language), language)
+}
+
+// buildKindPrompt picks the prompt variant for the kind and level of spoiler requested
+func buildKindPrompt(movie SpoilerRequest) string {
+	switch movie.Kind {
+	case KindSeries:
+		return buildSeriesPrompt(movie)
+	case KindSeason:
+		return buildSeasonPrompt(movie)
+	case KindEpisode:
+		return buildEpisodePrompt(movie)
+	case KindOutline:
+		return buildOutlinePrompt(movie)
+	}
+
+	switch movie.Level {
+	case models.SpoilerTeaser:
+		return buildTeaserPrompt(movie)
+	case models.SpoilerPartial:
+		return buildPartialPrompt(movie)
+	default:
+		return buildMoviePrompt(movie)
+	}
+}
+
+// buildTeaserPrompt creates the prompt for a teaser: the setup only, for
+// readers deciding whether to watch
+func buildTeaserPrompt(movie SpoilerRequest) string {
+	return fmt.Sprintf(`You are an elite film analyst writing for a premium movie spoiler platform.
+
+Movie Title: %s
+Release Year: %s
+Movie Overview: %s
+%s
+You MUST structure your response using EXACTLY these markdown headings. Do NOT skip any section.
+
+## Movie Overview
+Write a compelling 2-3 sentence non-spoiler summary that hooks the reader.
+
+## The Beginning
+Describe the setup, world-building, and introduction of main characters, up to the end of the first act. 2 paragraphs.
+
+RULES:
+- Total length: 200-350 words.
+- Reveal NOTHING after the first act: no twists, turning points, deaths, or hints about the ending.
+- Use bold (**text**) for character names and important terms.
+- Do NOT fabricate facts — if you're unsure, say so.
+- If you do not have information for this specific movie, respond with ONLY: "## Movie Not Found\nWe don't have spoiler information for this movie yet." Do NOT substitute another film.
+- Every section heading must start with ## exactly as shown above.`, movie.Title, movie.Year, movie.Overview, buildCreditsPrompt(movie))
+}
+
+// buildPartialPrompt creates the prompt for a partial spoiler: the story up
+// to the major turning point, for readers halfway through the film
+func buildPartialPrompt(movie SpoilerRequest) string {
+	return fmt.Sprintf(`You are an elite film analyst writing for a premium movie spoiler platform.
+
+Movie Title: %s
+Release Year: %s
+Movie Overview: %s
+%s
+You MUST structure your response using EXACTLY these markdown headings. Do NOT skip any section.
+
+## Movie Overview
+Write a compelling 2-3 sentence non-spoiler summary that hooks the reader.
+
+## ⚠️ SPOILER WARNING
+Write exactly: "Everything below contains plot spoilers up to the midpoint of the film, but not the ending."
+
+## The Beginning
+Describe the setup, world-building, and introduction of main characters. 2-3 paragraphs.
+
+## Major Turning Point
+Describe the key event that changes everything. What shifts? What revelation occurs? 2-3 paragraphs.
+
+## Key Moments
+List exactly 3 pivotal scenes from the first half of the film. Format each as:
+- **[Scene Title]** — One sentence description of what happens and why it matters.
+
+RULES:
+- Total length: 400-700 words.
+- Reveal NOTHING after the major turning point: not the climax, the ending, or who lives and dies.
+- Use bold (**text**) for character names and important terms.
+- Do NOT fabricate facts — if you're unsure, say so.
+- If you do not have spoiler information for this specific movie, respond with ONLY: "## Movie Not Found\nWe don't have spoiler information for this movie yet." Do NOT substitute another film's spoiler.
+- Every section heading must start with ## exactly as shown above.`, movie.Title, movie.Year, movie.Overview, buildCreditsPrompt(movie))
+}
+
+// buildMoviePrompt creates the full movie breakdown prompt, with the ending
+// and character fates
+func buildMoviePrompt(movie SpoilerRequest) string {
+	prompt := fmt.Sprintf(`You are an elite film analyst writing for a premium movie spoiler platform.
+
+Movie Title: %s
+Release Year: %s
+Movie Overview: %s
+%s
+You MUST structure your response using EXACTLY these markdown headings. Do NOT skip any section.
+
+## Movie Overview
+Write a compelling 2-3 sentence non-spoiler summary that hooks the reader.
+
+## ⚠️ SPOILER WARNING
+Write exactly: "Everything below contains major plot spoilers, twists, and ending details."
+
+## The Beginning
+Describe the setup, world-building, and introduction of main characters. 2-3 paragraphs.
+
+## Major Turning Point
+Describe the key event that changes everything. What shifts? What revelation occurs? 2-3 paragraphs.
+
+## The Climax
+Describe the peak conflict, major confrontations, and pivotal decisions. 2-3 paragraphs.
+
+## Ending Explained
+Explain the ending in detail. If ambiguous, provide multiple interpretations. 2-3 paragraphs.
+
+## Post-Credit Scene
+If there is a post-credit scene, describe it. If not, write "This film does not have a post-credit scene."
+
+## Key Moments
+List exactly 5 pivotal scenes. Format each as:
+- **[Scene Title]** — One sentence description of what happens and why it matters.
+
+## Character Fates
+List the main characters (up to 6). Format each as:
+- **[Character Name]** | [Actor Name] | [ALIVE/DEAD/UNKNOWN] | One sentence about their arc and final fate.
+When a cast list is given above, use those exact actor and character names.
+
+## What It Really Means
+### Symbolism
+Explain 2-3 key symbols or motifs in the film.
+### Hidden Clues
+Describe 2-3 subtle details viewers might have missed.
+### Fan Theories
+Present 2-3 popular or plausible fan theories.
+### Unanswered Questions
+List 2-3 questions the film leaves unanswered.
+
+RULES:
+- Total length: 800-1200 words.
+- Use bold (**text**) for character names and important terms.
+- Do NOT fabricate facts — if you're unsure, say so.
+- If you do not have spoiler information for this specific movie, respond with ONLY: "## Movie Not Found\nWe don't have spoiler information for this movie yet." Do NOT substitute another film's spoiler.
+- Write in an engaging, editorial tone — like a premium film magazine.
+- Every section heading must start with ## exactly as shown above.`, movie.Title, movie.Year, movie.Overview, buildCreditsPrompt(movie))
+
+	return prompt
+}
+
+// buildOutlinePrompt creates the prompt for a timestamped scene outline of a
+// whole movie, which progress spoilers are cut from
+func buildOutlinePrompt(movie SpoilerRequest) string {
+	runtime := "unknown"
+	if movie.Runtime > 0 {
+		runtime = fmt.Sprintf("%d minutes", movie.Runtime)
+	}
+
+	return fmt.Sprintf(`You are an elite film analyst writing for a premium movie spoiler platform.
+
+Movie Title: %s
+Release Year: %s
+Runtime: %s
+Movie Overview: %s
+%s
+Write a chronological outline of the ENTIRE film, from the opening scene to the end credits, under exactly this heading:
+
+## Scene Outline
+List 15-30 scenes in the order they happen. Format each as:
+- [MM] **[Scene Title]** — One or two sentences on what happens.
+MM is the minute the scene starts, counted from the start of the film (for example [0], [7], [42], [118]).
+
+RULES:
+- Minutes must increase from scene to scene and must not exceed the runtime.
+- Spread the scenes across the whole runtime, including the climax and the ending.
+- Use bold (**text**) only for scene titles.
+- Do NOT fabricate facts — estimate timestamps as best you can, but never invent events.
+- If you do not have plot information for this specific movie, respond with ONLY: "## Movie Not Found\nWe don't have spoiler information for this movie yet." Do NOT substitute another film.`, movie.Title, movie.Year, runtime, movie.Overview, buildCreditsPrompt(movie))
+}
+
+// buildSeriesPrompt creates the prompt for a whole-show breakdown
+func buildSeriesPrompt(show SpoilerRequest) string {
+	return fmt.Sprintf(`You are an elite TV critic writing for a premium spoiler platform.
+
+Show Title: %s
+First Aired: %s
+Show Overview: %s
+%s
+You MUST structure your response using EXACTLY these markdown headings. Do NOT skip any section.
+
+## Series Overview
+Write a compelling 2-3 sentence non-spoiler summary that hooks the reader.
+
+## ⚠️ SPOILER WARNING
+Write exactly: "Everything below contains major plot spoilers for every season."
+
+## Season by Season
+For each season, write a ### heading ("### Season 1") followed by one paragraph covering its main arc and how it ends.
+
+## How It Ends
+Explain the series finale in detail. If the show is still running, explain where the story currently stands instead. 2-3 paragraphs.
+
+## Character Fates
+List the main characters (up to 8). Format each as:
+- **[Character Name]** | [Actor Name] | [ALIVE/DEAD/UNKNOWN] | One sentence about their arc and current or final fate.
+When a cast list is given above, use those exact actor and character names.
+
+## Key Moments
+List exactly 5 pivotal scenes across the series. Format each as:
+- **[Scene Title]** (Season N) — One sentence description of what happens and why it matters.
+
+RULES:
+- Total length: 800-1200 words.
+- Use bold (**text**) for character names and important terms.
+- Do NOT fabricate facts — if you're unsure, say so.
+- If you do not have spoiler information for this specific show, respond with ONLY: "## Show Not Found\nWe don't have spoiler information for this show yet." Do NOT substitute another show's spoiler.
+- Write in an engaging, editorial tone — like a premium TV magazine.
+- Every section heading must start with ## exactly as shown above.`, show.Title, show.Year, show.Overview, buildCreditsPrompt(show))
+}
+
+// buildSeasonPrompt creates the prompt for a season recap
+func buildSeasonPrompt(season SpoilerRequest) string {
+	var episodes strings.Builder
+	for i, title := range season.EpisodeTitles {
+		fmt.Fprintf(&episodes, "%d. %s\n", i+1, title)
+	}
+
+	return fmt.Sprintf(`You are an elite TV critic writing for a premium spoiler platform.
+
+Show Title: %s
+Season: %d
+Season Aired: %s
+Season Overview: %s
+Episodes:
+%s%s
+You MUST structure your response using EXACTLY these markdown headings. Do NOT skip any section.
+
+## Season Overview
+Write a compelling 2-3 sentence non-spoiler summary of the season.
+
+## ⚠️ SPOILER WARNING
+Write exactly: "Everything below contains major plot spoilers for this season, including its finale."
+
+## Where It Starts
+Describe where the characters and story stand at the start of the season. 1-2 paragraphs.
+
+## Episode by Episode
+List every episode above in order. Format each as:
+- **E[Number]: [Episode Title]** — Two or three sentences on what happens.
+
+## The Finale
+Explain how the season ends in detail. 2-3 paragraphs.
+
+## Character Arcs
+List the main characters (up to 6). Format each as:
+- **[Character Name]** | [Actor Name] | [ALIVE/DEAD/UNKNOWN] | One sentence about their arc this season and where they end up.
+When a cast list is given above, use those exact actor and character names.
+
+## Loose Ends
+List 2-4 cliffhangers or open threads the season leaves for the next one.
+
+RULES:
+- Total length: 700-1100 words.
+- Use bold (**text**) for character names and important terms.
+- Do NOT fabricate facts — if you're unsure, say so.
+- Only cover this season: do NOT spoil later seasons.
+- If you do not have spoiler information for this specific season, respond with ONLY: "## Season Not Found\nWe don't have spoiler information for this season yet." Do NOT substitute another show's spoiler.
+- Every section heading must start with ## exactly as shown above.`, season.Title, season.Season, season.Year, season.Overview, episodes.String(), buildCreditsPrompt(season))
+}
+
+// buildEpisodePrompt creates the prompt for a single episode breakdown
+func buildEpisodePrompt(episode SpoilerRequest) string {
+	return fmt.Sprintf(`You are an elite TV critic writing for a premium spoiler platform.
+
+Show Title: %s
+Episode: Season %d, Episode %d — %s
+Aired: %s
+Episode Overview: %s
+%s
+You MUST structure your response using EXACTLY these markdown headings. Do NOT skip any section.
+
+## Episode Overview
+Write a compelling 2-3 sentence non-spoiler summary of the episode.
+
+## ⚠️ SPOILER WARNING
+Write exactly: "Everything below contains major plot spoilers for this episode."
+
+## What Happens
+Walk through the episode's plot in order. 2-4 paragraphs.
+
+## Key Moments
+List exactly 3 pivotal scenes. Format each as:
+- **[Scene Title]** — One sentence description of what happens and why it matters.
+
+## The Ending
+Explain how the episode ends and any cliffhanger. 1-2 paragraphs.
+
+## Character Developments
+List the characters (up to 5) whose story moves forward. Format each as:
+- **[Character Name]** | [Actor Name] | [ALIVE/DEAD/UNKNOWN] | One sentence about what changes for them.
+When a cast list is given above, use those exact actor and character names.
+
+RULES:
+- Total length: 400-700 words.
+- Use bold (**text**) for character names and important terms.
+- Do NOT fabricate facts — if you're unsure, say so.
+- Only cover this episode and what came before it: do NOT spoil later episodes.
+- If you do not have spoiler information for this specific episode, respond with ONLY: "## Episode Not Found\nWe don't have spoiler information for this episode yet." Do NOT substitute another show's spoiler.
+- Every section heading must start with ## exactly as shown above.`, episode.Title, episode.Season, episode.Episode, episode.EpisodeTitle, episode.Year, episode.Overview, buildCreditsPrompt(episode))
+}
+
+// buildCreditsPrompt lists the director and top-billed cast, or returns an
+// empty string when no credits are known
+func buildCreditsPrompt(movie SpoilerRequest) string {
+	var b strings.Builder
+	if movie.Director != "" {
+		fmt.Fprintf(&b, "Director: %s\n", movie.Director)
+	}
+	if len(movie.Cast) > 0 {
+		b.WriteString("Main Cast:\n")
+		for _, member := range movie.Cast {
+			if member.Character != "" {
+				fmt.Fprintf(&b, "- %s as %s\n", member.Name, member.Character)
+			} else {
+				fmt.Fprintf(&b, "- %s\n", member.Name)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"spoiler_api/internal/models"
+)
+
+// minOutlineScenes is the fewest scenes an outline must have to be usable
+const minOutlineScenes = 3
+
+// OutlineStore persists the timestamped scene outlines behind progress
+// spoilers, so each movie's outline is generated once per language
+type OutlineStore interface {
+	// FindOutline returns the stored outline markdown in language, or "" (and no error) when there is none
+	FindOutline(ctx context.Context, tmdbID int, language string) (string, error)
+	// SaveOutline stores a movie's outline in language, replacing any stored copy
+	SaveOutline(ctx context.Context, tmdbID int, language, outline string) error
+	// DeleteOutline removes a movie's stored outlines in every language; deleting a missing outline is not an error
+	DeleteOutline(ctx context.Context, tmdbID int) error
+}
+
+// scenePattern matches an outline line: "- [42] **Scene Title** — What happens."
+var scenePattern = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])?\s*\[\s*(\d{1,3})\s*(?:min)?\s*\]\s*(.+)$`)
+
+// ParseOutline reads the scenes of an outline written by the outline prompt,
+// in order of their minute
+func ParseOutline(markdown string) ([]models.Scene, error) {
+	var scenes []models.Scene
+	for _, section := range splitMarkdownSections(markdown) {
+		if strings.HasSuffix(section.heading, "not found") {
+			return nil, ErrSpoilerUnavailable
+		}
+		for _, line := range strings.Split(section.content, "\n") {
+			match := scenePattern.FindStringSubmatch(line)
+			if match == nil {
+				continue
+			}
+			minute, _ := strconv.Atoi(match[1])
+			scene := models.Scene{Minute: minute, Description: strings.TrimSpace(match[2])}
+			if lead := boldLeadPattern.FindStringSubmatch(scene.Description); lead != nil {
+				scene.Title = strings.TrimSpace(lead[1])
+				scene.Description = strings.TrimSpace(separatorPattern.ReplaceAllString(strings.TrimSpace(lead[2]), ""))
+			}
+			scenes = append(scenes, scene)
+		}
+	}
+
+	if len(scenes) < minOutlineScenes {
+		return nil, fmt.Errorf("%w: outline has %d timestamped scenes, want at least %d", ErrInvalidSpoiler, len(scenes), minOutlineScenes)
+	}
+	sort.SliceStable(scenes, func(i, j int) bool { return scenes[i].Minute < scenes[j].Minute })
+	return scenes, nil
+}
+
+// ScenesUntil returns the scenes that start at or before minute
+func ScenesUntil(scenes []models.Scene, minute int) []models.Scene {
+	n := sort.Search(len(scenes), func(i int) bool { return scenes[i].Minute > minute })
+	return scenes[:n]
+}
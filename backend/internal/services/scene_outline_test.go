@@ -0,0 +1,96 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseOutline(t *testing.T) {
+	tests := []struct {
+		name       string
+		markdown   string
+		wantMinute []int
+		wantTitle  string
+		wantErr    error
+	}{
+		{
+			name: "scenes are sorted by minute",
+			markdown: `## Scene Outline
+- [45] **The Heist** — The crew breaks in.
+- [0] **Opening** — A quiet morning.
+1. [12 min] **The Call** — An offer arrives.
+Some commentary without a timestamp.
+`,
+			wantMinute: []int{0, 12, 45},
+			wantTitle:  "Opening",
+		},
+		{
+			name:       "untitled scenes",
+			markdown:   "## Scene Outline\n- [5] A quiet morning.\n- [10] An offer arrives.\n- [20] The crew breaks in.\n",
+			wantMinute: []int{5, 10, 20},
+		},
+		{
+			name:     "too few scenes",
+			markdown: "## Scene Outline\n- [0] **Opening** — A quiet morning.\n- [10] **The Call** — An offer arrives.\n",
+			wantErr:  ErrInvalidSpoiler,
+		},
+		{
+			name:     "unknown movie",
+			markdown: "## Movie Not Found\nI have no information about this film.",
+			wantErr:  ErrSpoilerUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scenes, err := ParseOutline(tt.markdown)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(scenes) != len(tt.wantMinute) {
+				t.Fatalf("got %d scenes, want %d", len(scenes), len(tt.wantMinute))
+			}
+			for i, minute := range tt.wantMinute {
+				if scenes[i].Minute != minute {
+					t.Errorf("scene %d minute = %d, want %d", i, scenes[i].Minute, minute)
+				}
+			}
+			if scenes[0].Title != tt.wantTitle {
+				t.Errorf("first scene title = %q, want %q", scenes[0].Title, tt.wantTitle)
+			}
+			if tt.wantTitle != "" && scenes[0].Description != "A quiet morning." {
+				t.Errorf("first scene description = %q, want the text after the title", scenes[0].Description)
+			}
+		})
+	}
+}
+
+func TestScenesUntil(t *testing.T) {
+	scenes, err := ParseOutline("## Scene Outline\n- [0] Opening.\n- [30] Middle.\n- [30] Also middle.\n- [90] End.\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		minute int
+		want   int
+	}{
+		{minute: 0, want: 1},
+		{minute: 29, want: 1},
+		{minute: 30, want: 3},
+		{minute: 89, want: 3},
+		{minute: 90, want: 4},
+		{minute: 200, want: 4},
+	}
+	for _, tt := range tests {
+		if got := ScenesUntil(scenes, tt.minute); len(got) != tt.want {
+			t.Errorf("ScenesUntil(%d) = %d scenes, want %d", tt.minute, len(got), tt.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"spoiler_api/internal/metrics"
+)
+
+// SpoilerCache is a thread-safe in-memory LRU cache for generated spoilers,
+// bounded by entry count and total size, with a per-entry TTL. It is shared
+// by the LLM providers so a spoiler is never generated twice while cached.
+type SpoilerCache struct {
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List // front = most recently used
+	bytes      int
+	maxEntries int
+	maxBytes   int
+	ttl        time.Duration
+
+	hits        int64
+	misses      int64
+	evictions   int64
+	expirations int64
+}
+
+// cacheEntry is a single cached spoiler
+type cacheEntry struct {
+	key       string
+	value     string
+	expiresAt time.Time
+}
+
+// CacheStats is a snapshot of the cache counters, exposed through /health
+type CacheStats struct {
+	Entries     int   `json:"entries"`
+	Bytes       int   `json:"bytes"`
+	MaxEntries  int   `json:"max_entries"`
+	MaxBytes    int   `json:"max_bytes"`
+	TTLSeconds  int64 `json:"ttl_seconds"`
+	Hits        int64 `json:"hits"`
+	Misses      int64 `json:"misses"`
+	Evictions   int64 `json:"evictions"`
+	Expirations int64 `json:"expirations"`
+}
+
+// NewSpoilerCache creates a cache holding at most maxEntries spoilers and
+// maxBytes of spoiler text. A zero limit or TTL disables that bound.
+func NewSpoilerCache(maxEntries, maxBytes int, ttl time.Duration) *SpoilerCache {
+	return &SpoilerCache{
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		ttl:        ttl,
+	}
+}
+
+// Get returns a cached spoiler if present and not expired. The providers
+// call it after the database missed, so a miss here means a generation.
+func (c *SpoilerCache) Get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, exists := c.entries[key]
+	if !exists {
+		c.misses++
+		metrics.SpoilerLookups.WithLabelValues("miss").Inc()
+		return "", false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		c.removeElement(element)
+		c.expirations++
+		c.misses++
+		metrics.SpoilerLookups.WithLabelValues("miss").Inc()
+		return "", false
+	}
+
+	c.order.MoveToFront(element)
+	c.hits++
+	metrics.SpoilerLookups.WithLabelValues("memory").Inc()
+	return entry.value, true
+}
+
+// Contains reports whether a spoiler is cached and not expired, without
+// counting as a lookup or refreshing its recency
+func (c *SpoilerCache) Contains(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, exists := c.entries[key]
+	if !exists {
+		return false
+	}
+	entry := element.Value.(*cacheEntry)
+	return entry.expiresAt.IsZero() || time.Now().Before(entry.expiresAt)
+}
+
+// Set stores a spoiler, evicting the least recently used entries to stay
+// within bounds. Spoilers larger than the byte limit are not cached.
+func (c *SpoilerCache) Set(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.maxBytes > 0 && len(value) > c.maxBytes {
+		return
+	}
+
+	if element, exists := c.entries[key]; exists {
+		c.removeElement(element)
+	}
+
+	entry := &cacheEntry{key: key, value: value}
+	if c.ttl > 0 {
+		entry.expiresAt = time.Now().Add(c.ttl)
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	c.bytes += len(value)
+
+	for (c.maxEntries > 0 && c.order.Len() > c.maxEntries) || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		c.removeElement(c.order.Back())
+		c.evictions++
+	}
+}
+
+// Evict removes a single entry and reports whether it was cached
+func (c *SpoilerCache) Evict(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, exists := c.entries[key]
+	if !exists {
+		return false
+	}
+	c.removeElement(element)
+	return true
+}
+
+// Clear removes every entry (useful for testing or admin operations)
+func (c *SpoilerCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+	c.bytes = 0
+}
+
+// Len returns the number of cached items
+func (c *SpoilerCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Stats returns a snapshot of the cache size and counters
+func (c *SpoilerCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return CacheStats{
+		Entries:     c.order.Len(),
+		Bytes:       c.bytes,
+		MaxEntries:  c.maxEntries,
+		MaxBytes:    c.maxBytes,
+		TTLSeconds:  int64(c.ttl / time.Second),
+		Hits:        c.hits,
+		Misses:      c.misses,
+		Evictions:   c.evictions,
+		Expirations: c.expirations,
+	}
+}
+
+// removeElement unlinks an entry; the caller must hold mu
+func (c *SpoilerCache) removeElement(element *list.Element) {
+	entry := element.Value.(*cacheEntry)
+	c.order.Remove(element)
+	delete(c.entries, entry.key)
+	c.bytes -= len(entry.value)
+}
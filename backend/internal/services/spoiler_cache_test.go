@@ -0,0 +1,143 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSpoilerCacheBounds(t *testing.T) {
+	// Each op sets key to value, or looks key up when value is empty
+	type op struct {
+		key   string
+		value string
+	}
+
+	tests := []struct {
+		name          string
+		maxEntries    int
+		maxBytes      int
+		ops           []op
+		wantKeys      []string
+		wantMissing   []string
+		wantBytes     int
+		wantEvictions int64
+	}{
+		{
+			name:          "entry limit evicts the least recently used",
+			maxEntries:    2,
+			ops:           []op{{"a", "1"}, {"b", "2"}, {key: "a"}, {"c", "3"}},
+			wantKeys:      []string{"a", "c"},
+			wantMissing:   []string{"b"},
+			wantBytes:     2,
+			wantEvictions: 1,
+		},
+		{
+			name:          "byte limit evicts until the new entry fits",
+			maxBytes:      10,
+			ops:           []op{{"a", "12345"}, {"b", "12345"}, {"c", "123"}},
+			wantKeys:      []string{"b", "c"},
+			wantMissing:   []string{"a"},
+			wantBytes:     8,
+			wantEvictions: 1,
+		},
+		{
+			name:          "byte limit can evict several entries",
+			maxBytes:      10,
+			ops:           []op{{"a", "1234"}, {"b", "1234"}, {"c", "123456789"}},
+			wantKeys:      []string{"c"},
+			wantMissing:   []string{"a", "b"},
+			wantBytes:     9,
+			wantEvictions: 2,
+		},
+		{
+			name:        "oversized spoilers are not cached",
+			maxBytes:    4,
+			ops:         []op{{"a", "1234"}, {"b", "12345"}},
+			wantKeys:    []string{"a"},
+			wantMissing: []string{"b"},
+			wantBytes:   4,
+		},
+		{
+			name:       "replacing an entry updates its size",
+			maxEntries: 2,
+			ops:        []op{{"a", "123"}, {"b", "1"}, {"a", "12345"}},
+			wantKeys:   []string{"a", "b"},
+			wantBytes:  6,
+		},
+		{
+			name:      "zero limits are unbounded",
+			ops:       []op{{"a", "1"}, {"b", "2"}, {"c", "3"}},
+			wantKeys:  []string{"a", "b", "c"},
+			wantBytes: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewSpoilerCache(tt.maxEntries, tt.maxBytes, 0)
+			for _, op := range tt.ops {
+				if op.value == "" {
+					cache.Get(op.key)
+				} else {
+					cache.Set(op.key, op.value)
+				}
+			}
+
+			for _, key := range tt.wantKeys {
+				if !cache.Contains(key) {
+					t.Errorf("%q was evicted", key)
+				}
+			}
+			for _, key := range tt.wantMissing {
+				if cache.Contains(key) {
+					t.Errorf("%q is still cached", key)
+				}
+			}
+			stats := cache.Stats()
+			if stats.Entries != len(tt.wantKeys) || stats.Bytes != tt.wantBytes || stats.Evictions != tt.wantEvictions {
+				t.Errorf("entries, bytes, evictions = %d, %d, %d; want %d, %d, %d",
+					stats.Entries, stats.Bytes, stats.Evictions, len(tt.wantKeys), tt.wantBytes, tt.wantEvictions)
+			}
+		})
+	}
+}
+
+func TestSpoilerCacheExpiry(t *testing.T) {
+	cache := NewSpoilerCache(0, 0, 20*time.Millisecond)
+	cache.Set("27205", "spoiler")
+
+	if value, ok := cache.Get("27205"); !ok || value != "spoiler" {
+		t.Fatalf("Get before expiry = %q, %v", value, ok)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if cache.Contains("27205") {
+		t.Error("Contains reports an expired entry")
+	}
+	if _, ok := cache.Get("27205"); ok {
+		t.Error("Get returned an expired entry")
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Expirations != 1 || stats.Entries != 0 || stats.Bytes != 0 {
+		t.Errorf("stats after expiry = %+v", stats)
+	}
+}
+
+func TestSpoilerCacheEvictAndClear(t *testing.T) {
+	cache := NewSpoilerCache(0, 0, 0)
+	cache.Set("27205", "spoiler")
+	cache.Set("603", "spoiler")
+
+	if !cache.Evict("27205") || cache.Evict("27205") {
+		t.Error("Evict should report true once and then false")
+	}
+	if stats := cache.Stats(); stats.Entries != 1 || stats.Bytes != len("spoiler") || stats.Evictions != 0 {
+		t.Errorf("stats after Evict = %+v", stats)
+	}
+
+	cache.Clear()
+	if cache.Len() != 0 || cache.Stats().Bytes != 0 {
+		t.Errorf("cache not empty after Clear: %+v", cache.Stats())
+	}
+}
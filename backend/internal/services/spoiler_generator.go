@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"spoiler_api/internal/models"
+)
+
+// ErrGenerationRefused means the model returned no spoiler, typically
+// because the prompt or output was blocked by its safety filters
+var ErrGenerationRefused = errors.New("the model declined to write a spoiler for this movie")
+
+// SpoilerGenerator produces a markdown spoiler for a movie or TV show.
+// Implementations must follow the section layout of buildSpoilerPrompt so
+// ParseSpoiler can read their movie spoilers.
+type SpoilerGenerator interface {
+	GenerateSpoiler(ctx context.Context, movie SpoilerRequest) (string, error)
+}
+
+// SpoilerKind selects what a spoiler covers, and so which prompt is used
+type SpoilerKind int
+
+const (
+	// KindMovie is a full movie breakdown
+	KindMovie SpoilerKind = iota
+	// KindSeries covers a whole TV show, season by season
+	KindSeries
+	// KindSeason recaps one season of a show
+	KindSeason
+	// KindEpisode breaks down a single episode
+	KindEpisode
+	// KindOutline is a movie's timestamped scene outline, sliced for progress spoilers
+	KindOutline
+)
+
+// SpoilerRequest describes the movie or TV show a spoiler is generated for
+type SpoilerRequest struct {
+	Kind     SpoilerKind
+	Level    models.SpoilerLevel // for movies; empty means full
+	TMDBID   int
+	Title    string
+	Year     string
+	Overview string
+
+	// Language is the code of the language to write in; empty means DefaultLanguage
+	Language string
+
+	// Optional credits from the TMDB details endpoint, used so character
+	// fates name the real actors
+	Director string
+	Cast     []models.CastMember
+
+	// Runtime is the movie's length in minutes, which an outline spans
+	Runtime int
+
+	// For TV spoilers Title is the show's name and Overview that of the
+	// season or episode. EpisodeTitles lists a season's episodes in order.
+	Season        int
+	Episode       int
+	EpisodeTitle  string
+	EpisodeTitles []string
+}
+
+// CacheKey returns the key generated spoilers are cached under. Movie keys
+// are the TMDB movie ID so remakes and same-name films never share an entry,
+// followed by the level for teaser and partial spoilers; TV keys are prefixed
+// and name the season and episode, so every season and episode is cached
+// separately. Spoilers in other languages than DefaultLanguage end with the
+// language code.
+func (r SpoilerRequest) CacheKey() string {
+	key := r.kindCacheKey()
+	if r.Language != "" && r.Language != DefaultLanguage {
+		key += ":" + r.Language
+	}
+	return key
+}
+
+// kindCacheKey returns the cache key for the kind of spoiler, without the language
+func (r SpoilerRequest) kindCacheKey() string {
+	switch r.Kind {
+	case KindSeries:
+		return fmt.Sprintf("tv:%d", r.TMDBID)
+	case KindSeason:
+		return fmt.Sprintf("tv:%d:s%d", r.TMDBID, r.Season)
+	case KindEpisode:
+		return fmt.Sprintf("tv:%d:s%de%d", r.TMDBID, r.Season, r.Episode)
+	case KindOutline:
+		return fmt.Sprintf("%d:outline", r.TMDBID)
+	default:
+		if r.Level != "" && r.Level != models.SpoilerFull {
+			return fmt.Sprintf("%d:%s", r.TMDBID, r.Level)
+		}
+		return strconv.Itoa(r.TMDBID)
+	}
+}
+
+// SpoilerStreamer is implemented by generators that can deliver a spoiler
+// incrementally. onChunk is called with each new piece of text in order; if it
+// returns an error the stream is aborted. The complete text is returned.
+type SpoilerStreamer interface {
+	StreamSpoiler(ctx context.Context, movie SpoilerRequest, onChunk func(chunk string) error) (string, error)
+}
+
+// PlaceholderGenerator is implemented by generators whose output only stands
+// in for a real spoiler. It is served but never written to the database,
+// where it would outlive the development setup that produced it.
+type PlaceholderGenerator interface {
+	Placeholder() bool
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"testing"
+
+	"spoiler_api/internal/models"
+)
+
+func TestSpoilerRequestCacheKey(t *testing.T) {
+	inception := SpoilerRequest{TMDBID: 27205, Title: "Inception", Year: "2010"}
+	if got := inception.CacheKey(); got != "27205" {
+		t.Errorf("CacheKey = %q, want the TMDB ID", got)
+	}
+
+	// Remakes share a title and same-name films can share a year too
+	tests := []struct {
+		name  string
+		a, b  SpoilerRequest
+		equal bool
+	}{
+		{
+			name:  "remake",
+			a:     SpoilerRequest{TMDBID: 10016, Title: "Total Recall", Year: "1990"},
+			b:     SpoilerRequest{TMDBID: 64635, Title: "Total Recall", Year: "2012"},
+			equal: false,
+		},
+		{
+			name:  "same title and year",
+			a:     SpoilerRequest{TMDBID: 9659, Title: "Crash", Year: "2004"},
+			b:     SpoilerRequest{TMDBID: 1640, Title: "Crash", Year: "2004"},
+			equal: false,
+		},
+		{
+			name:  "retitled movie",
+			a:     SpoilerRequest{TMDBID: 27205, Title: "Inception", Year: "2010"},
+			b:     SpoilerRequest{TMDBID: 27205, Title: "El origen", Year: "2010"},
+			equal: true,
+		},
+	}
+	for _, tt := range tests {
+		if equal := tt.a.CacheKey() == tt.b.CacheKey(); equal != tt.equal {
+			t.Errorf("%s: keys %q and %q shared = %v, want %v", tt.name, tt.a.CacheKey(), tt.b.CacheKey(), equal, tt.equal)
+		}
+	}
+}
+
+func TestSpoilerRequestCacheKeyLevels(t *testing.T) {
+	tests := []struct {
+		level models.SpoilerLevel
+		want  string
+	}{
+		{"", "27205"},
+		{models.SpoilerFull, "27205"},
+		{models.SpoilerPartial, "27205:partial"},
+		{models.SpoilerTeaser, "27205:teaser"},
+	}
+	for _, tt := range tests {
+		if got := (SpoilerRequest{TMDBID: 27205, Level: tt.level}).CacheKey(); got != tt.want {
+			t.Errorf("CacheKey at level %q = %q, want %q", tt.level, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"spoiler_api/internal/models"
+)
+
+// levelStorySections lists the story sections each level below full may reveal
+var levelStorySections = map[models.SpoilerLevel][]string{
+	models.SpoilerTeaser:  {"beginning"},
+	models.SpoilerPartial: {"beginning", "turning-point"},
+}
+
+// levelReveals reports whether a spoiler at level may show a story section
+func levelReveals(level models.SpoilerLevel, sectionID string) bool {
+	allowed, limited := levelStorySections[level]
+	return !limited || slices.Contains(allowed, sectionID)
+}
+
+// CutSpoiler cuts a full structured spoiler down to what level may reveal:
+// the overview and the story sections up to the level's last one. Key
+// moments, character fates and the interpretation sections span the whole
+// film, so they are dropped.
+func CutSpoiler(full *models.StructuredSpoiler, level models.SpoilerLevel) *models.StructuredSpoiler {
+	if _, limited := levelStorySections[level]; !limited {
+		return full
+	}
+
+	cut := &models.StructuredSpoiler{Overview: full.Overview}
+	for _, section := range full.StorySections {
+		if levelReveals(level, section.ID) {
+			cut.StorySections = append(cut.StorySections, section)
+		}
+	}
+	return cut
+}
+
+// RenderSpoiler writes the overview, story sections and key moments of a
+// structured spoiler back out as markdown, with the prompt's headings
+func RenderSpoiler(spoiler *models.StructuredSpoiler) string {
+	var b strings.Builder
+	if spoiler.Overview != "" {
+		fmt.Fprintf(&b, "## Movie Overview\n%s\n\n", spoiler.Overview)
+	}
+	for _, section := range spoiler.StorySections {
+		fmt.Fprintf(&b, "## %s\n%s\n\n", section.Title, section.Content)
+	}
+	if len(spoiler.KeyMoments) > 0 {
+		b.WriteString("## Key Moments\n")
+		for _, moment := range spoiler.KeyMoments {
+			fmt.Fprintf(&b, "- **%s** — %s\n", moment.Title, moment.Description)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"spoiler_api/internal/models"
+)
+
+// ErrSpoilerUnavailable is returned when the model reported it has no spoiler for the movie
+var ErrSpoilerUnavailable = errors.New("no spoiler information available for this movie")
+
+// ErrInvalidSpoiler is returned when model output does not follow the layout the prompt asked for
+var ErrInvalidSpoiler = errors.New("the model returned a malformed spoiler")
+
+// storySectionDefs lists the chronological sections in the order they are shown
+var storySectionDefs = []struct {
+	id      string
+	title   string
+	aliases []string
+}{
+	{"beginning", "The Beginning", []string{"the beginning", "beginning", "the setup", "setup"}},
+	{"turning-point", "Major Turning Point", []string{"major turning point", "turning point", "the turning point"}},
+	{"climax", "The Climax", []string{"the climax", "climax"}},
+	{"ending", "Ending Explained", []string{"ending explained", "the ending", "ending", "the ending explained"}},
+	{"post-credit", "Post-Credit Scene", []string{"post-credit scene", "post-credits scene", "post-credit scenes", "post-credits scenes", "post credit scene"}},
+}
+
+var (
+	headingPattern    = regexp.MustCompile(`^\s{0,3}(#{1,4})\s*(.+?)\s*#*\s*$`)
+	listItemPattern   = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s+(.*)$`)
+	boldLeadPattern   = regexp.MustCompile(`^\*\*\[?(.+?)\]?\*\*\s*(.*)$`)
+	separatorPattern  = regexp.MustCompile(`^(?:[—–:-]+|\|)\s*`)
+	nonHeadingPattern = regexp.MustCompile(`[^\p{L}\p{N}\s-]`)
+)
+
+// markdownSection is a heading and the text that follows it up to the next heading
+type markdownSection struct {
+	heading string
+	content string
+}
+
+// ParseSpoiler converts the markdown produced by the spoiler prompt into a
+// validated StructuredSpoiler. Headings are matched loosely (case, emoji,
+// punctuation and common synonyms are ignored) so small formatting drift in
+// the model output does not break consumers.
+func ParseSpoiler(markdown string) (*models.StructuredSpoiler, error) {
+	return ParseSpoilerLevel(markdown, models.SpoilerFull)
+}
+
+// ParseSpoilerLevel parses a spoiler written at level. Story sections the
+// level may not reveal are dropped, in case the model wrote them anyway.
+func ParseSpoilerLevel(markdown string, level models.SpoilerLevel) (*models.StructuredSpoiler, error) {
+	sections := splitMarkdownSections(markdown)
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("%w: spoiler has no markdown sections", ErrInvalidSpoiler)
+	}
+
+	byHeading := make(map[string]string)
+	for _, section := range sections {
+		if _, exists := byHeading[section.heading]; !exists {
+			byHeading[section.heading] = section.content
+		}
+	}
+
+	if _, notFound := byHeading["movie not found"]; notFound {
+		return nil, ErrSpoilerUnavailable
+	}
+
+	spoiler := &models.StructuredSpoiler{
+		Overview:            lookupSection(byHeading, "movie overview", "overview", "summary"),
+		KeyMoments:          parseKeyMoments(lookupSection(byHeading, "key moments", "key scenes", "pivotal moments")),
+		CharacterFates:      parseCharacterFates(lookupSection(byHeading, "character fates", "characters")),
+		Symbolism:           parseItems(lookupSection(byHeading, "symbolism", "symbols")),
+		HiddenClues:         parseItems(lookupSection(byHeading, "hidden clues", "hidden details", "easter eggs")),
+		FanTheories:         parseItems(lookupSection(byHeading, "fan theories", "theories")),
+		UnansweredQuestions: parseItems(lookupSection(byHeading, "unanswered questions", "open questions")),
+	}
+
+	for _, def := range storySectionDefs {
+		content := lookupSection(byHeading, def.aliases...)
+		if content == "" || !levelReveals(level, def.id) {
+			continue
+		}
+		spoiler.StorySections = append(spoiler.StorySections, models.StorySection{
+			ID:      def.id,
+			Title:   def.title,
+			Content: content,
+		})
+	}
+
+	if err := validateSpoiler(spoiler, level); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSpoiler, err)
+	}
+
+	return spoiler, nil
+}
+
+// ValidateTVSpoiler checks a show, season or episode spoiler: it must be
+// written in markdown sections, and not be the prompt's "Not Found" reply
+func ValidateTVSpoiler(markdown string) error {
+	sections := splitMarkdownSections(markdown)
+	if len(sections) == 0 {
+		return fmt.Errorf("%w: spoiler has no markdown sections", ErrInvalidSpoiler)
+	}
+	for _, section := range sections {
+		if strings.HasSuffix(section.heading, "not found") {
+			return fmt.Errorf("%w: no spoiler information available", ErrShowNotFound)
+		}
+	}
+	return nil
+}
+
+// ValidateSpoiler checks that a structured spoiler has the minimum content
+// clients rely on and that every enumerated field holds a known value
+func ValidateSpoiler(spoiler *models.StructuredSpoiler) error {
+	return validateSpoiler(spoiler, models.SpoilerFull)
+}
+
+// validateSpoiler validates a spoiler written at level: full spoilers must
+// reach the ending, shorter ones must at least cover the beginning
+func validateSpoiler(spoiler *models.StructuredSpoiler, level models.SpoilerLevel) error {
+	if spoiler == nil {
+		return fmt.Errorf("spoiler is empty")
+	}
+	if len(spoiler.StorySections) == 0 {
+		return fmt.Errorf("spoiler is missing story sections")
+	}
+
+	required := "ending"
+	if level != models.SpoilerFull {
+		required = "beginning"
+	}
+	hasRequired := false
+	for _, section := range spoiler.StorySections {
+		if section.ID == required {
+			hasRequired = true
+		}
+	}
+	if !hasRequired {
+		return fmt.Errorf("spoiler is missing the %s section", required)
+	}
+
+	for i, fate := range spoiler.CharacterFates {
+		if fate.Name == "" {
+			return fmt.Errorf("character fate %d has no name", i+1)
+		}
+		switch fate.Status {
+		case models.CharacterAlive, models.CharacterDead, models.CharacterUnknown:
+		default:
+			return fmt.Errorf("character %q has invalid status %q", fate.Name, fate.Status)
+		}
+	}
+
+	return nil
+}
+
+// splitMarkdownSections splits markdown into sections keyed by normalized heading
+func splitMarkdownSections(markdown string) []markdownSection {
+	var sections []markdownSection
+	var current *markdownSection
+	var body []string
+
+	flush := func() {
+		if current != nil {
+			current.content = strings.TrimSpace(strings.Join(body, "\n"))
+			sections = append(sections, *current)
+		}
+		body = nil
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n") {
+		if match := headingPattern.FindStringSubmatch(line); match != nil {
+			flush()
+			current = &markdownSection{heading: normalizeHeading(match[2])}
+			continue
+		}
+		body = append(body, line)
+	}
+	flush()
+
+	return sections
+}
+
+// normalizeHeading lowercases a heading and strips emoji, markup and punctuation
+func normalizeHeading(heading string) string {
+	heading = strings.ReplaceAll(heading, "*", "")
+	heading = nonHeadingPattern.ReplaceAllString(heading, "")
+	return strings.ToLower(strings.Join(strings.Fields(heading), " "))
+}
+
+// lookupSection returns the content of the first heading that matches any alias
+func lookupSection(byHeading map[string]string, aliases ...string) string {
+	for _, alias := range aliases {
+		if content, exists := byHeading[alias]; exists {
+			return content
+		}
+	}
+	return ""
+}
+
+// listItems returns the text of each markdown list item in a section
+func listItems(content string) []string {
+	var items []string
+	for _, line := range strings.Split(content, "\n") {
+		if match := listItemPattern.FindStringSubmatch(line); match != nil {
+			if item := strings.TrimSpace(match[1]); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// parseItems returns list items if the section is a list, otherwise its paragraphs
+func parseItems(content string) []string {
+	if content == "" {
+		return nil
+	}
+	if items := listItems(content); len(items) > 0 {
+		return items
+	}
+
+	var paragraphs []string
+	for _, paragraph := range strings.Split(content, "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+	return paragraphs
+}
+
+// parseKeyMoments parses lines of the form "- **Title** — description"
+func parseKeyMoments(content string) []models.KeyMoment {
+	var moments []models.KeyMoment
+	for _, item := range listItems(content) {
+		match := boldLeadPattern.FindStringSubmatch(item)
+		if match == nil {
+			continue
+		}
+		moments = append(moments, models.KeyMoment{
+			Title:       strings.TrimSpace(match[1]),
+			Description: strings.TrimSpace(separatorPattern.ReplaceAllString(match[2], "")),
+		})
+	}
+	return moments
+}
+
+// parseCharacterFates parses lines of the form
+// "- **Name** | Actor | STATUS | summary", falling back to "- **Name** — summary"
+func parseCharacterFates(content string) []models.CharacterFate {
+	var fates []models.CharacterFate
+	for _, item := range listItems(content) {
+		parts := strings.Split(item, "|")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+
+		name := strings.Trim(parts[0], "*[] ")
+		if name == "" {
+			continue
+		}
+
+		if len(parts) >= 4 {
+			fates = append(fates, models.CharacterFate{
+				Name:    name,
+				Actor:   strings.Trim(parts[1], "*[] "),
+				Status:  parseCharacterStatus(parts[2]),
+				Summary: strings.Join(parts[3:], " | "),
+			})
+			continue
+		}
+
+		match := boldLeadPattern.FindStringSubmatch(item)
+		if match == nil {
+			continue
+		}
+		fates = append(fates, models.CharacterFate{
+			Name:    strings.TrimSpace(match[1]),
+			Status:  models.CharacterUnknown,
+			Summary: strings.TrimSpace(separatorPattern.ReplaceAllString(match[2], "")),
+		})
+	}
+	return fates
+}
+
+// parseCharacterStatus maps free-form status text onto the ALIVE/DEAD/UNKNOWN enum
+func parseCharacterStatus(status string) models.CharacterStatus {
+	status = strings.ToUpper(strings.Trim(status, "*[] "))
+	switch {
+	case strings.Contains(status, "DEAD"), strings.Contains(status, "DECEASED"), strings.Contains(status, "KILLED"):
+		return models.CharacterDead
+	case strings.Contains(status, "ALIVE"), strings.Contains(status, "SURVIVES"):
+		return models.CharacterAlive
+	default:
+		return models.CharacterUnknown
+	}
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"spoiler_api/internal/models"
+)
+
+const fullSpoiler = `## Movie Overview
+A thief enters dreams.
+
+## ⚠️ SPOILER WARNING
+Everything below contains spoilers.
+
+## **The Beginning**
+Cobb takes one last job.
+
+## Major Turning Point
+The team goes three dreams deep.
+
+## 🎬 The Climax
+The kicks are synchronized.
+
+## Ending Explained
+The top keeps spinning.
+
+## Key Moments
+- **The Hallway Fight** — Arthur fights without gravity.
+- not a key moment
+
+## Character Fates
+- **Cobb** | Leonardo DiCaprio | ALIVE | Goes home.
+- **Mal** | Marion Cotillard | DEAD | A projection.
+
+## Fan Theories
+- It was all a dream.
+- The ring is the totem.
+`
+
+func TestParseSpoilerLevel(t *testing.T) {
+	tests := []struct {
+		name         string
+		markdown     string
+		level        models.SpoilerLevel
+		wantSections []string
+		wantErr      error  // matched with errors.Is
+		wantErrText  string // a substring of the error
+	}{
+		{
+			name:         "full spoiler",
+			markdown:     fullSpoiler,
+			level:        models.SpoilerFull,
+			wantSections: []string{"beginning", "turning-point", "climax", "ending"},
+		},
+		{
+			name:         "partial drops the climax and ending",
+			markdown:     fullSpoiler,
+			level:        models.SpoilerPartial,
+			wantSections: []string{"beginning", "turning-point"},
+		},
+		{
+			name:         "teaser keeps only the beginning",
+			markdown:     fullSpoiler,
+			level:        models.SpoilerTeaser,
+			wantSections: []string{"beginning"},
+		},
+		{
+			name:         "windows line endings and heading synonyms",
+			markdown:     "# Overview\r\nA film.\r\n### The Setup\r\nIt starts.\r\n### The Ending\r\nIt ends.\r\n",
+			level:        models.SpoilerFull,
+			wantSections: []string{"beginning", "ending"},
+		},
+		{
+			name:     "movie not found",
+			markdown: "## Movie Not Found\nI have no information about this film.",
+			level:    models.SpoilerFull,
+			wantErr:  ErrSpoilerUnavailable,
+		},
+		{
+			name:        "no sections",
+			markdown:    "Just a paragraph of text.",
+			level:       models.SpoilerFull,
+			wantErrText: "no markdown sections",
+		},
+		{
+			name:        "full spoiler without an ending",
+			markdown:    "## The Beginning\nIt starts.\n## The Climax\nA fight.",
+			level:       models.SpoilerFull,
+			wantErrText: "missing the ending section",
+		},
+		{
+			name:        "teaser without a beginning",
+			markdown:    "## Movie Overview\nA film.",
+			level:       models.SpoilerTeaser,
+			wantErrText: "missing story sections",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spoiler, err := ParseSpoilerLevel(tt.markdown, tt.level)
+			if tt.wantErr != nil || tt.wantErrText != "" {
+				if err == nil {
+					t.Fatalf("got no error, want %v%s", tt.wantErr, tt.wantErrText)
+				}
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantErrText) {
+					t.Fatalf("got error %q, want it to contain %q", err, tt.wantErrText)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var sections []string
+			for _, section := range spoiler.StorySections {
+				sections = append(sections, section.ID)
+			}
+			if strings.Join(sections, ",") != strings.Join(tt.wantSections, ",") {
+				t.Fatalf("got sections %v, want %v", sections, tt.wantSections)
+			}
+		})
+	}
+}
+
+func TestParseSpoilerFields(t *testing.T) {
+	spoiler, err := ParseSpoiler(fullSpoiler)
+	if err != nil {
+		t.Fatalf("ParseSpoiler: %v", err)
+	}
+
+	if spoiler.Overview != "A thief enters dreams." {
+		t.Errorf("overview = %q", spoiler.Overview)
+	}
+	if len(spoiler.KeyMoments) != 1 || spoiler.KeyMoments[0].Title != "The Hallway Fight" || spoiler.KeyMoments[0].Description != "Arthur fights without gravity." {
+		t.Errorf("key moments = %+v", spoiler.KeyMoments)
+	}
+	if len(spoiler.CharacterFates) != 2 || spoiler.CharacterFates[1].Name != "Mal" || spoiler.CharacterFates[1].Status != models.CharacterDead {
+		t.Errorf("character fates = %+v", spoiler.CharacterFates)
+	}
+	if len(spoiler.FanTheories) != 2 {
+		t.Errorf("fan theories = %v", spoiler.FanTheories)
+	}
+}
+
+func TestParseCharacterStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		want   models.CharacterStatus
+	}{
+		{"ALIVE", models.CharacterAlive},
+		{"**Survives**", models.CharacterAlive},
+		{"[DEAD]", models.CharacterDead},
+		{"killed in the climax", models.CharacterDead},
+		{"asleep", models.CharacterUnknown},
+		{"", models.CharacterUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := parseCharacterStatus(tt.status); got != tt.want {
+			t.Errorf("parseCharacterStatus(%q) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestCutSpoilerRoundTrip(t *testing.T) {
+	full, err := ParseSpoiler(fullSpoiler)
+	if err != nil {
+		t.Fatalf("ParseSpoiler: %v", err)
+	}
+
+	for _, level := range []models.SpoilerLevel{models.SpoilerTeaser, models.SpoilerPartial} {
+		t.Run(string(level), func(t *testing.T) {
+			cut := CutSpoiler(full, level)
+			if cut.CharacterFates != nil {
+				t.Errorf("%s spoiler keeps character fates", level)
+			}
+			if _, err := ParseSpoilerLevel(RenderSpoiler(cut), level); err != nil {
+				t.Errorf("rendered %s spoiler does not parse: %v", level, err)
+			}
+		})
+	}
+}
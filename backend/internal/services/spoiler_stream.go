@@ -0,0 +1,175 @@
+package services
+
+import (
+	"strings"
+	"sync"
+
+	"spoiler_api/internal/models"
+)
+
+// SectionTracker splits streamed spoiler text into chunks tagged with the
+// markdown section currently being written. A line that might be a heading is
+// held back until it is complete so that heading text is never attributed to
+// the previous section.
+type SectionTracker struct {
+	section     string
+	heading     string
+	pending     string
+	atLineStart bool
+}
+
+// NewSectionTracker creates a tracker positioned before the first heading
+func NewSectionTracker() *SectionTracker {
+	return &SectionTracker{section: "preamble", atLineStart: true}
+}
+
+// Write consumes the next piece of streamed text and returns the chunks that
+// can be emitted so far
+func (t *SectionTracker) Write(text string) []models.SpoilerChunk {
+	var chunks []models.SpoilerChunk
+	data := t.pending + text
+	t.pending = ""
+
+	for data != "" {
+		newline := strings.IndexByte(data, '\n')
+		if newline == -1 {
+			if t.atLineStart && mayBeHeading(data) {
+				t.pending = data
+			} else {
+				chunks = t.appendChunk(chunks, data)
+				t.atLineStart = false
+			}
+			break
+		}
+
+		line := data[:newline+1]
+		data = data[newline+1:]
+
+		if t.atLineStart {
+			t.enterSection(line)
+		}
+		chunks = t.appendChunk(chunks, line)
+		t.atLineStart = true
+	}
+
+	return chunks
+}
+
+// Flush returns any text still held back at the end of the stream
+func (t *SectionTracker) Flush() []models.SpoilerChunk {
+	if t.pending == "" {
+		return nil
+	}
+	line := t.pending
+	t.pending = ""
+	if t.atLineStart {
+		t.enterSection(line)
+	}
+	return t.appendChunk(nil, line)
+}
+
+// enterSection switches the current section if line is a markdown heading
+func (t *SectionTracker) enterSection(line string) {
+	match := headingPattern.FindStringSubmatch(strings.TrimRight(line, "\r\n"))
+	if match == nil {
+		return
+	}
+	t.heading = strings.TrimSpace(strings.ReplaceAll(match[2], "*", ""))
+	t.section = sectionID(t.heading)
+}
+
+// appendChunk adds text to the last chunk when it belongs to the same section
+func (t *SectionTracker) appendChunk(chunks []models.SpoilerChunk, text string) []models.SpoilerChunk {
+	if n := len(chunks); n > 0 && chunks[n-1].Section == t.section {
+		chunks[n-1].Text += text
+		return chunks
+	}
+	return append(chunks, models.SpoilerChunk{
+		Section: t.section,
+		Heading: t.heading,
+		Text:    text,
+	})
+}
+
+// mayBeHeading reports whether an incomplete line could still become a heading
+func mayBeHeading(partial string) bool {
+	trimmed := strings.TrimLeft(partial, " \t")
+	return trimmed == "" || strings.HasPrefix(trimmed, "#")
+}
+
+// sectionID maps a heading to a stable identifier, reusing the story section
+// IDs from ParseSpoiler and slugifying everything else
+func sectionID(heading string) string {
+	normalized := normalizeHeading(heading)
+	if normalized == "movie overview" {
+		return "overview"
+	}
+	for _, def := range storySectionDefs {
+		for _, alias := range def.aliases {
+			if normalized == alias {
+				return def.id
+			}
+		}
+	}
+	return strings.ReplaceAll(normalized, " ", "-")
+}
+
+// SpoilerBroadcast fans one streamed spoiler out to every request following
+// it. Readers keep their own offset, so one that joins late first receives the
+// text written so far, and a slow reader never holds up the writer.
+type SpoilerBroadcast struct {
+	mu      sync.Mutex
+	text    string
+	changed chan struct{}
+	closed  bool
+}
+
+// NewSpoilerBroadcast creates an open, empty broadcast
+func NewSpoilerBroadcast() *SpoilerBroadcast {
+	return &SpoilerBroadcast{changed: make(chan struct{})}
+}
+
+// Write appends streamed text and wakes every reader
+func (b *SpoilerBroadcast) Write(text string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed || text == "" {
+		return
+	}
+	b.text += text
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// Close ends the broadcast and wakes every reader; later writes are ignored
+func (b *SpoilerBroadcast) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	close(b.changed)
+}
+
+// Closed reports whether the broadcast has ended
+func (b *SpoilerBroadcast) Closed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+// Read returns the text written after offset bytes and a channel that is
+// closed once more is written. The channel is nil after the broadcast ends.
+func (b *SpoilerBroadcast) Read(offset int) (string, <-chan struct{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	text := b.text[min(offset, len(b.text)):]
+	if b.closed {
+		return text, nil
+	}
+	return text, b.changed
+}
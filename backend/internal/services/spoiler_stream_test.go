@@ -0,0 +1,145 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"spoiler_api/internal/models"
+)
+
+// streamSections feeds pieces through a SectionTracker and merges the
+// resulting chunks per section
+func streamSections(pieces []string) []models.SpoilerChunk {
+	tracker := NewSectionTracker()
+	var chunks []models.SpoilerChunk
+	for _, piece := range pieces {
+		chunks = append(chunks, tracker.Write(piece)...)
+	}
+	chunks = append(chunks, tracker.Flush()...)
+
+	var merged []models.SpoilerChunk
+	for _, chunk := range chunks {
+		if n := len(merged); n > 0 && merged[n-1].Section == chunk.Section {
+			merged[n-1].Text += chunk.Text
+			continue
+		}
+		merged = append(merged, chunk)
+	}
+	return merged
+}
+
+// splitEvery cuts text into pieces of n bytes
+func splitEvery(text string, n int) []string {
+	var pieces []string
+	for len(text) > n {
+		pieces = append(pieces, text[:n])
+		text = text[n:]
+	}
+	return append(pieces, text)
+}
+
+func TestSectionTracker(t *testing.T) {
+	const spoiler = "Here you go.\n## Movie Overview\nA thief enters dreams.\n## The **Beginning**\nCobb takes a job.\n# Ending Explained\nThe top spins."
+	want := []models.SpoilerChunk{
+		{Section: "preamble", Text: "Here you go.\n"},
+		{Section: "overview", Heading: "Movie Overview", Text: "## Movie Overview\nA thief enters dreams.\n"},
+		{Section: "beginning", Heading: "The Beginning", Text: "## The **Beginning**\nCobb takes a job.\n"},
+		{Section: "ending", Heading: "Ending Explained", Text: "# Ending Explained\nThe top spins."},
+	}
+
+	tests := []struct {
+		name   string
+		pieces []string
+		want   []models.SpoilerChunk
+	}{
+		{name: "whole text", pieces: []string{spoiler}, want: want},
+		{name: "one byte at a time", pieces: splitEvery(spoiler, 1), want: want},
+		{name: "three bytes at a time", pieces: splitEvery(spoiler, 3), want: want},
+		{name: "line by line", pieces: strings.SplitAfter(spoiler, "\n"), want: want},
+		{
+			name:   "heading markers split",
+			pieces: []string{"Here you go.\n#", "# Movie Overview\nA thief enters dreams.\n## The **Beg", "inning**\nCobb takes a job.\n", "# Ending Explained\nThe top spins."},
+			want:   want,
+		},
+		{
+			name:   "heading newline in the next chunk",
+			pieces: []string{"Here you go.\n## Movie Overview", "\nA thief enters dreams.\n## The **Beginning**", "\nCobb takes a job.\n# Ending Explained", "\nThe top spins."},
+			want:   want,
+		},
+		{
+			name:   "unfinished heading at the end",
+			pieces: []string{"## Movie Overview\nA thief.\n", "## Ending Exp", "lained"},
+			want: []models.SpoilerChunk{
+				{Section: "overview", Heading: "Movie Overview", Text: "## Movie Overview\nA thief.\n"},
+				{Section: "ending", Heading: "Ending Explained", Text: "## Ending Explained"},
+			},
+		},
+		{
+			name:   "indented text is not held back",
+			pieces: []string{"## Movie Overview\n  A thief", " enters dreams."},
+			want: []models.SpoilerChunk{
+				{Section: "overview", Heading: "Movie Overview", Text: "## Movie Overview\n  A thief enters dreams."},
+			},
+		},
+		{
+			name:   "unknown headings are slugified",
+			pieces: []string{"## Themes & Motifs\n", "Memory."},
+			want: []models.SpoilerChunk{
+				{Section: "themes-motifs", Heading: "Themes & Motifs", Text: "## Themes & Motifs\nMemory."},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := streamSections(tt.pieces); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("chunks = %#v\nwant %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSectionTrackerHoldsBackPossibleHeadings(t *testing.T) {
+	tracker := NewSectionTracker()
+	tracker.Write("## Movie Overview\nA thief.\n")
+
+	if chunks := tracker.Write("## The Begin"); len(chunks) != 0 {
+		t.Errorf("partial heading emitted as %#v", chunks)
+	}
+	chunks := tracker.Write("ning\nCobb.")
+	if len(chunks) != 1 || chunks[0].Section != "beginning" || chunks[0].Text != "## The Beginning\nCobb." {
+		t.Errorf("completed heading emitted as %#v", chunks)
+	}
+}
+
+func TestSpoilerBroadcast(t *testing.T) {
+	b := NewSpoilerBroadcast()
+	b.Write("## Movie")
+
+	// A late reader starts with everything written so far
+	text, changed := b.Read(0)
+	if text != "## Movie" || changed == nil {
+		t.Fatalf("Read(0) = %q, %v", text, changed)
+	}
+
+	b.Write(" Overview\n")
+	select {
+	case <-changed:
+	default:
+		t.Fatal("write did not wake the reader")
+	}
+	if text, _ := b.Read(len("## Movie")); text != " Overview\n" {
+		t.Errorf("Read after the first write = %q", text)
+	}
+
+	b.Close()
+	b.Write("ignored")
+	text, changed = b.Read(0)
+	if text != "## Movie Overview\n" || changed != nil {
+		t.Errorf("Read after Close = %q, %v; want the full text and no channel", text, changed)
+	}
+	if !b.Closed() {
+		t.Error("Closed() = false after Close")
+	}
+}
@@ -0,0 +1,445 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite" // pure-Go SQLite driver, registers "sqlite"
+
+	"spoiler_api/internal/models"
+)
+
+// sqliteMigrations are applied in order; the number applied so far is kept
+// in PRAGMA user_version. Append new migrations, never edit existing ones.
+var sqliteMigrations = []string{
+	// 1: movies table keyed by TMDB ID
+	`CREATE TABLE movies (
+		tmdb_id            INTEGER PRIMARY KEY,
+		title              TEXT    NOT NULL,
+		year               TEXT    NOT NULL DEFAULT '',
+		poster             TEXT    NOT NULL DEFAULT '',
+		backdrop           TEXT    NOT NULL DEFAULT '',
+		rating             REAL    NOT NULL DEFAULT 0,
+		genres             TEXT    NOT NULL DEFAULT '[]',
+		overview           TEXT    NOT NULL DEFAULT '',
+		spoiler            TEXT    NOT NULL DEFAULT '',
+		structured_spoiler TEXT,
+		search_count       INTEGER NOT NULL DEFAULT 1,
+		created_at         TEXT    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at         TEXT    NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX idx_movies_search_count ON movies (search_count DESC);`,
+
+	// 2: partner API keys, stored by hash, with usage counters
+	`CREATE TABLE api_keys (
+		id           TEXT    PRIMARY KEY,
+		name         TEXT    NOT NULL,
+		prefix       TEXT    NOT NULL,
+		key_hash     TEXT    NOT NULL UNIQUE,
+		scopes       TEXT    NOT NULL DEFAULT '[]',
+		created_at   TEXT    NOT NULL,
+		revoked_at   TEXT,
+		last_used_at TEXT,
+		requests     INTEGER NOT NULL DEFAULT 0,
+		generations  INTEGER NOT NULL DEFAULT 0,
+		tokens       INTEGER NOT NULL DEFAULT 0
+	);`,
+
+	// 3: timestamped scene outlines behind progress spoilers
+	`CREATE TABLE movie_outlines (
+		tmdb_id    INTEGER PRIMARY KEY,
+		outline    TEXT    NOT NULL,
+		created_at TEXT    NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`,
+
+	// 4: spoilers and outlines keyed by TMDB ID and language; existing rows are English
+	`CREATE TABLE movies_by_language (
+		tmdb_id            INTEGER NOT NULL,
+		language           TEXT    NOT NULL DEFAULT 'en',
+		title              TEXT    NOT NULL,
+		year               TEXT    NOT NULL DEFAULT '',
+		poster             TEXT    NOT NULL DEFAULT '',
+		backdrop           TEXT    NOT NULL DEFAULT '',
+		rating             REAL    NOT NULL DEFAULT 0,
+		genres             TEXT    NOT NULL DEFAULT '[]',
+		overview           TEXT    NOT NULL DEFAULT '',
+		spoiler            TEXT    NOT NULL DEFAULT '',
+		structured_spoiler TEXT,
+		search_count       INTEGER NOT NULL DEFAULT 1,
+		created_at         TEXT    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at         TEXT    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (tmdb_id, language)
+	);
+	INSERT INTO movies_by_language (tmdb_id, title, year, poster, backdrop, rating, genres, overview, spoiler,
+		structured_spoiler, search_count, created_at, updated_at)
+	SELECT tmdb_id, title, year, poster, backdrop, rating, genres, overview, spoiler,
+		structured_spoiler, search_count, created_at, updated_at FROM movies;
+	DROP TABLE movies;
+	ALTER TABLE movies_by_language RENAME TO movies;
+	CREATE INDEX idx_movies_search_count ON movies (language, search_count DESC);
+
+	CREATE TABLE outlines_by_language (
+		tmdb_id    INTEGER NOT NULL,
+		language   TEXT    NOT NULL DEFAULT 'en',
+		outline    TEXT    NOT NULL,
+		created_at TEXT    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (tmdb_id, language)
+	);
+	INSERT INTO outlines_by_language (tmdb_id, outline, created_at) SELECT tmdb_id, outline, created_at FROM movie_outlines;
+	DROP TABLE movie_outlines;
+	ALTER TABLE outlines_by_language RENAME TO movie_outlines;`,
+}
+
+// SQLiteStore is a MovieStore, APIKeyStore and OutlineStore backed by an embedded SQLite database file
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (creating if needed) the database at path and applies
+// any pending schema migrations
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+
+	// SQLite allows a single writer; one connection avoids SQLITE_BUSY between our own goroutines
+	db.SetMaxOpenConns(1)
+
+	store := &SQLiteStore{db: db}
+	if err := store.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// migrate applies the migrations that have not run yet, each in its own transaction
+func (s *SQLiteStore) migrate() error {
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read SQLite schema version: %w", err)
+	}
+
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to start migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply migration %d: %w", i+1, err)
+		}
+		// PRAGMA does not accept bound parameters
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit migration %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// Close closes the underlying database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+const sqliteMovieColumns = `tmdb_id, language, title, year, poster, backdrop, rating, genres, overview, spoiler, structured_spoiler`
+
+// FindMovie looks up a movie in the database by its TMDB ID and language
+func (s *SQLiteStore) FindMovie(ctx context.Context, tmdbID int, language string) (*models.MovieResponse, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+sqliteMovieColumns+` FROM movies WHERE tmdb_id = ? AND language = ?`,
+		tmdbID, storedLanguage(language))
+
+	movie, err := scanSQLiteMovie(row)
+	if err == sql.ErrNoRows {
+		return nil, nil // Not found — not an error, just no cache hit
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query SQLite: %w", err)
+	}
+
+	return movie, nil
+}
+
+// SaveMovie stores a movie with its spoiler, replacing any stored copy but
+// keeping its search count
+func (s *SQLiteStore) SaveMovie(ctx context.Context, movie *models.MovieResponse) error {
+	genres, err := json.Marshal(movie.Genres)
+	if err != nil {
+		return fmt.Errorf("failed to marshal genres: %w", err)
+	}
+
+	var structured []byte
+	if movie.Structured != nil {
+		if structured, err = json.Marshal(movie.Structured); err != nil {
+			return fmt.Errorf("failed to marshal structured spoiler: %w", err)
+		}
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO movies (`+sqliteMovieColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (tmdb_id, language) DO UPDATE SET
+			title = excluded.title,
+			year = excluded.year,
+			poster = excluded.poster,
+			backdrop = excluded.backdrop,
+			rating = excluded.rating,
+			genres = excluded.genres,
+			overview = excluded.overview,
+			spoiler = excluded.spoiler,
+			structured_spoiler = excluded.structured_spoiler,
+			updated_at = CURRENT_TIMESTAMP`,
+		movie.TMDBID, storedLanguage(movie.Language), movie.Title, movie.Year, movie.Poster, movie.Backdrop, movie.Rating,
+		string(genres), movie.Overview, movie.Spoiler, nullableString(structured),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save to SQLite: %w", err)
+	}
+
+	return nil
+}
+
+// IncrementSearchCount increments the search_count for a movie by TMDB ID and language
+func (s *SQLiteStore) IncrementSearchCount(ctx context.Context, tmdbID int, language string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE movies SET search_count = search_count + 1 WHERE tmdb_id = ? AND language = ?`,
+		tmdbID, storedLanguage(language))
+	if err != nil {
+		return fmt.Errorf("failed to increment search count: %w", err)
+	}
+	return nil
+}
+
+// ListTrending retrieves the most searched movies in a language from the database
+func (s *SQLiteStore) ListTrending(ctx context.Context, language string, limit int) ([]models.MovieResponse, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+sqliteMovieColumns+` FROM movies WHERE language = ? ORDER BY search_count DESC LIMIT ?`,
+		storedLanguage(language), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query SQLite: %w", err)
+	}
+	defer rows.Close()
+
+	var movies []models.MovieResponse
+	for rows.Next() {
+		movie, err := scanSQLiteMovie(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SQLite row: %w", err)
+		}
+		movies = append(movies, *movie)
+	}
+
+	return movies, rows.Err()
+}
+
+// DeleteMovie removes a movie in every language from the database by TMDB ID
+func (s *SQLiteStore) DeleteMovie(ctx context.Context, tmdbID int) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM movies WHERE tmdb_id = ?`, tmdbID); err != nil {
+		return fmt.Errorf("failed to delete from SQLite: %w", err)
+	}
+	return nil
+}
+
+// FindOutline looks up a movie's scene outline by TMDB ID and language
+func (s *SQLiteStore) FindOutline(ctx context.Context, tmdbID int, language string) (string, error) {
+	var outline string
+	err := s.db.QueryRowContext(ctx, `SELECT outline FROM movie_outlines WHERE tmdb_id = ? AND language = ?`,
+		tmdbID, storedLanguage(language)).Scan(&outline)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query SQLite outline: %w", err)
+	}
+	return outline, nil
+}
+
+// SaveOutline stores a movie's scene outline in language, replacing any stored copy
+func (s *SQLiteStore) SaveOutline(ctx context.Context, tmdbID int, language, outline string) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO movie_outlines (tmdb_id, language, outline) VALUES (?, ?, ?)
+		ON CONFLICT (tmdb_id, language) DO UPDATE SET outline = excluded.outline, created_at = CURRENT_TIMESTAMP`,
+		tmdbID, storedLanguage(language), outline)
+	if err != nil {
+		return fmt.Errorf("failed to save outline to SQLite: %w", err)
+	}
+	return nil
+}
+
+// DeleteOutline removes a movie's scene outlines in every language by TMDB ID
+func (s *SQLiteStore) DeleteOutline(ctx context.Context, tmdbID int) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM movie_outlines WHERE tmdb_id = ?`, tmdbID); err != nil {
+		return fmt.Errorf("failed to delete outline from SQLite: %w", err)
+	}
+	return nil
+}
+
+// sqliteScanner is satisfied by *sql.Row and *sql.Rows
+type sqliteScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSQLiteMovie reads a row selected with sqliteMovieColumns
+func scanSQLiteMovie(row sqliteScanner) (*models.MovieResponse, error) {
+	var movie models.MovieResponse
+	var genres string
+	var structured sql.NullString
+
+	err := row.Scan(
+		&movie.TMDBID, &movie.Language, &movie.Title, &movie.Year, &movie.Poster, &movie.Backdrop, &movie.Rating,
+		&genres, &movie.Overview, &movie.Spoiler, &structured,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(genres), &movie.Genres); err != nil {
+		return nil, fmt.Errorf("failed to parse stored genres: %w", err)
+	}
+	if structured.Valid {
+		movie.Structured = &models.StructuredSpoiler{}
+		if err := json.Unmarshal([]byte(structured.String), movie.Structured); err != nil {
+			return nil, fmt.Errorf("failed to parse stored structured spoiler: %w", err)
+		}
+	}
+
+	return &movie, nil
+}
+
+// nullableString converts an empty byte slice to SQL NULL
+func nullableString(b []byte) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
+
+const sqliteAPIKeyColumns = `id, name, prefix, scopes, created_at, revoked_at, last_used_at, requests, generations, tokens`
+
+// CreateAPIKey stores a new API key
+func (s *SQLiteStore) CreateAPIKey(ctx context.Context, key *models.APIKey, hash string) error {
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal scopes: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO api_keys (id, name, prefix, key_hash, scopes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		key.ID, key.Name, key.Prefix, hash, string(scopes), key.CreatedAt.Format(time.RFC3339Nano),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save API key to SQLite: %w", err)
+	}
+	return nil
+}
+
+// FindAPIKey looks up an API key by the hash of the key
+func (s *SQLiteStore) FindAPIKey(ctx context.Context, hash string) (*models.APIKey, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+sqliteAPIKeyColumns+` FROM api_keys WHERE key_hash = ?`, hash)
+
+	key, err := scanSQLiteAPIKey(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query SQLite: %w", err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys returns every API key, newest first
+func (s *SQLiteStore) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+sqliteAPIKeyColumns+` FROM api_keys ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query SQLite: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanSQLiteAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SQLite row: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey marks an API key revoked; revoking it again keeps the first time
+func (s *SQLiteStore) RevokeAPIKey(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ?`,
+		time.Now().UTC().Format(time.RFC3339Nano), id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key in SQLite: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// AddAPIKeyUsage adds to an API key's usage counters
+func (s *SQLiteStore) AddAPIKeyUsage(ctx context.Context, id string, usage models.APIKeyUsage, lastUsed time.Time) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE api_keys SET
+			requests = requests + ?,
+			generations = generations + ?,
+			tokens = tokens + ?,
+			last_used_at = ?
+		WHERE id = ?`,
+		usage.Requests, usage.Generations, usage.Tokens, lastUsed.Format(time.RFC3339Nano), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update API key usage in SQLite: %w", err)
+	}
+	return nil
+}
+
+// scanSQLiteAPIKey reads a row selected with sqliteAPIKeyColumns
+func scanSQLiteAPIKey(row sqliteScanner) (*models.APIKey, error) {
+	var key models.APIKey
+	var scopes, createdAt string
+	var revokedAt, lastUsedAt sql.NullString
+
+	err := row.Scan(
+		&key.ID, &key.Name, &key.Prefix, &scopes, &createdAt, &revokedAt, &lastUsedAt,
+		&key.Usage.Requests, &key.Usage.Generations, &key.Usage.Tokens,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(scopes), &key.Scopes); err != nil {
+		return nil, fmt.Errorf("failed to parse stored scopes: %w", err)
+	}
+	if key.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return nil, fmt.Errorf("failed to parse stored creation time: %w", err)
+	}
+	if key.RevokedAt, err = parseSQLiteTime(revokedAt); err != nil {
+		return nil, fmt.Errorf("failed to parse stored revocation time: %w", err)
+	}
+	if key.LastUsedAt, err = parseSQLiteTime(lastUsedAt); err != nil {
+		return nil, fmt.Errorf("failed to parse stored last use: %w", err)
+	}
+
+	return &key, nil
+}
+
+// parseSQLiteTime parses an optional RFC 3339 timestamp column
+func parseSQLiteTime(value sql.NullString) (*time.Time, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, value.String)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"spoiler_api/internal/models"
+)
+
+// sqliteAtVersion creates a database with the first version migrations
+// applied, runs seed against it and returns its path
+func sqliteAtVersion(t *testing.T, version int, seed string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "spoilers.db")
+
+	db, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, migration := range sqliteMigrations[:version] {
+		if _, err := db.Exec(migration); err != nil {
+			t.Fatalf("apply migration: %v", err)
+		}
+	}
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", version)); err != nil {
+		t.Fatal(err)
+	}
+	if seed != "" {
+		if _, err := db.Exec(seed); err != nil {
+			t.Fatalf("seed version %d: %v", version, err)
+		}
+	}
+	return path
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	const seedMovies = `INSERT INTO movies (tmdb_id, title, year, genres, spoiler, search_count) VALUES
+		(27205, 'Inception', '2010', '["Action"]', '## Ending Explained\nThe top spins.', 7),
+		(603, 'The Matrix', '1999', '[]', '## Ending Explained\nNeo wins.', 3);`
+	const seedOutline = `INSERT INTO movie_outlines (tmdb_id, outline) VALUES (27205, '[{"minute":0,"scene":"Limbo"}]');`
+
+	tests := []struct {
+		name        string
+		version     int
+		seed        string
+		wantMovies  []int // trending English TMDB IDs after migrating
+		wantOutline bool
+	}{
+		{name: "empty database", version: 0},
+		{name: "from v1", version: 1, seed: seedMovies, wantMovies: []int{27205, 603}},
+		{name: "from v2", version: 2, seed: seedMovies + `INSERT INTO api_keys (id, name, prefix, key_hash, created_at) VALUES ('k1', 'partner', 'sk_live_abc', 'hash', '2026-01-01T00:00:00Z');`, wantMovies: []int{27205, 603}},
+		{name: "from v3", version: 3, seed: seedMovies + seedOutline, wantMovies: []int{27205, 603}, wantOutline: true},
+		{name: "already current", version: len(sqliteMigrations)},
+	}
+
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := sqliteAtVersion(t, tt.version, tt.seed)
+
+			store, err := NewSQLiteStore(path)
+			if err != nil {
+				t.Fatalf("NewSQLiteStore: %v", err)
+			}
+			defer store.Close()
+
+			var version int
+			if err := store.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil || version != len(sqliteMigrations) {
+				t.Fatalf("user_version = %d (%v), want %d", version, err, len(sqliteMigrations))
+			}
+
+			// Existing rows become English and keep their search counts
+			trending, err := store.ListTrending(ctx, "en", 10)
+			if err != nil {
+				t.Fatalf("ListTrending: %v", err)
+			}
+			var ids []int
+			for _, movie := range trending {
+				if movie.Language != "en" {
+					t.Errorf("movie %d migrated with language %q, want en", movie.TMDBID, movie.Language)
+				}
+				ids = append(ids, movie.TMDBID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.wantMovies) {
+				t.Errorf("trending = %v, want %v", ids, tt.wantMovies)
+			}
+
+			outline, err := store.FindOutline(ctx, 27205, "en")
+			if err != nil {
+				t.Fatalf("FindOutline: %v", err)
+			}
+			if (outline != "") != tt.wantOutline {
+				t.Errorf("English outline = %q after migrating, want one: %v", outline, tt.wantOutline)
+			}
+
+			// The migrated schema keys movies and outlines by language
+			spanish := &models.MovieResponse{TMDBID: 27205, Language: "es", Title: "Origen", Genres: []string{"Acción"}}
+			if err := store.SaveMovie(ctx, spanish); err != nil {
+				t.Fatalf("SaveMovie(es): %v", err)
+			}
+			if err := store.SaveOutline(ctx, 27205, "es", "[]"); err != nil {
+				t.Fatalf("SaveOutline(es): %v", err)
+			}
+			if movie, err := store.FindMovie(ctx, 27205, "es"); err != nil || movie == nil || movie.Title != "Origen" {
+				t.Errorf("FindMovie(es) = %+v, %v", movie, err)
+			}
+			if len(tt.wantMovies) > 0 {
+				if movie, err := store.FindMovie(ctx, 27205, "en"); err != nil || movie == nil || movie.Title != "Inception" {
+					t.Errorf("FindMovie(en) after saving Spanish = %+v, %v", movie, err)
+				}
+			}
+
+			// Reopening applies nothing twice
+			store.Close()
+			reopened, err := NewSQLiteStore(path)
+			if err != nil {
+				t.Fatalf("reopen: %v", err)
+			}
+			reopened.Close()
+		})
+	}
+}
+
+func TestSQLiteStoreMovies(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStore(filepath.Join(t.TempDir(), "spoilers.db"))
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	defer store.Close()
+
+	if movie, err := store.FindMovie(ctx, 27205, "en"); movie != nil || err != nil {
+		t.Fatalf("FindMovie on an empty store = %+v, %v; want nil, nil", movie, err)
+	}
+
+	movie := &models.MovieResponse{
+		TMDBID:     27205,
+		Title:      "Inception",
+		Genres:     []string{"Action"},
+		Spoiler:    "## Ending Explained\nThe top spins.",
+		Structured: &models.StructuredSpoiler{Overview: "A thief enters dreams."},
+	}
+	if err := store.SaveMovie(ctx, movie); err != nil {
+		t.Fatalf("SaveMovie: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := store.IncrementSearchCount(ctx, 27205, ""); err != nil {
+			t.Fatalf("IncrementSearchCount: %v", err)
+		}
+	}
+
+	// A language-less save and lookup are English
+	found, err := store.FindMovie(ctx, 27205, "en")
+	if err != nil || found == nil {
+		t.Fatalf("FindMovie = %+v, %v", found, err)
+	}
+	if found.Language != "en" || found.Structured == nil || found.Structured.Overview != "A thief enters dreams." {
+		t.Errorf("FindMovie = %+v", found)
+	}
+
+	// Saving again replaces the spoiler but keeps the search count
+	movie.Spoiler = "## Ending Explained\nIt falls."
+	if err := store.SaveMovie(ctx, movie); err != nil {
+		t.Fatalf("SaveMovie again: %v", err)
+	}
+	var count int
+	if err := store.db.QueryRow(`SELECT search_count FROM movies WHERE tmdb_id = 27205`).Scan(&count); err != nil || count != 3 {
+		t.Errorf("search_count = %d (%v), want 3", count, err)
+	}
+
+	if err := store.DeleteMovie(ctx, 27205); err != nil {
+		t.Fatalf("DeleteMovie: %v", err)
+	}
+	if movie, _ := store.FindMovie(ctx, 27205, "en"); movie != nil {
+		t.Error("movie still stored after DeleteMovie")
+	}
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -11,52 +12,67 @@ import (
 	"time"
 
 	"spoiler_api/internal/models"
+	"spoiler_api/internal/upstream"
 )
 
-// SupabaseService handles Supabase database interactions
+// SupabaseService is a MovieStore, APIKeyStore and OutlineStore backed by a Supabase (PostgREST) database
 type SupabaseService struct {
-	baseURL    string
-	apiKey     string
-	client     *http.Client
+	baseURL string
+	apiKey  string
+	client  *upstream.Client
 }
 
 // NewSupabaseService creates a new Supabase service instance
-func NewSupabaseService(supabaseURL, supabaseKey string) *SupabaseService {
+func NewSupabaseService(supabaseURL, supabaseKey string, client *upstream.Client) *SupabaseService {
 	return &SupabaseService{
 		baseURL: strings.TrimRight(supabaseURL, "/"),
 		apiKey:  supabaseKey,
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		client:  client,
 	}
 }
 
 // supabaseMovie represents a movie row in the Supabase database
 type supabaseMovie struct {
-	ID          string   `json:"id,omitempty"`
-	Title       string   `json:"title"`
-	Year        string   `json:"year"`
-	Poster      string   `json:"poster"`
-	Backdrop    string   `json:"backdrop"`
-	Rating      float64  `json:"rating"`
-	Genres      []string `json:"genres"`
-	Overview    string   `json:"overview"`
-	Spoiler     string   `json:"spoiler"`
-	SearchCount int      `json:"search_count"`
-	CreatedAt   string   `json:"created_at,omitempty"`
-	UpdatedAt   string   `json:"updated_at,omitempty"`
-}
-
-// FindMovieByTitleAndYear looks up a movie in the database by title and year
-func (s *SupabaseService) FindMovieByTitleAndYear(title, year string) (*models.MovieResponse, error) {
-	// Use PostgREST query: filter by title (case-insensitive) and year
-	endpoint := fmt.Sprintf("%s/rest/v1/movies?title=ilike.%s&year=eq.%s&limit=1",
-		s.baseURL,
-		url.QueryEscape(title),
-		url.QueryEscape(year),
-	)
-
-	req, err := http.NewRequest("GET", endpoint, nil)
+	ID                string                    `json:"id,omitempty"`
+	TMDBID            int                       `json:"tmdb_id,omitempty"`
+	Language          string                    `json:"language,omitempty"`
+	Title             string                    `json:"title"`
+	Year              string                    `json:"year"`
+	Poster            string                    `json:"poster"`
+	Backdrop          string                    `json:"backdrop"`
+	Rating            float64                   `json:"rating"`
+	Genres            []string                  `json:"genres"`
+	Overview          string                    `json:"overview"`
+	Spoiler           string                    `json:"spoiler"`
+	StructuredSpoiler *models.StructuredSpoiler `json:"structured_spoiler,omitempty"`
+	SearchCount       int                       `json:"search_count"`
+	CreatedAt         string                    `json:"created_at,omitempty"`
+	UpdatedAt         string                    `json:"updated_at,omitempty"`
+}
+
+// toMovieResponse converts a database row to the API representation
+func (m supabaseMovie) toMovieResponse() models.MovieResponse {
+	return models.MovieResponse{
+		TMDBID:     m.TMDBID,
+		Language:   m.Language,
+		Title:      m.Title,
+		Year:       m.Year,
+		Poster:     m.Poster,
+		Backdrop:   m.Backdrop,
+		Rating:     m.Rating,
+		Genres:     m.Genres,
+		Overview:   m.Overview,
+		Spoiler:    m.Spoiler,
+		Structured: m.StructuredSpoiler,
+	}
+}
+
+// FindMovie looks up a movie in the database by its TMDB ID and language
+func (s *SupabaseService) FindMovie(ctx context.Context, tmdbID int, language string) (*models.MovieResponse, error) {
+	endpoint := fmt.Sprintf("%s/rest/v1/movies?tmdb_id=eq.%d&language=eq.%s&limit=1",
+		s.baseURL, tmdbID, url.QueryEscape(storedLanguage(language)))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -88,35 +104,25 @@ func (s *SupabaseService) FindMovieByTitleAndYear(title, year string) (*models.M
 		return nil, nil // Not found — not an error, just no cache hit
 	}
 
-	movie := movies[0]
-
-	// Increment search count in the background
-	go s.incrementSearchCount(movie.ID)
-
-	return &models.MovieResponse{
-		Title:    movie.Title,
-		Year:     movie.Year,
-		Poster:   movie.Poster,
-		Backdrop: movie.Backdrop,
-		Rating:   movie.Rating,
-		Genres:   movie.Genres,
-		Overview: movie.Overview,
-		Spoiler:  movie.Spoiler,
-	}, nil
+	response := movies[0].toMovieResponse()
+	return &response, nil
 }
 
 // SaveMovie stores a movie with its spoiler in the database
-func (s *SupabaseService) SaveMovie(movie *models.MovieResponse) error {
+func (s *SupabaseService) SaveMovie(ctx context.Context, movie *models.MovieResponse) error {
 	record := supabaseMovie{
-		Title:       movie.Title,
-		Year:        movie.Year,
-		Poster:      movie.Poster,
-		Backdrop:    movie.Backdrop,
-		Rating:      movie.Rating,
-		Genres:      movie.Genres,
-		Overview:    movie.Overview,
-		Spoiler:     movie.Spoiler,
-		SearchCount: 1,
+		TMDBID:            movie.TMDBID,
+		Language:          storedLanguage(movie.Language),
+		Title:             movie.Title,
+		Year:              movie.Year,
+		Poster:            movie.Poster,
+		Backdrop:          movie.Backdrop,
+		Rating:            movie.Rating,
+		Genres:            movie.Genres,
+		Overview:          movie.Overview,
+		Spoiler:           movie.Spoiler,
+		StructuredSpoiler: movie.Structured,
+		SearchCount:       1,
 	}
 
 	jsonBody, err := json.Marshal(record)
@@ -124,15 +130,15 @@ func (s *SupabaseService) SaveMovie(movie *models.MovieResponse) error {
 		return fmt.Errorf("failed to marshal movie for Supabase: %w", err)
 	}
 
-	endpoint := fmt.Sprintf("%s/rest/v1/movies", s.baseURL)
+	endpoint := fmt.Sprintf("%s/rest/v1/movies?on_conflict=tmdb_id,language", s.baseURL)
 
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return fmt.Errorf("failed to create Supabase request: %w", err)
 	}
 
 	s.setHeaders(req)
-	req.Header.Set("Prefer", "resolution=merge-duplicates") // Upsert on conflict
+	req.Header.Set("Prefer", "resolution=merge-duplicates") // Upsert on (tmdb_id, language) conflict
 
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -142,42 +148,75 @@ func (s *SupabaseService) SaveMovie(movie *models.MovieResponse) error {
 
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("Supabase save error: status %d, response: %s", resp.StatusCode, string(body))
+		return rejectedWrite(resp.StatusCode, fmt.Errorf("Supabase save error: status %d, response: %s", resp.StatusCode, string(body)))
 	}
 
 	return nil
 }
 
-// incrementSearchCount increments the search_count for a movie by ID
-func (s *SupabaseService) incrementSearchCount(movieID string) {
-	// Use Supabase RPC to increment the counter
-	endpoint := fmt.Sprintf("%s/rest/v1/rpc/increment_search_count", s.baseURL)
+// IncrementSearchCount increments the search_count for a movie by TMDB ID and language
+func (s *SupabaseService) IncrementSearchCount(ctx context.Context, tmdbID int, language string) error {
+	// Use Supabase RPC to increment the counter atomically
+	endpoint := fmt.Sprintf("%s/rest/v1/rpc/increment_search_count_by_tmdb_id", s.baseURL)
 
-	payload := map[string]string{"movie_id": movieID}
+	payload := map[string]interface{}{"p_tmdb_id": tmdbID, "p_language": storedLanguage(language)}
 	jsonBody, err := json.Marshal(payload)
 	if err != nil {
-		return
+		return fmt.Errorf("failed to marshal search count payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create Supabase request: %w", err)
+	}
+
+	s.setHeaders(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to increment search count: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return rejectedWrite(resp.StatusCode, fmt.Errorf("Supabase RPC error: status %d, response: %s", resp.StatusCode, string(body)))
 	}
 
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonBody))
+	return nil
+}
+
+// DeleteMovie removes a movie in every language from the database by TMDB ID
+func (s *SupabaseService) DeleteMovie(ctx context.Context, tmdbID int) error {
+	endpoint := fmt.Sprintf("%s/rest/v1/movies?tmdb_id=eq.%d", s.baseURL, tmdbID)
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", endpoint, nil)
 	if err != nil {
-		return
+		return fmt.Errorf("failed to create Supabase request: %w", err)
 	}
 
 	s.setHeaders(req)
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return
+		return fmt.Errorf("failed to delete from Supabase: %w", err)
 	}
 	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Supabase delete error: status %d, response: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
 }
 
-// GetAllMovies retrieves all cached movies from the database
-func (s *SupabaseService) GetAllMovies() ([]models.MovieResponse, error) {
-	endpoint := fmt.Sprintf("%s/rest/v1/movies?order=search_count.desc&limit=50", s.baseURL)
+// ListTrending retrieves the most searched movies in a language from the database
+func (s *SupabaseService) ListTrending(ctx context.Context, language string, limit int) ([]models.MovieResponse, error) {
+	endpoint := fmt.Sprintf("%s/rest/v1/movies?language=eq.%s&order=search_count.desc&limit=%d",
+		s.baseURL, url.QueryEscape(storedLanguage(language)), limit)
 
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -207,21 +246,399 @@ func (s *SupabaseService) GetAllMovies() ([]models.MovieResponse, error) {
 
 	var movies []models.MovieResponse
 	for _, m := range dbMovies {
-		movies = append(movies, models.MovieResponse{
-			Title:    m.Title,
-			Year:     m.Year,
-			Poster:   m.Poster,
-			Backdrop: m.Backdrop,
-			Rating:   m.Rating,
-			Genres:   m.Genres,
-			Overview: m.Overview,
-			Spoiler:  m.Spoiler,
-		})
+		movies = append(movies, m.toMovieResponse())
+	}
+
+	return movies, nil
+}
+
+// LegacyMovie is a stored movie that predates TMDB ID keys
+type LegacyMovie struct {
+	RowID string
+	Title string
+	Year  string
+}
+
+// ListMoviesWithoutTMDBID returns a page of rows that have no tmdb_id yet
+func (s *SupabaseService) ListMoviesWithoutTMDBID(ctx context.Context, offset, limit int) ([]LegacyMovie, error) {
+	endpoint := fmt.Sprintf("%s/rest/v1/movies?tmdb_id=is.null&select=id,title,year&order=created_at.asc&offset=%d&limit=%d",
+		s.baseURL, offset, limit)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	s.setHeaders(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Supabase: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("Supabase query error: status %d, response: %s", resp.StatusCode, string(body))
+	}
+
+	var dbMovies []supabaseMovie
+	if err := json.NewDecoder(resp.Body).Decode(&dbMovies); err != nil {
+		return nil, fmt.Errorf("failed to parse Supabase response: %w", err)
+	}
+
+	var movies []LegacyMovie
+	for _, m := range dbMovies {
+		movies = append(movies, LegacyMovie{RowID: m.ID, Title: m.Title, Year: m.Year})
 	}
 
 	return movies, nil
 }
 
+// SetTMDBID assigns a TMDB ID to an existing row
+func (s *SupabaseService) SetTMDBID(ctx context.Context, rowID string, tmdbID int) error {
+	jsonBody, err := json.Marshal(map[string]int{"tmdb_id": tmdbID})
+	if err != nil {
+		return fmt.Errorf("failed to marshal tmdb_id update: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/rest/v1/movies?id=eq.%s", s.baseURL, url.QueryEscape(rowID))
+
+	req, err := http.NewRequestWithContext(ctx, "PATCH", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create Supabase request: %w", err)
+	}
+
+	s.setHeaders(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to update Supabase: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Supabase update error: status %d, response: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// supabaseAPIKey represents an API key row in the Supabase database
+type supabaseAPIKey struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	KeyHash     string     `json:"key_hash,omitempty"`
+	Scopes      []string   `json:"scopes"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	Requests    int64      `json:"requests"`
+	Generations int64      `json:"generations"`
+	Tokens      int64      `json:"tokens"`
+}
+
+// supabaseAPIKeyColumns selects everything but the hash
+const supabaseAPIKeyColumns = "id,name,prefix,scopes,created_at,revoked_at,last_used_at,requests,generations,tokens"
+
+// toAPIKey converts a database row to the API representation
+func (k supabaseAPIKey) toAPIKey() models.APIKey {
+	return models.APIKey{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     k.Scopes,
+		CreatedAt:  k.CreatedAt,
+		RevokedAt:  k.RevokedAt,
+		LastUsedAt: k.LastUsedAt,
+		Usage: models.APIKeyUsage{
+			Requests:    k.Requests,
+			Generations: k.Generations,
+			Tokens:      k.Tokens,
+		},
+	}
+}
+
+// CreateAPIKey stores a new API key
+func (s *SupabaseService) CreateAPIKey(ctx context.Context, key *models.APIKey, hash string) error {
+	jsonBody, err := json.Marshal(supabaseAPIKey{
+		ID:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		KeyHash:   hash,
+		Scopes:    key.Scopes,
+		CreatedAt: key.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal API key for Supabase: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL+"/rest/v1/api_keys", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create Supabase request: %w", err)
+	}
+
+	s.setHeaders(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to save API key to Supabase: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Supabase save error: status %d, response: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// FindAPIKey looks up an API key by the hash of the key
+func (s *SupabaseService) FindAPIKey(ctx context.Context, hash string) (*models.APIKey, error) {
+	keys, err := s.queryAPIKeys(ctx, fmt.Sprintf("key_hash=eq.%s&limit=1", url.QueryEscape(hash)))
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+	return &keys[0], nil
+}
+
+// ListAPIKeys returns every API key, newest first
+func (s *SupabaseService) ListAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	return s.queryAPIKeys(ctx, "order=created_at.desc")
+}
+
+// RevokeAPIKey marks an API key revoked; revoking it again keeps the first time
+func (s *SupabaseService) RevokeAPIKey(ctx context.Context, id string) error {
+	jsonBody, err := json.Marshal(map[string]time.Time{"revoked_at": time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal API key revocation: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/rest/v1/api_keys?id=eq.%s&revoked_at=is.null", s.baseURL, url.QueryEscape(id))
+
+	req, err := http.NewRequestWithContext(ctx, "PATCH", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create Supabase request: %w", err)
+	}
+
+	s.setHeaders(req)
+	req.Header.Set("Prefer", "return=representation")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key in Supabase: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Supabase update error: status %d, response: %s", resp.StatusCode, string(body))
+	}
+
+	var updated []supabaseAPIKey
+	if err := json.NewDecoder(resp.Body).Decode(&updated); err != nil {
+		return fmt.Errorf("failed to parse Supabase response: %w", err)
+	}
+	if len(updated) > 0 {
+		return nil
+	}
+
+	// Nothing updated: either already revoked or no such key
+	existing, err := s.queryAPIKeys(ctx, fmt.Sprintf("id=eq.%s&limit=1", url.QueryEscape(id)))
+	if err != nil {
+		return err
+	}
+	if len(existing) == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// AddAPIKeyUsage adds to an API key's usage counters
+func (s *SupabaseService) AddAPIKeyUsage(ctx context.Context, id string, usage models.APIKeyUsage, lastUsed time.Time) error {
+	// Use Supabase RPC to add to the counters atomically
+	endpoint := fmt.Sprintf("%s/rest/v1/rpc/add_api_key_usage", s.baseURL)
+
+	jsonBody, err := json.Marshal(map[string]interface{}{
+		"p_id":          id,
+		"p_requests":    usage.Requests,
+		"p_generations": usage.Generations,
+		"p_tokens":      usage.Tokens,
+		"p_last_used":   lastUsed,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal API key usage payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create Supabase request: %w", err)
+	}
+
+	s.setHeaders(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to update API key usage: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Supabase RPC error: status %d, response: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// queryAPIKeys selects API keys matching a PostgREST filter
+func (s *SupabaseService) queryAPIKeys(ctx context.Context, filter string) ([]models.APIKey, error) {
+	endpoint := fmt.Sprintf("%s/rest/v1/api_keys?select=%s&%s", s.baseURL, supabaseAPIKeyColumns, filter)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	s.setHeaders(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Supabase: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("Supabase query error: status %d, response: %s", resp.StatusCode, string(body))
+	}
+
+	var rows []supabaseAPIKey
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("failed to parse Supabase response: %w", err)
+	}
+
+	keys := make([]models.APIKey, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, row.toAPIKey())
+	}
+	return keys, nil
+}
+
+// supabaseOutline is the row format of the movie_outlines table
+type supabaseOutline struct {
+	TMDBID   int    `json:"tmdb_id"`
+	Language string `json:"language"`
+	Outline  string `json:"outline"`
+}
+
+// FindOutline looks up a movie's scene outline by TMDB ID and language
+func (s *SupabaseService) FindOutline(ctx context.Context, tmdbID int, language string) (string, error) {
+	endpoint := fmt.Sprintf("%s/rest/v1/movie_outlines?tmdb_id=eq.%d&language=eq.%s&select=tmdb_id,language,outline&limit=1",
+		s.baseURL, tmdbID, url.QueryEscape(storedLanguage(language)))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	s.setHeaders(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to query Supabase outline: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("Supabase outline query error: status %d, response: %s", resp.StatusCode, string(body))
+	}
+
+	var rows []supabaseOutline
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return "", fmt.Errorf("failed to parse Supabase outline response: %w", err)
+	}
+	if len(rows) == 0 {
+		return "", nil
+	}
+	return rows[0].Outline, nil
+}
+
+// SaveOutline stores a movie's scene outline in language, replacing any stored copy
+func (s *SupabaseService) SaveOutline(ctx context.Context, tmdbID int, language, outline string) error {
+	jsonBody, err := json.Marshal(supabaseOutline{TMDBID: tmdbID, Language: storedLanguage(language), Outline: outline})
+	if err != nil {
+		return fmt.Errorf("failed to marshal outline for Supabase: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/rest/v1/movie_outlines?on_conflict=tmdb_id,language", s.baseURL)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create Supabase request: %w", err)
+	}
+
+	s.setHeaders(req)
+	req.Header.Set("Prefer", "resolution=merge-duplicates") // Upsert on (tmdb_id, language) conflict
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to save outline to Supabase: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Supabase outline save error: status %d, response: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// DeleteOutline removes a movie's scene outlines in every language by TMDB ID
+func (s *SupabaseService) DeleteOutline(ctx context.Context, tmdbID int) error {
+	endpoint := fmt.Sprintf("%s/rest/v1/movie_outlines?tmdb_id=eq.%d", s.baseURL, tmdbID)
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create Supabase request: %w", err)
+	}
+
+	s.setHeaders(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete outline from Supabase: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("Supabase outline delete error: status %d, response: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// rejectedWrite marks err as ErrWriteRejected when status says the write
+// itself is at fault. Auth failures and 408/429 may pass once the
+// configuration or load changes, so they stay retryable.
+func rejectedWrite(status int, err error) error {
+	switch {
+	case status < 400 || status >= 500:
+		return err
+	case status == http.StatusUnauthorized, status == http.StatusForbidden,
+		status == http.StatusRequestTimeout, status == http.StatusTooManyRequests:
+		return err
+	default:
+		return fmt.Errorf("%w: %w", ErrWriteRejected, err)
+	}
+}
+
 // setHeaders sets the required Supabase headers on a request
 func (s *SupabaseService) setHeaders(req *http.Request) {
 	req.Header.Set("apikey", s.apiKey)
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestRejectedWrite(t *testing.T) {
+	tests := []struct {
+		status       int
+		wantRejected bool
+	}{
+		{http.StatusBadRequest, true},
+		{http.StatusNotFound, true},
+		{http.StatusConflict, true},
+		{http.StatusUnprocessableEntity, true},
+		{http.StatusUnauthorized, false},
+		{http.StatusForbidden, false},
+		{http.StatusRequestTimeout, false},
+		{http.StatusTooManyRequests, false},
+		{http.StatusInternalServerError, false},
+		{http.StatusServiceUnavailable, false},
+	}
+
+	for _, tt := range tests {
+		err := rejectedWrite(tt.status, errors.New("Supabase save error"))
+		if rejected := errors.Is(err, ErrWriteRejected); rejected != tt.wantRejected {
+			t.Errorf("status %d: rejected = %v, want %v", tt.status, rejected, tt.wantRejected)
+		}
+	}
+}
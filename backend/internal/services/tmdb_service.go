@@ -1,44 +1,102 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
 
 	"spoiler_api/internal/models"
+	"spoiler_api/internal/tracing"
+	"spoiler_api/internal/upstream"
 )
 
+// ErrMovieNotFound is returned when TMDB has no movie with the requested ID or title
+var ErrMovieNotFound = errors.New("movie not found")
+
 // TMDBService handles TMDB API interactions
 type TMDBService struct {
 	apiKey string
-	client *http.Client
+	client *upstream.Client
 }
 
-// NewTMDBService creates a new TMDB service instance
-func NewTMDBService(apiKey string) *TMDBService {
+// NewTMDBService creates a new TMDB service instance. apiKey may be a v3 API
+// key or a v4 API Read Access Token; the token is preferred because it is
+// sent in a header instead of the URL.
+func NewTMDBService(apiKey string, client *upstream.Client) *TMDBService {
 	return &TMDBService{
 		apiKey: apiKey,
-		client: &http.Client{},
+		client: client,
+	}
+}
+
+// get issues an authenticated GET request to TMDB. Read Access Tokens (JWTs)
+// go in the Authorization header; v3 keys are only accepted as the api_key
+// query parameter.
+func (s *TMDBService) get(ctx context.Context, endpoint string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.Count(s.apiKey, ".") == 2 {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	} else {
+		query := req.URL.Query()
+		query.Set("api_key", s.apiKey)
+		req.URL.RawQuery = query.Encode()
+	}
+
+	return s.client.Do(req)
+}
+
+// localize adds TMDB's language parameter to endpoint, so titles, overviews
+// and genre names come back translated where TMDB has a translation
+func localize(endpoint, language string) string {
+	if language == "" {
+		return endpoint
 	}
+	separator := "?"
+	if strings.Contains(endpoint, "?") {
+		separator = "&"
+	}
+	return endpoint + separator + "language=" + url.QueryEscape(language)
 }
 
-// SearchMovie searches for a movie by title on TMDB
-func (s *TMDBService) SearchMovie(title string) (*models.TMDBMovie, error) {
+// SearchMovie searches for a movie by title on TMDB, optionally restricted to
+// a release year. When several results match about equally well it returns an
+// *AmbiguousMovieError listing them instead of guessing.
+func (s *TMDBService) SearchMovie(ctx context.Context, title, year, language string) (movie *models.TMDBMovie, err error) {
+	ctx, span := tracing.Start(ctx, "tmdb.search_movie", tracing.AttrMovieTitle.String(title), attribute.String("movie.year", year))
+	defer func() {
+		if movie != nil {
+			span.SetAttributes(tracing.AttrMovieID.Int(movie.ID))
+		}
+		tracing.End(span, err)
+	}()
+
 	// URL encode the title
 	encodedTitle := url.QueryEscape(title)
-	
+
 	// Construct TMDB search endpoint
 	searchURL := fmt.Sprintf(
-		"https://api.themoviedb.org/3/search/movie?api_key=%s&query=%s",
-		s.apiKey,
+		"https://api.themoviedb.org/3/search/movie?query=%s",
 		encodedTitle,
 	)
+	if year != "" {
+		searchURL += "&primary_release_year=" + url.QueryEscape(year)
+	}
 
 	// Make request to TMDB
-	resp, err := s.client.Get(searchURL)
+	resp, err := s.get(ctx, localize(searchURL, language))
 	if err != nil {
 		return nil, fmt.Errorf("failed to search TMDB: %w", err)
 	}
@@ -63,23 +121,47 @@ func (s *TMDBService) SearchMovie(title string) (*models.TMDBMovie, error) {
 
 	// Check if any results were found
 	if len(searchResult.Results) == 0 {
-		return nil, fmt.Errorf("no movies found for title: %s", title)
+		return nil, fmt.Errorf("%w: no movies found for title %q", ErrMovieNotFound, title)
 	}
 
-	// Return the first (best match) result
-	return &searchResult.Results[0], nil
+	// Return the best match only if it is clearly ahead of the alternatives
+	return pickBestMatch(title, year, searchResult.Results)
+}
+
+// GetMovie retrieves a single movie by TMDB ID in the same shape as search results
+func (s *TMDBService) GetMovie(ctx context.Context, id int, language string) (*models.TMDBMovie, error) {
+	details, err := s.GetMovieDetails(ctx, id, language)
+	if err != nil {
+		return nil, err
+	}
+
+	movie := &models.TMDBMovie{
+		ID:            details.ID,
+		Title:         details.Title,
+		OriginalTitle: details.OriginalTitle,
+		ReleaseDate:   details.ReleaseDate,
+		PosterPath:    details.PosterPath,
+		BackdropPath:  details.BackdropPath,
+		VoteAverage:   details.VoteAverage,
+		Overview:      details.Overview,
+		Popularity:    details.Popularity,
+	}
+	for _, genre := range details.Genres {
+		movie.GenreIDs = append(movie.GenreIDs, genre.ID)
+	}
+
+	return movie, nil
 }
 
 // SearchMovies searches for movies by title on TMDB and returns all results
-func (s *TMDBService) SearchMovies(title string) ([]models.TMDBMovie, error) {
+func (s *TMDBService) SearchMovies(ctx context.Context, title, language string) ([]models.TMDBMovie, error) {
 	encodedTitle := url.QueryEscape(title)
 	searchURL := fmt.Sprintf(
-		"https://api.themoviedb.org/3/search/movie?api_key=%s&query=%s",
-		s.apiKey,
+		"https://api.themoviedb.org/3/search/movie?query=%s",
 		encodedTitle,
 	)
 
-	resp, err := s.client.Get(searchURL)
+	resp, err := s.get(ctx, localize(searchURL, language))
 	if err != nil {
 		return nil, fmt.Errorf("failed to search TMDB: %w", err)
 	}
@@ -102,16 +184,60 @@ func (s *TMDBService) SearchMovies(title string) ([]models.TMDBMovie, error) {
 	return searchResult.Results, nil
 }
 
-// GetGenres retrieves all genres from TMDB
-func (s *TMDBService) GetGenres() (map[int]string, error) {
-	// Construct genres endpoint
-	genresURL := fmt.Sprintf(
-		"https://api.themoviedb.org/3/genre/movie/list?api_key=%s",
-		s.apiKey,
+// GetMovieDetails retrieves full details for a movie by TMDB ID, including
+// credits, release dates (for certifications) and keywords
+func (s *TMDBService) GetMovieDetails(ctx context.Context, id int, language string) (_ *models.TMDBMovieDetails, err error) {
+	ctx, span := tracing.Start(ctx, "tmdb.movie_details", tracing.AttrMovieID.Int(id))
+	defer func() { tracing.End(span, err) }()
+
+	detailsURL := fmt.Sprintf(
+		"https://api.themoviedb.org/3/movie/%d?append_to_response=credits,release_dates,keywords",
+		id,
 	)
 
+	resp, err := s.get(ctx, localize(detailsURL, language))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch movie details: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: no movie with TMDB ID %d", ErrMovieNotFound, id)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("TMDB details API error: status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read details response: %w", err)
+	}
+
+	var details models.TMDBMovieDetails
+	if err := json.Unmarshal(body, &details); err != nil {
+		return nil, fmt.Errorf("failed to parse details response: %w", err)
+	}
+
+	return &details, nil
+}
+
+// GetGenres retrieves all movie genres from TMDB, with names in language
+func (s *TMDBService) GetGenres(ctx context.Context, language string) (map[int]string, error) {
+	return s.getGenres(ctx, "movie", language)
+}
+
+// GetTVGenres retrieves all TV genres from TMDB, which differ from the movie genres
+func (s *TMDBService) GetTVGenres(ctx context.Context, language string) (map[int]string, error) {
+	return s.getGenres(ctx, "tv", language)
+}
+
+// getGenres retrieves the genre list for a media type ("movie" or "tv")
+func (s *TMDBService) getGenres(ctx context.Context, mediaType, language string) (map[int]string, error) {
+	// Construct genres endpoint
+	genresURL := fmt.Sprintf("https://api.themoviedb.org/3/genre/%s/list", mediaType)
+
 	// Make request to TMDB
-	resp, err := s.client.Get(genresURL)
+	resp, err := s.get(ctx, localize(genresURL, language))
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch genres: %w", err)
 	}
@@ -144,14 +270,13 @@ func (s *TMDBService) GetGenres() (map[int]string, error) {
 }
 
 // DiscoverMoviesByYear fetches popular movies for a given year from TMDB
-func (s *TMDBService) DiscoverMoviesByYear(year string) ([]models.TMDBMovie, error) {
+func (s *TMDBService) DiscoverMoviesByYear(ctx context.Context, year, language string) ([]models.TMDBMovie, error) {
 	discoverURL := fmt.Sprintf(
-		"https://api.themoviedb.org/3/discover/movie?api_key=%s&primary_release_year=%s&sort_by=popularity.desc&page=1",
-		s.apiKey,
+		"https://api.themoviedb.org/3/discover/movie?primary_release_year=%s&sort_by=popularity.desc&page=1",
 		url.QueryEscape(year),
 	)
 
-	resp, err := s.client.Get(discoverURL)
+	resp, err := s.get(ctx, localize(discoverURL, language))
 	if err != nil {
 		return nil, fmt.Errorf("failed to discover movies: %w", err)
 	}
@@ -182,20 +307,19 @@ type TMDBDiscoverResult struct {
 }
 
 // DiscoverMoviesByDateRange fetches trending (most popular) movies between two years from TMDB (single page)
-func (s *TMDBService) DiscoverMoviesByDateRange(startYear, endYear string, page int) ([]models.TMDBMovie, int, error) {
+func (s *TMDBService) DiscoverMoviesByDateRange(ctx context.Context, startYear, endYear string, page int, language string) ([]models.TMDBMovie, int, error) {
 	if page < 1 {
 		page = 1
 	}
 
 	discoverURL := fmt.Sprintf(
-		"https://api.themoviedb.org/3/discover/movie?api_key=%s&primary_release_date.gte=%s-01-01&primary_release_date.lte=%s-12-31&sort_by=popularity.desc&page=%d",
-		s.apiKey,
+		"https://api.themoviedb.org/3/discover/movie?primary_release_date.gte=%s-01-01&primary_release_date.lte=%s-12-31&sort_by=popularity.desc&page=%d",
 		url.QueryEscape(startYear),
 		url.QueryEscape(endYear),
 		page,
 	)
 
-	resp, err := s.client.Get(discoverURL)
+	resp, err := s.get(ctx, localize(discoverURL, language))
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to discover movies: %w", err)
 	}
@@ -234,6 +358,77 @@ func (s *TMDBService) FormatBackdropURL(backdropPath string) string {
 	return fmt.Sprintf("https://image.tmdb.org/t/p/w1280%s", backdropPath)
 }
 
+// FormatProfileURL formats a cast member's profile image URL
+func (s *TMDBService) FormatProfileURL(profilePath string) string {
+	if profilePath == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://image.tmdb.org/t/p/w185%s", profilePath)
+}
+
+// ExtractTopCast returns the first limit cast members in billing order
+func (s *TMDBService) ExtractTopCast(credits models.TMDBCredits, limit int) []models.CastMember {
+	cast := make([]models.TMDBCastMember, len(credits.Cast))
+	copy(cast, credits.Cast)
+	sort.SliceStable(cast, func(i, j int) bool { return cast[i].Order < cast[j].Order })
+
+	var members []models.CastMember
+	for _, member := range cast {
+		if len(members) == limit {
+			break
+		}
+		members = append(members, models.CastMember{
+			Name:      member.Name,
+			Character: member.Character,
+			Profile:   s.FormatProfileURL(member.ProfilePath),
+		})
+	}
+	return members
+}
+
+// ExtractDirector returns the director's name, joining co-directors
+func (s *TMDBService) ExtractDirector(credits models.TMDBCredits) string {
+	var directors []string
+	for _, member := range credits.Crew {
+		if member.Job == "Director" {
+			directors = append(directors, member.Name)
+		}
+	}
+	return strings.Join(directors, ", ")
+}
+
+// ExtractCertification returns the age rating for a country, preferring the
+// theatrical release (type 3) over other release types
+func (s *TMDBService) ExtractCertification(details *models.TMDBMovieDetails, country string) string {
+	certification := ""
+	for _, releases := range details.ReleaseDates.Results {
+		if releases.Country != country {
+			continue
+		}
+		for _, release := range releases.ReleaseDates {
+			if release.Certification == "" {
+				continue
+			}
+			if release.Type == 3 {
+				return release.Certification
+			}
+			if certification == "" {
+				certification = release.Certification
+			}
+		}
+	}
+	return certification
+}
+
+// ExtractKeywords returns the keyword names for a movie
+func (s *TMDBService) ExtractKeywords(details *models.TMDBMovieDetails) []string {
+	var keywords []string
+	for _, keyword := range details.Keywords.Keywords {
+		keywords = append(keywords, keyword.Name)
+	}
+	return keywords
+}
+
 // ExtractYear extracts year from release date
 func (s *TMDBService) ExtractYear(releaseDate string) string {
 	if len(releaseDate) >= 4 {
@@ -253,10 +448,13 @@ func (s *TMDBService) ExtractGenreNames(genreIDs []int, genreMap map[int]string)
 	return genres
 }
 
-// TruncateOverview truncates overview to first 500 characters
+// TruncateOverview truncates overview to its first maxLength characters.
+// It counts runes rather than bytes so localized overviews are never cut
+// in the middle of a character.
 func (s *TMDBService) TruncateOverview(overview string, maxLength int) string {
-	if len(overview) > maxLength {
-		return strings.TrimSpace(overview[:maxLength]) + "..."
+	if utf8.RuneCountInString(overview) <= maxLength {
+		return overview
 	}
-	return overview
+	runes := []rune(overview)
+	return strings.TrimSpace(string(runes[:maxLength])) + "..."
 }
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"spoiler_api/internal/models"
+)
+
+// inceptionDetails is a trimmed TMDB details response with credits and release dates
+const inceptionDetails = `{
+	"id": 27205,
+	"title": "Inception",
+	"credits": {
+		"cast": [
+			{"name": "Tom Hardy", "character": "Eames", "order": 3, "profile_path": "/hardy.jpg"},
+			{"name": "Leonardo DiCaprio", "character": "Cobb", "order": 0, "profile_path": "/dicaprio.jpg"},
+			{"name": "Elliot Page", "character": "Ariadne", "order": 2},
+			{"name": "Joseph Gordon-Levitt", "character": "Arthur", "order": 1}
+		],
+		"crew": [
+			{"name": "Hans Zimmer", "job": "Original Music Composer"},
+			{"name": "Christopher Nolan", "job": "Director"},
+			{"name": "Christopher Nolan", "job": "Writer"}
+		]
+	},
+	"release_dates": {
+		"results": [
+			{"iso_3166_1": "DE", "release_dates": [{"certification": "12", "type": 3}]},
+			{"iso_3166_1": "US", "release_dates": [
+				{"certification": "", "type": 1},
+				{"certification": "NR", "type": 4},
+				{"certification": "PG-13", "type": 3}
+			]}
+		]
+	}
+}`
+
+func TestExtractCredits(t *testing.T) {
+	var details models.TMDBMovieDetails
+	if err := json.Unmarshal([]byte(inceptionDetails), &details); err != nil {
+		t.Fatal(err)
+	}
+	var tmdb TMDBService
+
+	cast := tmdb.ExtractTopCast(details.Credits, 3)
+	var billed []string
+	for _, member := range cast {
+		billed = append(billed, member.Name+" as "+member.Character)
+	}
+	want := "Leonardo DiCaprio as Cobb, Joseph Gordon-Levitt as Arthur, Elliot Page as Ariadne"
+	if got := strings.Join(billed, ", "); got != want {
+		t.Errorf("ExtractTopCast = %s, want %s", got, want)
+	}
+	if cast[0].Profile != "https://image.tmdb.org/t/p/w185/dicaprio.jpg" || cast[2].Profile != "" {
+		t.Errorf("profiles = %q, %q; want a w185 URL and none for a missing path", cast[0].Profile, cast[2].Profile)
+	}
+	if all := tmdb.ExtractTopCast(details.Credits, 10); len(all) != 4 {
+		t.Errorf("ExtractTopCast with a limit above the cast size returned %d members, want 4", len(all))
+	}
+	if details.Credits.Cast[0].Name != "Tom Hardy" {
+		t.Error("ExtractTopCast reordered the credits it was given")
+	}
+
+	if got := tmdb.ExtractDirector(details.Credits); got != "Christopher Nolan" {
+		t.Errorf("ExtractDirector = %q, want Christopher Nolan", got)
+	}
+	codirected := models.TMDBCredits{Crew: []models.TMDBCrewMember{{Name: "Lana Wachowski", Job: "Director"}, {Name: "Lilly Wachowski", Job: "Director"}}}
+	if got := tmdb.ExtractDirector(codirected); got != "Lana Wachowski, Lilly Wachowski" {
+		t.Errorf("ExtractDirector for co-directors = %q", got)
+	}
+}
+
+func TestExtractCertification(t *testing.T) {
+	var details models.TMDBMovieDetails
+	if err := json.Unmarshal([]byte(inceptionDetails), &details); err != nil {
+		t.Fatal(err)
+	}
+	var tmdb TMDBService
+
+	tests := []struct {
+		country string
+		want    string
+	}{
+		{"US", "PG-13"}, // the theatrical release wins over earlier types
+		{"DE", "12"},
+		{"FR", ""},
+	}
+	for _, tt := range tests {
+		if got := tmdb.ExtractCertification(&details, tt.country); got != tt.want {
+			t.Errorf("ExtractCertification(%s) = %q, want %q", tt.country, got, tt.want)
+		}
+	}
+
+	// Without a theatrical release the first certification is used
+	details.ReleaseDates.Results[1].ReleaseDates = details.ReleaseDates.Results[1].ReleaseDates[:2]
+	if got := tmdb.ExtractCertification(&details, "US"); got != "NR" {
+		t.Errorf("ExtractCertification without a theatrical release = %q, want NR", got)
+	}
+}
+
+func TestTruncateOverview(t *testing.T) {
+	tests := []struct {
+		name      string
+		overview  string
+		maxLength int
+		want      string
+	}{
+		{"short", "A thief enters dreams.", 500, "A thief enters dreams."},
+		{"exact length", "abcde", 5, "abcde"},
+		{"ascii", "A thief enters dreams.", 7, "A thief..."},
+		{"trailing space trimmed", "A thief enters dreams.", 8, "A thief..."},
+		{"accents", "Un ladrón entra en los sueños.", 8, "Un ladró..."},
+		{"amharic", "ሌባ ወደ ሕልሞች ይገባል።", 5, "ሌባ ወደ..."},
+		{"multibyte at the byte limit", strings.Repeat("é", 10), 5, "ééééé..."},
+	}
+
+	var tmdb TMDBService
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tmdb.TruncateOverview(tt.overview, tt.maxLength)
+			if got != tt.want {
+				t.Errorf("TruncateOverview(%q, %d) = %q, want %q", tt.overview, tt.maxLength, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("TruncateOverview(%q, %d) returned invalid UTF-8", tt.overview, tt.maxLength)
+			}
+		})
+	}
+}
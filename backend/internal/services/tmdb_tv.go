@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"spoiler_api/internal/models"
+	"spoiler_api/internal/tracing"
+)
+
+// ErrShowNotFound is returned when TMDB has no show, season or episode with the requested number
+var ErrShowNotFound = errors.New("TV show not found")
+
+// SearchTV searches for TV shows by name on TMDB and returns all results
+func (s *TMDBService) SearchTV(ctx context.Context, query, language string) ([]models.TMDBTVShow, error) {
+	searchURL := fmt.Sprintf(
+		"https://api.themoviedb.org/3/search/tv?query=%s",
+		url.QueryEscape(query),
+	)
+
+	resp, err := s.get(ctx, localize(searchURL, language))
+	if err != nil {
+		return nil, fmt.Errorf("failed to search TMDB: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("TMDB API error: status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TMDB response: %w", err)
+	}
+
+	var searchResult models.TMDBTVSearchResult
+	if err := json.Unmarshal(body, &searchResult); err != nil {
+		return nil, fmt.Errorf("failed to parse TMDB response: %w", err)
+	}
+
+	return searchResult.Results, nil
+}
+
+// GetTVShow retrieves a show by TMDB ID with its season list and credits, localized to language
+func (s *TMDBService) GetTVShow(ctx context.Context, id int, language string) (_ *models.TMDBTVDetails, err error) {
+	ctx, span := tracing.Start(ctx, "tmdb.tv_details", tracing.AttrShowID.Int(id))
+	defer func() { tracing.End(span, err) }()
+
+	detailsURL := fmt.Sprintf(
+		"https://api.themoviedb.org/3/tv/%d?append_to_response=credits",
+		id,
+	)
+
+	resp, err := s.get(ctx, localize(detailsURL, language))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch TV show: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: no show with TMDB ID %d", ErrShowNotFound, id)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("TMDB TV API error: status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TV show response: %w", err)
+	}
+
+	var show models.TMDBTVDetails
+	if err := json.Unmarshal(body, &show); err != nil {
+		return nil, fmt.Errorf("failed to parse TV show response: %w", err)
+	}
+
+	return &show, nil
+}
+
+// GetTVSeason retrieves a season of a show with all of its episodes
+func (s *TMDBService) GetTVSeason(ctx context.Context, id, seasonNumber int, language string) (_ *models.TMDBSeason, err error) {
+	ctx, span := tracing.Start(ctx, "tmdb.tv_season", tracing.AttrShowID.Int(id), tracing.AttrSeason.Int(seasonNumber))
+	defer func() { tracing.End(span, err) }()
+
+	seasonURL := fmt.Sprintf(
+		"https://api.themoviedb.org/3/tv/%d/season/%d",
+		id,
+		seasonNumber,
+	)
+
+	resp, err := s.get(ctx, localize(seasonURL, language))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch TV season: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: show %d has no season %d", ErrShowNotFound, id, seasonNumber)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("TMDB season API error: status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TV season response: %w", err)
+	}
+
+	var season models.TMDBSeason
+	if err := json.Unmarshal(body, &season); err != nil {
+		return nil, fmt.Errorf("failed to parse TV season response: %w", err)
+	}
+
+	return &season, nil
+}
+
+// FindEpisode returns an episode of a season by its number
+func (s *TMDBService) FindEpisode(season *models.TMDBSeason, showID, episodeNumber int) (*models.TMDBEpisode, error) {
+	for i := range season.Episodes {
+		if season.Episodes[i].EpisodeNumber == episodeNumber {
+			return &season.Episodes[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: show %d has no episode %d in season %d", ErrShowNotFound, showID, episodeNumber, season.SeasonNumber)
+}
+
+// FormatStillURL formats an episode still image URL
+func (s *TMDBService) FormatStillURL(stillPath string) string {
+	if stillPath == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://image.tmdb.org/t/p/w300%s", stillPath)
+}
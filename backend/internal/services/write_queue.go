@@ -0,0 +1,397 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"spoiler_api/internal/metrics"
+	"spoiler_api/internal/models"
+	"spoiler_api/internal/tracing"
+)
+
+const (
+	writeOpSave        = "save"
+	writeOpSaveOutline = "save_outline"
+	writeOpIncrement   = "increment"
+	writeOpDone        = "done"
+
+	writeRetryBaseDelay = time.Second
+	writeRetryMaxDelay  = time.Minute
+)
+
+// queuedWrite is one record in the write-behind journal. save, save_outline
+// and increment records add a pending write; a done record removes the write
+// with that ID.
+type queuedWrite struct {
+	ID         uint64                `json:"id"`
+	Op         string                `json:"op"`
+	TMDBID     int                   `json:"tmdb_id,omitempty"`
+	Language   string                `json:"language,omitempty"`
+	Movie      *models.MovieResponse `json:"movie,omitempty"`
+	Outline    string                `json:"outline,omitempty"`
+	EnqueuedAt time.Time             `json:"enqueued_at"`
+}
+
+// WriteQueueStats describes the writes that have not reached the store yet
+type WriteQueueStats struct {
+	Depth                int     `json:"depth"`
+	OldestPendingSeconds float64 `json:"oldest_pending_seconds"`
+}
+
+// WriteQueue is a durable write-behind queue in front of a MovieStore. Writes
+// are appended to a local journal file before being applied, retried with
+// backoff until the store accepts them, and replayed from the journal after a
+// restart. Writes are applied one at a time in the order they were queued; a
+// write the store rejects with ErrWriteRejected is dropped as a dead letter so
+// it cannot hold up the ones behind it.
+type WriteQueue struct {
+	store MovieStore
+	path  string
+
+	mu      sync.Mutex
+	file    *os.File
+	nextID  uint64
+	pending []queuedWrite
+	idle    chan struct{} // closed when pending empties while Drain is waiting
+
+	wake chan struct{}
+	stop chan struct{}
+}
+
+// NewWriteQueue opens the journal at path, reloads any writes left pending by
+// a previous run and starts applying them to store
+func NewWriteQueue(store MovieStore, path string) (*WriteQueue, error) {
+	q := &WriteQueue{
+		store: store,
+		path:  path,
+		wake:  make(chan struct{}, 1),
+		stop:  make(chan struct{}),
+	}
+
+	if err := q.replay(); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open write queue journal: %w", err)
+	}
+	q.file = file
+
+	if len(q.pending) > 0 {
+		slog.Info("Replaying pending database writes", "pending", len(q.pending), "path", path)
+	}
+
+	go q.run()
+	return q, nil
+}
+
+// EnqueueSave queues a movie to be inserted or replaced in the store
+func (q *WriteQueue) EnqueueSave(movie models.MovieResponse) error {
+	return q.enqueue(queuedWrite{Op: writeOpSave, TMDBID: movie.TMDBID, Language: movie.Language, Movie: &movie})
+}
+
+// EnqueueSaveOutline queues a movie's scene outline in language to be stored,
+// replacing any stored copy. The store must implement OutlineStore.
+func (q *WriteQueue) EnqueueSaveOutline(tmdbID int, language, outline string) error {
+	return q.enqueue(queuedWrite{Op: writeOpSaveOutline, TMDBID: tmdbID, Language: language, Outline: outline})
+}
+
+// EnqueueIncrement queues a search count increment for a stored movie in language
+func (q *WriteQueue) EnqueueIncrement(tmdbID int, language string) error {
+	return q.enqueue(queuedWrite{Op: writeOpIncrement, TMDBID: tmdbID, Language: language})
+}
+
+// Stats returns the current queue depth and the age of the oldest pending write
+func (q *WriteQueue) Stats() WriteQueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := WriteQueueStats{Depth: len(q.pending)}
+	if len(q.pending) > 0 {
+		stats.OldestPendingSeconds = time.Since(q.pending[0].EnqueuedAt).Seconds()
+	}
+	return stats
+}
+
+// Drain waits until every pending write has been applied or ctx is done, then
+// stops the queue and closes the journal. It returns the number of writes
+// left in the journal; they are replayed at the next start.
+func (q *WriteQueue) Drain(ctx context.Context) int {
+	q.mu.Lock()
+	if len(q.pending) > 0 {
+		if q.idle == nil {
+			q.idle = make(chan struct{})
+		}
+		idle := q.idle
+		q.mu.Unlock()
+
+		select {
+		case <-idle:
+		case <-ctx.Done():
+		}
+		q.mu.Lock()
+	}
+	defer q.mu.Unlock()
+
+	close(q.stop)
+	if err := q.file.Close(); err != nil {
+		slog.Error("Failed to close write queue journal", "error", err)
+	}
+	q.file = nil
+
+	return len(q.pending)
+}
+
+// enqueue journals a write and hands it to the worker. Saves are synced so a
+// generated spoiler or outline survives a crash; increments are not, since losing one
+// only loses a search count, and done records replay an idempotent save at
+// worst. The sync happens outside q.mu so it does not stall other requests
+// queueing writes or the worker completing them.
+func (q *WriteQueue) enqueue(write queuedWrite) error {
+	q.mu.Lock()
+	if q.file == nil {
+		q.mu.Unlock()
+		return errors.New("write queue is closed")
+	}
+
+	q.nextID++
+	write.ID = q.nextID
+	write.EnqueuedAt = time.Now()
+
+	if err := q.appendRecord(write); err != nil {
+		q.mu.Unlock()
+		return err
+	}
+	q.pending = append(q.pending, write)
+	file := q.file
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	// The write is queued either way; a failed sync only means it might not
+	// survive a crash. A journal closed by Drain meanwhile keeps it for replay.
+	if write.Op == writeOpSave || write.Op == writeOpSaveOutline {
+		if err := file.Sync(); err != nil && !errors.Is(err, os.ErrClosed) {
+			slog.Warn("Failed to sync write queue journal", "op", write.Op, "tmdb_id", write.TMDBID, "error", err)
+		}
+	}
+	return nil
+}
+
+// run applies pending writes in order, backing off while the store is failing
+func (q *WriteQueue) run() {
+	delay := writeRetryBaseDelay
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.mu.Unlock()
+			select {
+			case <-q.wake:
+				continue
+			case <-q.stop:
+				return
+			}
+		}
+		write := q.pending[0]
+		q.mu.Unlock()
+
+		err := q.apply(write)
+		if errors.Is(err, ErrWriteRejected) {
+			metrics.DatabaseWriteFailures.WithLabelValues(write.Op).Inc()
+			metrics.DatabaseWritesDropped.WithLabelValues(write.Op).Inc()
+			slog.Error("Database rejected queued write, dropping it", "op", write.Op, "tmdb_id", write.TMDBID, "language", write.Language, "enqueued_at", write.EnqueuedAt, "error", err)
+			q.complete(write)
+			continue
+		}
+		if err != nil {
+			metrics.DatabaseWriteFailures.WithLabelValues(write.Op).Inc()
+			slog.Warn("Database write failed, retrying", "op", write.Op, "tmdb_id", write.TMDBID, "retry_in", delay.String(), "pending", q.Stats().Depth, "error", err)
+			select {
+			case <-time.After(delay):
+			case <-q.stop:
+				return
+			}
+			delay = min(delay*2, writeRetryMaxDelay)
+			continue
+		}
+
+		delay = writeRetryBaseDelay
+		q.complete(write)
+	}
+}
+
+// apply performs a single queued write against the store. Writes outlive the
+// request that queued them, so each one is traced as its own root span.
+func (q *WriteQueue) apply(write queuedWrite) (err error) {
+	ctx, span := tracing.Start(context.Background(), "write_queue.apply",
+		attribute.String("write.op", write.Op),
+		tracing.AttrMovieID.Int(write.TMDBID),
+		attribute.Float64("write.queued_seconds", time.Since(write.EnqueuedAt).Seconds()),
+	)
+	defer func() { tracing.End(span, err) }()
+
+	switch write.Op {
+	case writeOpSave:
+		if write.Movie == nil {
+			return fmt.Errorf("%w: queued save %d has no movie", ErrWriteRejected, write.ID)
+		}
+		if err := q.store.SaveMovie(ctx, write.Movie); err != nil {
+			return err
+		}
+		slog.Info("Saved movie to database", "title", write.Movie.Title, "year", write.Movie.Year, "tmdb_id", write.TMDBID)
+		return nil
+	case writeOpSaveOutline:
+		outlines, ok := q.store.(OutlineStore)
+		if !ok {
+			return fmt.Errorf("%w: the store does not keep scene outlines", ErrWriteRejected)
+		}
+		if err := outlines.SaveOutline(ctx, write.TMDBID, storedLanguage(write.Language), write.Outline); err != nil {
+			return err
+		}
+		slog.Info("Saved scene outline to database", "tmdb_id", write.TMDBID, "language", write.Language)
+		return nil
+	case writeOpIncrement:
+		// Increments journaled before languages existed have none and count against DefaultLanguage
+		return q.store.IncrementSearchCount(ctx, write.TMDBID, storedLanguage(write.Language))
+	default:
+		slog.Warn("Skipping unknown queued write", "op", write.Op, "id", write.ID)
+		return nil
+	}
+}
+
+// complete removes an applied write from the queue and the journal. Once the
+// queue is empty the journal is truncated so it does not grow without bound.
+func (q *WriteQueue) complete(write queuedWrite) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.pending = q.pending[1:]
+
+	if q.file != nil {
+		var err error
+		if len(q.pending) == 0 {
+			err = q.file.Truncate(0)
+		} else {
+			err = q.appendRecord(queuedWrite{ID: write.ID, Op: writeOpDone})
+		}
+		if err != nil {
+			slog.Error("Failed to update write queue journal", "error", err)
+		}
+	}
+
+	if len(q.pending) == 0 && q.idle != nil {
+		close(q.idle)
+		q.idle = nil
+	}
+}
+
+// appendRecord writes one JSON line to the journal; callers hold q.mu
+func (q *WriteQueue) appendRecord(record queuedWrite) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal queued write: %w", err)
+	}
+	if _, err := q.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to append to write queue journal: %w", err)
+	}
+	return nil
+}
+
+// replay loads the writes still pending in the journal and rewrites it with
+// only those, dropping records for writes that already completed
+func (q *WriteQueue) replay() error {
+	file, err := os.Open(q.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open write queue journal: %w", err)
+	}
+	defer file.Close()
+
+	var order []uint64
+	writes := make(map[uint64]queuedWrite)
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var record queuedWrite
+			if jsonErr := json.Unmarshal(line, &record); jsonErr != nil {
+				// A crash mid-append leaves a partial last line; skip it
+				slog.Warn("Skipping unreadable write queue record", "error", jsonErr)
+			} else {
+				q.nextID = max(q.nextID, record.ID)
+				if record.Op == writeOpDone {
+					delete(writes, record.ID)
+				} else {
+					order = append(order, record.ID)
+					writes[record.ID] = record
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read write queue journal: %w", err)
+		}
+	}
+
+	for _, id := range order {
+		if write, ok := writes[id]; ok {
+			q.pending = append(q.pending, write)
+		}
+	}
+
+	return q.compact()
+}
+
+// compact atomically replaces the journal with the pending writes only
+func (q *WriteQueue) compact() error {
+	tmpPath := q.path + ".tmp"
+	tmp, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to compact write queue journal: %w", err)
+	}
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, write := range q.pending {
+		if err := encoder.Encode(write); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to compact write queue journal: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to compact write queue journal: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to compact write queue journal: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to compact write queue journal: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, q.path); err != nil {
+		return fmt.Errorf("failed to compact write queue journal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"spoiler_api/internal/models"
+)
+
+// recordingStore is a MovieStore that records the writes applied to it.
+// Writes fail with err while it is set, and saves of a movie in reject are
+// refused with ErrWriteRejected.
+type recordingStore struct {
+	mu      sync.Mutex
+	err     error
+	reject  map[int]bool
+	applied []string
+}
+
+func (s *recordingStore) FindMovie(ctx context.Context, tmdbID int, language string) (*models.MovieResponse, error) {
+	return nil, nil
+}
+
+func (s *recordingStore) SaveMovie(ctx context.Context, movie *models.MovieResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	if s.reject[movie.TMDBID] {
+		return fmt.Errorf("%w: status 400", ErrWriteRejected)
+	}
+	s.applied = append(s.applied, fmt.Sprintf("save %d %s", movie.TMDBID, movie.Language))
+	return nil
+}
+
+func (s *recordingStore) IncrementSearchCount(ctx context.Context, tmdbID int, language string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	s.applied = append(s.applied, fmt.Sprintf("increment %d %s", tmdbID, language))
+	return nil
+}
+
+func (s *recordingStore) ListTrending(ctx context.Context, language string, limit int) ([]models.MovieResponse, error) {
+	return nil, nil
+}
+
+func (s *recordingStore) DeleteMovie(ctx context.Context, tmdbID int) error {
+	return nil
+}
+
+// Applied returns the writes applied so far, in order
+func (s *recordingStore) Applied() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.applied...)
+}
+
+// drain drains q within timeout and returns the number of writes left
+func drain(t *testing.T, q *WriteQueue, timeout time.Duration) int {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return q.Drain(ctx)
+}
+
+func TestWriteQueueReplaysPendingWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "writes.jsonl")
+
+	// The database is down: both writes stay in the journal
+	down := &recordingStore{err: errors.New("connection refused")}
+	q, err := NewWriteQueue(down, path)
+	if err != nil {
+		t.Fatalf("NewWriteQueue: %v", err)
+	}
+	if err := q.EnqueueSave(models.MovieResponse{TMDBID: 27205, Language: "es"}); err != nil {
+		t.Fatalf("EnqueueSave: %v", err)
+	}
+	if err := q.EnqueueIncrement(603, ""); err != nil {
+		t.Fatalf("EnqueueIncrement: %v", err)
+	}
+	if left := drain(t, q, 50*time.Millisecond); left != 2 {
+		t.Fatalf("Drain left %d writes, want 2", left)
+	}
+
+	// The next run applies them in order; the increment journaled without a language counts as English
+	up := &recordingStore{}
+	q, err = NewWriteQueue(up, path)
+	if err != nil {
+		t.Fatalf("NewWriteQueue after restart: %v", err)
+	}
+	if left := drain(t, q, 5*time.Second); left != 0 {
+		t.Fatalf("Drain after restart left %d writes, want 0", left)
+	}
+
+	want := []string{"save 27205 es", "increment 603 en"}
+	if got := up.Applied(); strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("applied %v, want %v", got, want)
+	}
+}
+
+func TestWriteQueueReplaySkipsCompletedAndTornRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "writes.jsonl")
+	journal := `{"id":1,"op":"save","tmdb_id":1,"movie":{"tmdb_id":1,"title":"A"},"enqueued_at":"2026-01-01T00:00:00Z"}
+{"id":2,"op":"increment","tmdb_id":2,"language":"es","enqueued_at":"2026-01-01T00:00:00Z"}
+{"id":1,"op":"done","enqueued_at":"0001-01-01T00:00:00Z"}
+{"id":3,"op":"incr`
+	if err := os.WriteFile(path, []byte(journal), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := &recordingStore{}
+	q, err := NewWriteQueue(store, path)
+	if err != nil {
+		t.Fatalf("NewWriteQueue: %v", err)
+	}
+	if left := drain(t, q, 5*time.Second); left != 0 {
+		t.Fatalf("Drain left %d writes, want 0", left)
+	}
+
+	want := []string{"increment 2 es"}
+	if got := store.Applied(); strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("applied %v, want %v", got, want)
+	}
+
+	// New writes are numbered after the highest readable journaled ID
+	if q.nextID != 2 {
+		t.Errorf("nextID = %d, want 2", q.nextID)
+	}
+}
+
+func TestWriteQueueDropsRejectedWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "writes.jsonl")
+	store := &recordingStore{reject: map[int]bool{13: true}}
+
+	q, err := NewWriteQueue(store, path)
+	if err != nil {
+		t.Fatalf("NewWriteQueue: %v", err)
+	}
+
+	writes := []func() error{
+		func() error { return q.EnqueueSave(models.MovieResponse{TMDBID: 13, Language: "en"}) },
+		func() error { return q.EnqueueSave(models.MovieResponse{TMDBID: 14, Language: "en"}) },
+		func() error { return q.EnqueueIncrement(14, "en") },
+		func() error { return q.enqueue(queuedWrite{Op: writeOpSave, TMDBID: 15}) },
+		func() error { return q.EnqueueIncrement(14, "en") },
+	}
+	for i, write := range writes {
+		if err := write(); err != nil {
+			t.Fatalf("write %d: %v", i+1, err)
+		}
+	}
+
+	if left := drain(t, q, 5*time.Second); left != 0 {
+		t.Fatalf("Drain left %d writes, want the rejected ones dropped", left)
+	}
+
+	want := []string{"save 14 en", "increment 14 en", "increment 14 en"}
+	if got := store.Applied(); strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("applied %v, want %v", got, want)
+	}
+
+	if info, err := os.Stat(path); err != nil || info.Size() != 0 {
+		t.Errorf("journal not emptied after the queue drained: %v, %v", info, err)
+	}
+}
+
+func TestWriteQueueConcurrentEnqueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "writes.jsonl")
+
+	// The database is down, so every write is still journaled at shutdown
+	q, err := NewWriteQueue(&recordingStore{err: errors.New("connection refused")}, path)
+	if err != nil {
+		t.Fatalf("NewWriteQueue: %v", err)
+	}
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			var err error
+			if id%2 == 0 {
+				err = q.EnqueueSave(models.MovieResponse{TMDBID: id, Language: "en"})
+			} else {
+				err = q.EnqueueIncrement(id, "en")
+			}
+			if err != nil {
+				t.Errorf("enqueue %d: %v", id, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if left := drain(t, q, 50*time.Millisecond); left != writers {
+		t.Fatalf("Drain left %d writes, want %d", left, writers)
+	}
+	if err := q.EnqueueSave(models.MovieResponse{TMDBID: 1}); err == nil {
+		t.Error("EnqueueSave after Drain succeeded, want an error")
+	}
+
+	store := &recordingStore{}
+	q, err = NewWriteQueue(store, path)
+	if err != nil {
+		t.Fatalf("NewWriteQueue after restart: %v", err)
+	}
+	if left := drain(t, q, 5*time.Second); left != 0 {
+		t.Fatalf("Drain after restart left %d writes, want 0", left)
+	}
+	if applied := store.Applied(); len(applied) != writers {
+		t.Errorf("applied %d writes after restart, want %d", len(applied), writers)
+	}
+}
+
+// outlineRecordingStore is a recordingStore that also keeps scene outlines
+type outlineRecordingStore struct {
+	*recordingStore
+}
+
+func (s outlineRecordingStore) FindOutline(ctx context.Context, tmdbID int, language string) (string, error) {
+	return "", nil
+}
+
+func (s outlineRecordingStore) SaveOutline(ctx context.Context, tmdbID int, language, outline string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	s.applied = append(s.applied, fmt.Sprintf("outline %d %s", tmdbID, language))
+	return nil
+}
+
+func (s outlineRecordingStore) DeleteOutline(ctx context.Context, tmdbID int) error {
+	return nil
+}
+
+func TestWriteQueueSavesOutlines(t *testing.T) {
+	tests := []struct {
+		name  string
+		store MovieStore
+		want  []string
+	}{
+		{name: "outline store", store: outlineRecordingStore{&recordingStore{}}, want: []string{"outline 27205 es", "increment 27205 es"}},
+		{name: "store without outlines drops them", store: &recordingStore{}, want: []string{"increment 27205 es"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "writes.jsonl")
+
+			// The outline survives a restart like any other write
+			q, err := NewWriteQueue(outlineRecordingStore{&recordingStore{err: errors.New("connection refused")}}, path)
+			if err != nil {
+				t.Fatalf("NewWriteQueue: %v", err)
+			}
+			if err := q.EnqueueSaveOutline(27205, "es", "- [0] **Opening** — It begins."); err != nil {
+				t.Fatalf("EnqueueSaveOutline: %v", err)
+			}
+			if err := q.EnqueueIncrement(27205, "es"); err != nil {
+				t.Fatalf("EnqueueIncrement: %v", err)
+			}
+			if left := drain(t, q, 50*time.Millisecond); left != 2 {
+				t.Fatalf("Drain left %d writes, want 2", left)
+			}
+
+			q, err = NewWriteQueue(tt.store, path)
+			if err != nil {
+				t.Fatalf("NewWriteQueue after restart: %v", err)
+			}
+			if left := drain(t, q, 5*time.Second); left != 0 {
+				t.Fatalf("Drain after restart left %d writes, want 0", left)
+			}
+
+			var applied []string
+			switch store := tt.store.(type) {
+			case outlineRecordingStore:
+				applied = store.Applied()
+			case *recordingStore:
+				applied = store.Applied()
+			}
+			if strings.Join(applied, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("applied %v, want %v", applied, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Package tracing configures OpenTelemetry tracing. Spans can be exported to
+// an OTLP/HTTP collector, or written as JSON to stdout or a file when no
+// collector is available.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"spoiler_api/internal/logging"
+)
+
+// serviceName identifies this process in traces
+const serviceName = "spoilerhub-api"
+
+// instrumentationName names the tracer used across the application
+const instrumentationName = "spoiler_api"
+
+// Span attribute keys shared by handlers and services
+const (
+	AttrMovieID          = attribute.Key("movie.tmdb_id")
+	AttrMovieTitle       = attribute.Key("movie.title")
+	AttrShowID           = attribute.Key("tv.tmdb_id")
+	AttrSeason           = attribute.Key("tv.season")
+	AttrEpisode          = attribute.Key("tv.episode")
+	AttrLanguage         = attribute.Key("spoiler.language")
+	AttrCacheTier        = attribute.Key("spoiler.cache_tier")
+	AttrStoreBackend     = attribute.Key("store.backend")
+	AttrStoreHit         = attribute.Key("store.hit")
+	AttrLLMProvider      = attribute.Key("llm.provider")
+	AttrLLMModel         = attribute.Key("llm.model")
+	AttrPromptTokens     = attribute.Key("llm.usage.prompt_tokens")
+	AttrCompletionTokens = attribute.Key("llm.usage.completion_tokens")
+)
+
+// Setup installs the global tracer provider for exporter, which is one of
+// "none", "otlp", "stdout" or "file" (writing to path). The OTLP exporter
+// reads its endpoint from the standard OTEL_EXPORTER_OTLP_* variables. The
+// returned function flushes and stops the exporter.
+func Setup(ctx context.Context, exporter, path string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var spanExporter sdktrace.SpanExporter
+	var closer io.Closer
+	var err error
+
+	switch exporter {
+	case "none", "":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		spanExporter, err = otlptracehttp.New(ctx)
+	case "stdout":
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "file":
+		var file *os.File
+		file, err = os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err == nil {
+			closer = file
+			spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+		}
+	default:
+		return nil, fmt.Errorf("unknown TRACING_EXPORTER %q (expected none, otlp, stdout or file)", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", serviceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			if closeErr := closer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}, nil
+}
+
+// Tracer returns the application tracer from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start starts a span as a child of any span in ctx
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err (if any) on span, with secrets redacted, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		message := logging.Redact(err.Error())
+		span.RecordError(errors.New(message))
+		span.SetStatus(codes.Error, message)
+	}
+	span.End()
+}
+
+// SetAttributes adds attributes to the span in ctx, if any
+func SetAttributes(ctx context.Context, attrs ...attribute.KeyValue) {
+	trace.SpanFromContext(ctx).SetAttributes(attrs...)
+}
@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"spoiler_api/internal/logging"
+)
+
+func TestSetupUnknownExporter(t *testing.T) {
+	if _, err := Setup(context.Background(), "jaeger", ""); err == nil {
+		t.Error("Setup accepted an unknown exporter")
+	}
+}
+
+func TestEndRecordsRedactedError(t *testing.T) {
+	secret := "tracing-test-secret-0123456789"
+	logging.RegisterSecrets(secret)
+
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	shutdown, err := Setup(context.Background(), "file", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, span := Start(context.Background(), "test.operation", AttrMovieID.Int(27205))
+	End(span, errors.New("upstream rejected key "+secret))
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trace := string(written)
+	for _, want := range []string{`"Name":"test.operation"`, `"Code":"Error"`, "movie.tmdb_id", "upstream rejected key"} {
+		if !strings.Contains(trace, want) {
+			t.Errorf("exported span lacks %s:\n%s", want, trace)
+		}
+	}
+	if strings.Contains(trace, secret) {
+		t.Errorf("exported span leaks the secret:\n%s", trace)
+	}
+}
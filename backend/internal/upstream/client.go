@@ -0,0 +1,433 @@
+// Package upstream provides the resilient HTTP client shared by the TMDB, LLM
+// and Supabase services: per-service timeouts, retries with exponential
+// backoff and jitter, a circuit breaker, and typed errors that handlers can
+// map to 503/504 responses.
+package upstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"spoiler_api/internal/metrics"
+	"spoiler_api/internal/tracing"
+)
+
+var (
+	// ErrUnavailable means the upstream kept failing or its circuit is open (maps to 503)
+	ErrUnavailable = errors.New("upstream unavailable")
+	// ErrTimeout means the upstream did not answer within the service timeout (maps to 504)
+	ErrTimeout = errors.New("upstream timeout")
+	// ErrRateLimited means the upstream kept answering 429 (maps to 503 with Retry-After)
+	ErrRateLimited = errors.New("upstream rate limited")
+	// ErrRejected means the upstream refused the request itself, e.g. with a 400 or 401 (maps to 503)
+	ErrRejected = errors.New("upstream rejected the request")
+	// ErrCircuitOpen means the call was rejected without contacting the upstream
+	ErrCircuitOpen = errors.New("circuit breaker open")
+)
+
+// Error describes a failed upstream call. Kind is one of ErrUnavailable,
+// ErrTimeout, ErrRateLimited or ErrRejected and can be tested with errors.Is.
+type Error struct {
+	Service    string
+	Kind       error
+	StatusCode int
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.StatusCode != 0 && e.Err != nil:
+		return fmt.Sprintf("%s: %v (status code %d): %v", e.Service, e.Kind, e.StatusCode, e.Err)
+	case e.StatusCode != 0:
+		return fmt.Sprintf("%s: %v (status code %d)", e.Service, e.Kind, e.StatusCode)
+	case e.Err != nil:
+		return fmt.Sprintf("%s: %v: %v", e.Service, e.Kind, e.Err)
+	default:
+		return fmt.Sprintf("%s: %v", e.Service, e.Kind)
+	}
+}
+
+// Unwrap exposes both the kind and the underlying cause to errors.Is/As
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// Options configures a Client. Zero values fall back to the defaults below.
+type Options struct {
+	// Timeout bounds a single attempt, including reading the response body
+	Timeout time.Duration
+	// MaxRetries is the number of retries after the first attempt; negative disables retries
+	MaxRetries int
+	// BaseDelay and MaxDelay bound the exponential backoff between attempts
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// FailureThreshold consecutive failures open the circuit for Cooldown
+	FailureThreshold int
+	Cooldown         time.Duration
+	// Transport sends the requests; nil uses http.DefaultTransport
+	Transport http.RoundTripper
+}
+
+const (
+	defaultTimeout          = 10 * time.Second
+	defaultMaxRetries       = 3
+	defaultBaseDelay        = 500 * time.Millisecond
+	defaultMaxDelay         = 10 * time.Second
+	defaultFailureThreshold = 5
+	defaultCooldown         = 30 * time.Second
+
+	// maxErrorBody is how much of a rejected response's body ResponseError quotes
+	maxErrorBody = 256
+)
+
+// Client is an http.Client replacement with retries and a circuit breaker
+type Client struct {
+	service string
+	client  *http.Client
+	opts    Options
+	breaker *breaker
+}
+
+// New creates a client for the named upstream service
+func New(service string, opts Options) *Client {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.MaxRetries < 0 {
+		opts.MaxRetries = 0
+	} else if opts.MaxRetries == 0 {
+		opts.MaxRetries = defaultMaxRetries
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = defaultBaseDelay
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = defaultMaxDelay
+	}
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = defaultFailureThreshold
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = defaultCooldown
+	}
+
+	return &Client{
+		service: service,
+		client:  &http.Client{Timeout: opts.Timeout, Transport: opts.Transport},
+		opts:    opts,
+		breaker: &breaker{threshold: opts.FailureThreshold, cooldown: opts.Cooldown},
+	}
+}
+
+// Get issues a GET request
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// Post issues a POST request. body must be replayable for retries
+// (bytes.Buffer, bytes.Reader and strings.Reader are).
+func (c *Client) Post(ctx context.Context, url, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return c.Do(req)
+}
+
+// Do sends a request, retrying network errors, 429 and 5xx responses with
+// backoff. Other responses, including 4xx, are returned to the caller as-is.
+// Exhausted retries, timeouts and an open circuit return an *Error. Each call
+// is traced as a client span under the span in the request context.
+func (c *Client) Do(req *http.Request) (resp *http.Response, err error) {
+	ctx, span := tracing.Tracer().Start(req.Context(), fmt.Sprintf("%s %s", c.service, req.Method),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("peer.service", c.service),
+			attribute.String("http.request.method", req.Method),
+			attribute.String("server.address", req.URL.Host),
+			attribute.String("url.path", req.URL.Path),
+		),
+	)
+	defer func() {
+		if resp != nil {
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		}
+		tracing.End(span, err)
+	}()
+
+	req = req.WithContext(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	allowed, probe := c.breaker.allow()
+	if !allowed {
+		metrics.UpstreamRequests.WithLabelValues(c.service, "circuit_open").Inc()
+		return nil, &Error{Service: c.service, Kind: ErrUnavailable, Err: ErrCircuitOpen}
+	}
+	// A probe that ends without a verdict must not keep the circuit half-open forever
+	defer func() {
+		if probe {
+			c.breaker.release()
+		}
+	}()
+
+	for attempt := 0; ; attempt++ {
+		span.SetAttributes(attribute.Int("http.request.resend_count", attempt))
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		start := time.Now()
+		resp, err := c.client.Do(req)
+		metrics.UpstreamRequestDuration.WithLabelValues(c.service).Observe(time.Since(start).Seconds())
+		stripQuery(err)
+		if err != nil && ctx.Err() != nil {
+			// The caller gave up or ran out of its own time; neither is the
+			// upstream's fault, so only the per-attempt timeout counts against it
+			metrics.UpstreamRequests.WithLabelValues(c.service, "canceled").Inc()
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, &Error{Service: c.service, Kind: ErrTimeout, Err: err}
+			}
+			return nil, err
+		}
+
+		failure := c.classify(resp, err)
+		metrics.UpstreamRequests.WithLabelValues(c.service, outcomeCode(resp, failure)).Inc()
+		if failure == nil {
+			c.breaker.success()
+			probe = false
+			return resp, nil
+		}
+
+		// A rate limit says nothing about upstream health
+		if errors.Is(failure.Kind, ErrRateLimited) {
+			if probe {
+				c.breaker.release()
+				probe = false
+			}
+		} else {
+			c.breaker.failure()
+			probe = false
+		}
+
+		// Timeouts are not retried: another full-length attempt would keep the handler waiting
+		retryable := !errors.Is(failure.Kind, ErrTimeout) && attempt < c.opts.MaxRetries && req.Context().Err() == nil
+		if retryable && (req.Body != nil && req.GetBody == nil) {
+			retryable = false
+		}
+
+		delay := c.backoff(attempt)
+		if failure.RetryAfter > 0 {
+			if failure.RetryAfter > c.opts.MaxDelay {
+				retryable = false
+			}
+			delay = failure.RetryAfter
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if !retryable {
+			return nil, failure
+		}
+		if allowed, probe = c.breaker.allow(); !allowed {
+			return nil, failure
+		}
+
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, failure
+		}
+	}
+}
+
+// ResponseError turns a response that Do returned but the caller cannot use,
+// such as a 400 or 401, into an ErrRejected error and closes its body. Only
+// the start of the body is quoted: upstream error pages can be large and are
+// not meant for our clients.
+func (c *Client) ResponseError(resp *http.Response) *Error {
+	defer resp.Body.Close()
+
+	failure := &Error{Service: c.service, Kind: ErrRejected, StatusCode: resp.StatusCode}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if text := strings.TrimSpace(strings.ToValidUTF8(string(body), "")); text != "" {
+		failure.Err = errors.New(text)
+	}
+	return failure
+}
+
+// classify returns nil for a usable response, or the typed failure
+func (c *Client) classify(resp *http.Response, err error) *Error {
+	if err != nil {
+		var netErr net.Error
+		if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+			return &Error{Service: c.service, Kind: ErrTimeout, Err: err}
+		}
+		return &Error{Service: c.service, Kind: ErrUnavailable, Err: err}
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &Error{
+			Service:    c.service,
+			Kind:       ErrRateLimited,
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	case resp.StatusCode == http.StatusGatewayTimeout:
+		return &Error{Service: c.service, Kind: ErrTimeout, StatusCode: resp.StatusCode}
+	case resp.StatusCode >= 500:
+		return &Error{
+			Service:    c.service,
+			Kind:       ErrUnavailable,
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	default:
+		return nil
+	}
+}
+
+// stripQuery removes the query string from the URL quoted in a transport
+// error, since some upstreams only accept credentials as query parameters
+func stripQuery(err error) {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		if before, _, found := strings.Cut(urlErr.URL, "?"); found {
+			urlErr.URL = before
+		}
+	}
+}
+
+// outcomeCode labels an attempt with its status code, or the failure kind
+// when no response was received
+func outcomeCode(resp *http.Response, failure *Error) string {
+	switch {
+	case resp != nil:
+		return strconv.Itoa(resp.StatusCode)
+	case errors.Is(failure.Kind, ErrTimeout):
+		return "timeout"
+	default:
+		return "error"
+	}
+}
+
+// backoff returns a full-jitter exponential delay for the given attempt
+func (c *Client) backoff(attempt int) time.Duration {
+	ceiling := c.opts.BaseDelay << attempt
+	if ceiling <= 0 || ceiling > c.opts.MaxDelay {
+		ceiling = c.opts.MaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(ceiling))) + 1
+}
+
+// parseRetryAfter reads a Retry-After header in seconds or HTTP-date form
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(at); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// breaker is a consecutive-failure circuit breaker. After threshold failures
+// it opens for cooldown; then a single probe request is let through and its
+// outcome closes or re-opens the circuit.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// allow reports whether a request may be sent, and whether it is the probe
+// of a half-open circuit. A probe must end in success, failure or release.
+func (b *breaker) allow() (allowed, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true, false
+	}
+	if time.Now().Before(b.openUntil) || b.probing {
+		return false, false
+	}
+	b.probing = true
+	return true, true
+}
+
+// release ends a probe that said nothing about upstream health (it was rate
+// limited or cancelled), letting the next request probe instead
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// success closes the circuit
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures = 0
+	b.probing = false
+}
+
+// failure records a failed request, opening the circuit at the threshold
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.probing = false
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
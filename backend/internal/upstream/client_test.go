@@ -0,0 +1,197 @@
+package upstream
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testCooldown = 20 * time.Millisecond
+
+// newTestClient returns a client whose circuit opens after one failure and
+// which does not retry, so each call is a single attempt
+func newTestClient() *Client {
+	return New("test", Options{
+		Timeout:          time.Second,
+		MaxRetries:       -1,
+		FailureThreshold: 1,
+		Cooldown:         testCooldown,
+	})
+}
+
+func TestBreakerProbeWithoutVerdict(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		// probeStatus is what the upstream answers the probe; 0 cancels it instead
+		probeStatus int
+		wantErr     error
+	}{
+		{name: "rate limited probe", probeStatus: http.StatusTooManyRequests, wantErr: ErrRateLimited},
+		{name: "cancelled probe", wantErr: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var status atomic.Int32
+			status.Store(http.StatusInternalServerError)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(int(status.Load()))
+			}))
+			defer server.Close()
+
+			client := newTestClient()
+			get := func(ctx context.Context) error {
+				resp, err := client.Get(ctx, server.URL)
+				if err == nil {
+					resp.Body.Close()
+				}
+				return err
+			}
+
+			if err := get(context.Background()); !errors.Is(err, ErrUnavailable) {
+				t.Fatalf("first call: got %v, want ErrUnavailable", err)
+			}
+			if err := get(context.Background()); !errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("call during cooldown: got %v, want ErrCircuitOpen", err)
+			}
+			time.Sleep(2 * testCooldown)
+
+			ctx := context.Background()
+			if tt.probeStatus == 0 {
+				ctx = cancelled
+			} else {
+				status.Store(int32(tt.probeStatus))
+			}
+			if err := get(ctx); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("probe: got %v, want %v", err, tt.wantErr)
+			}
+
+			status.Store(http.StatusOK)
+			if err := get(context.Background()); err != nil {
+				t.Fatalf("call after probe: got %v, want the circuit to let it through", err)
+			}
+			if err := get(context.Background()); err != nil {
+				t.Fatalf("call after recovery: got %v, want a closed circuit", err)
+			}
+		})
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int
+		wait       time.Duration
+		wantAllow  bool
+		wantProbe  bool
+		secondCall bool // whether a second concurrent request is also allowed
+	}{
+		{name: "closed", failures: 2, wantAllow: true, secondCall: true},
+		{name: "open", failures: 3},
+		{name: "half-open lets one probe through", failures: 3, wait: 2 * testCooldown, wantAllow: true, wantProbe: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &breaker{threshold: 3, cooldown: testCooldown}
+			for i := 0; i < tt.failures; i++ {
+				b.failure()
+			}
+			time.Sleep(tt.wait)
+
+			allowed, probe := b.allow()
+			if allowed != tt.wantAllow || probe != tt.wantProbe {
+				t.Fatalf("allow() = %v, %v; want %v, %v", allowed, probe, tt.wantAllow, tt.wantProbe)
+			}
+			if again, _ := b.allow(); again != tt.secondCall {
+				t.Fatalf("second allow() = %v, want %v", again, tt.secondCall)
+			}
+		})
+	}
+}
+
+func TestBreakerProbeOutcome(t *testing.T) {
+	tests := []struct {
+		name      string
+		verdict   func(*breaker)
+		wantAllow bool
+	}{
+		{name: "success closes", verdict: (*breaker).success, wantAllow: true},
+		{name: "failure reopens", verdict: (*breaker).failure, wantAllow: false},
+		{name: "release allows another probe", verdict: (*breaker).release, wantAllow: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &breaker{threshold: 1, cooldown: testCooldown}
+			b.failure()
+			time.Sleep(2 * testCooldown)
+
+			if allowed, probe := b.allow(); !allowed || !probe {
+				t.Fatalf("allow() = %v, %v; want a probe", allowed, probe)
+			}
+			tt.verdict(b)
+			if allowed, _ := b.allow(); allowed != tt.wantAllow {
+				t.Fatalf("allow() after verdict = %v, want %v", allowed, tt.wantAllow)
+			}
+		})
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"` + strings.Repeat("x", 4096) + `"}}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient()
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("a 400 is returned to the caller, got %v", err)
+	}
+
+	failure := client.ResponseError(resp)
+	if !errors.Is(failure, ErrRejected) || failure.StatusCode != http.StatusBadRequest {
+		t.Errorf("got %v, want a rejection with status 400", failure)
+	}
+	if failure.Err == nil || len(failure.Err.Error()) > maxErrorBody {
+		t.Errorf("quoted body %v, want at most %d bytes of it", failure.Err, maxErrorBody)
+	}
+
+	// A rejection is the caller's problem, not a sign the upstream is down
+	if allowed, _ := client.breaker.allow(); !allowed {
+		t.Error("circuit opened after a 400")
+	}
+}
+
+func TestCallerDeadlineDoesNotTripBreaker(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// The caller's deadline is far shorter than the client's own timeout
+	_, err := client.Get(ctx, server.URL)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("got %v, want ErrTimeout", err)
+	}
+	if allowed, probe := client.breaker.allow(); !allowed || probe {
+		t.Errorf("allow() = %v, %v after the caller's deadline, want a closed circuit", allowed, probe)
+	}
+}
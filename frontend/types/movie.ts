@@ -3,8 +3,12 @@
  * Keep in sync with backend/internal/models/movie.go
  */
 
+/** How much of the plot a spoiler reveals (the level parameter of /api/movie) */
+export type SpoilerLevel = "teaser" | "partial" | "full";
+
 /** Single movie — returned by /api/movie and as items in lists */
 export interface Movie {
+  tmdb_id: number;
   title: string;
   year: string;
   poster: string;
@@ -13,9 +17,49 @@ export interface Movie {
   genres: string[];
   overview: string;
   spoiler?: string; // Only present when fetched with spoiler
+  level?: SpoilerLevel; // How much of the plot spoiler reveals
+  language?: string; // Language code the spoiler is written in, e.g. "es"
+  structured?: StructuredSpoiler; // Server-parsed form of spoiler
   runtime?: number; // Runtime in minutes (from TMDB detail endpoint)
 }
 
+/** Top-billed cast member from /api/movie/:id */
+export interface CastMember {
+  name: string;
+  character: string;
+  profile: string;
+}
+
+/** Full metadata returned by /api/movie/:id */
+export interface MovieDetails extends Omit<Movie, "spoiler" | "structured"> {
+  runtime: number;
+  tagline: string;
+  certification: string;
+  director: string;
+  cast: CastMember[];
+  keywords: string[];
+}
+
+/** One timestamped scene of a movie's outline */
+export interface Scene {
+  minute: number;
+  title: string;
+  description: string;
+}
+
+/** Plot up to a minute of the movie — returned by /api/movie/progress */
+export interface ProgressResponse {
+  tmdb_id: number;
+  title: string;
+  year: string;
+  poster: string;
+  runtime: number;
+  minute: number; // Clamped to runtime
+  language: string;
+  scenes: Scene[]; // Scenes starting at or before minute
+  finished: boolean;
+}
+
 /** Parsed character fate from Gemini spoiler text */
 export interface CharacterFate {
   name: string;
@@ -38,6 +82,18 @@ export interface StorySection {
   content: string;
 }
 
+/** Structured spoiler parsed and validated by the backend */
+export interface StructuredSpoiler {
+  overview: string;
+  story_sections: Omit<StorySection, "icon">[];
+  key_moments: KeyMoment[];
+  character_fates: CharacterFate[];
+  symbolism: string[];
+  hidden_clues: string[];
+  fan_theories: string[];
+  unanswered_questions: string[];
+}
+
 /** Parsed interpretation section */
 export interface InterpretationSection {
   title: string;
@@ -60,7 +116,42 @@ export interface TrendingResponse {
   count: number;
 }
 
+/** One possible match in a 300 Multiple Choices response from /api/movie */
+export interface MovieCandidate {
+  tmdb_id: number;
+  title: string;
+  year: string;
+  poster: string;
+  rating: number;
+  overview: string;
+}
+
+/** Stable error codes; each always comes with the same HTTP status */
+export type ErrorCode =
+  | 'not_found'
+  | 'ambiguous'
+  | 'upstream_unavailable'
+  | 'upstream_timeout'
+  | 'rate_limited'
+  | 'invalid_input'
+  | 'generation_refused'
+  | 'unauthorized'
+  | 'forbidden'
+  | 'internal';
+
 /** Backend error response */
 export interface ApiError {
+  code: ErrorCode;
   error: string;
+  details?: Record<string, unknown>;
+  /** Seconds to wait before retrying, for rate_limited and some upstream_unavailable and upstream_timeout errors */
+  retry_after?: number;
+}
+
+/** Response shape when a title matches several movies */
+export interface AmbiguousMovieResponse extends ApiError {
+  code: 'ambiguous';
+  details: {
+    candidates: MovieCandidate[];
+  };
 }
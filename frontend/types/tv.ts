@@ -0,0 +1,64 @@
+/**
+ * TypeScript interfaces matching the backend TV API responses.
+ * Keep in sync with backend/internal/models/tv.go
+ */
+
+/** One season in a show's season list */
+export interface SeasonSummary {
+  season_number: number;
+  name: string;
+  episode_count: number;
+  air_date: string;
+  poster: string;
+}
+
+/** TV show — returned by /api/tv/:id and as items in /api/tv/search */
+export interface TVShow {
+  tmdb_id: number;
+  name: string;
+  year: string;
+  poster: string;
+  backdrop: string;
+  rating: number;
+  genres: string[];
+  overview: string;
+  status?: string;
+  number_of_seasons?: number;
+  seasons?: SeasonSummary[];
+  spoiler?: string; // Whole-series spoiler, only from /api/tv/:id
+}
+
+/** Single episode — returned by /api/tv/:id/season/:n/episode/:e and in seasons */
+export interface Episode {
+  show_id: number;
+  show_name?: string;
+  season_number: number;
+  episode_number: number;
+  name: string;
+  air_date: string;
+  overview: string;
+  runtime: number;
+  still: string;
+  rating: number;
+  spoiler?: string; // Episode breakdown, only from the episode endpoint
+}
+
+/** Season with its recap — returned by /api/tv/:id/season/:n */
+export interface Season {
+  show_id: number;
+  show_name: string;
+  season_number: number;
+  name: string;
+  air_date: string;
+  overview: string;
+  poster: string;
+  episodes: Episode[];
+  spoiler?: string;
+}
+
+/** Response shape from /api/tv/search */
+export interface TVSearchResponse {
+  shows: TVShow[];
+  count: number;
+  query: string;
+}